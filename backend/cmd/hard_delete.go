@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"time"
 
 	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -28,11 +30,79 @@ func main() {
 	}
 
 	fmt.Println("Successfully hard deleted old books.")
+
+	// Covers of purged books are only removed once no other book uses them
+	removed, err := services.DeleteOrphanedCovers(context.Background(), db, services.NewBlobStore())
+	if err != nil {
+		log.Fatalf("failed to delete orphaned covers: %v", err)
+	}
+	fmt.Printf("Deleted %d orphaned cover images.\n", removed)
 }
 
 func hardDeleteOldBooks(db *gorm.DB) error {
 	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
 
-	result := db.Unscoped().Where("deleted_at < ?", thirtyDaysAgo).Delete(&models.Book{})
-	return result.Error
+	return db.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Unscoped().Model(&models.Book{}).Select("id").Where("deleted_at < ?", thirtyDaysAgo)
+
+		// Remember when the purged books were finished so their owners' goal history can be recomputed
+		var finished []struct {
+			Auth0ID    string
+			FinishedAt time.Time
+		}
+		if err := tx.Unscoped().Model(&models.Book{}).
+			Select("users.auth0_id, books.finished_at").
+			Joins("JOIN users ON users.id = books.user_id").
+			Where("books.deleted_at < ? AND books.finished_at IS NOT NULL", thirtyDaysAgo).
+			Scan(&finished).Error; err != nil {
+			return err
+		}
+
+		// Clear the join tables, notes, sessions, read-throughs, reviews, loans and copies first so they don't keep rows for books that no longer exist
+		if err := tx.Where("book_id IN (?)", expired).Delete(&models.BookShelf{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN (?)", expired).Delete(&models.BookAuthor{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN (?)", expired).Delete(&models.Note{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN (?)", expired).Delete(&models.Highlight{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN (?)", expired).Delete(&models.ReadingSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN (?)", expired).Delete(&models.ReadThrough{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN (?)", expired).Delete(&models.Review{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN (?)", expired).Delete(&models.Loan{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN (?)", expired).Delete(&models.BookCopy{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN (?)", expired).Delete(&models.BookSearch{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("deleted_at < ?", thirtyDaysAgo).Delete(&models.Book{}).Error; err != nil {
+			return err
+		}
+
+		dates := map[string][]time.Time{}
+		for _, book := range finished {
+			dates[book.Auth0ID] = append(dates[book.Auth0ID], book.FinishedAt)
+		}
+		goals := services.NewGoalService(tx)
+		for auth0ID, finishedAt := range dates {
+			if err := goals.RecomputeGoalHistory(context.Background(), auth0ID, finishedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
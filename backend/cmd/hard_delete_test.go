@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	// Foreign keys are enforced like they are on Postgres
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.Book{}, &models.ReadingSession{}, &models.ReadThrough{}, &models.Shelf{}, &models.BookShelf{}, &models.Series{}, &models.Author{}, &models.BookAuthor{}, &models.Note{}, &models.Highlight{}, &models.Loan{}, &models.BookCopy{}, &models.WishlistItem{}, &models.CustomField{}, &models.BookSearch{}, &models.SmartShelf{}, &models.Review{}, &models.GoalHistory{}, &models.StreakSettings{})
+	if err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	return db
+}
+
+func TestHardDeleteOldBooks(t *testing.T) {
+	db := setupTestDB(t)
+	user := models.User{Auth0ID: "test-auth0-id", Email: "test@example.com"}
+	assert.NoError(t, db.Create(&user).Error)
+
+	finished := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	reread := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	expired := models.Book{Title: "Dune", Author: "Frank Herbert", UserID: user.ID, FinishedAt: &finished}
+	recent := models.Book{Title: "Emma", Author: "Jane Austen", UserID: user.ID, FinishedAt: &finished}
+	assert.NoError(t, db.Create(&expired).Error)
+	assert.NoError(t, db.Create(&recent).Error)
+	assert.NoError(t, db.Create(&models.ReadThrough{BookID: expired.ID, UserID: user.ID, FinishedAt: &reread}).Error)
+	assert.NoError(t, db.Create(&models.ReadThrough{BookID: recent.ID, UserID: user.ID, FinishedAt: &reread}).Error)
+
+	assert.NoError(t, db.Model(&expired).Update("deleted_at", time.Now().AddDate(0, 0, -31)).Error)
+	assert.NoError(t, db.Model(&recent).Update("deleted_at", time.Now().AddDate(0, 0, -1)).Error)
+
+	assert.NoError(t, hardDeleteOldBooks(db))
+
+	var books []models.Book
+	assert.NoError(t, db.Unscoped().Find(&books).Error)
+	if assert.Len(t, books, 1) {
+		assert.Equal(t, recent.ID, books[0].ID)
+	}
+
+	// Only the read-throughs of the purged book go with it
+	var readThroughs []models.ReadThrough
+	assert.NoError(t, db.Find(&readThroughs).Error)
+	if assert.Len(t, readThroughs, 1) {
+		assert.Equal(t, recent.ID, readThroughs[0].BookID)
+	}
+}
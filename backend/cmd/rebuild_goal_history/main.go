@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Rebuilds goal history from finished books for one user, or for every user
+// when -user is not given. With -dry-run the differences are only printed.
+func main() {
+	userID := flag.String("user", "", "Auth0 ID of the user to rebuild (default: all users)")
+	dryRun := flag.Bool("dry-run", false, "print the changes without saving them")
+	flag.Parse()
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Fatal("DATABASE_URL environment variable not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	users := []string{*userID}
+	if *userID == "" {
+		users = nil
+		if err := db.Model(&models.User{}).Order("id").Pluck("auth0_id", &users).Error; err != nil {
+			log.Fatalf("failed to fetch users: %v", err)
+		}
+	}
+
+	goals := services.NewGoalService(db)
+	for _, auth0ID := range users {
+		rebuild, err := goals.RebuildGoalHistory(context.Background(), auth0ID, *dryRun)
+		if err != nil {
+			log.Fatalf("failed to rebuild goal history for %s: %v", auth0ID, err)
+		}
+		printRebuild(auth0ID, rebuild)
+	}
+
+	if *dryRun {
+		fmt.Println("Dry run, no goal history was changed.")
+	} else {
+		fmt.Printf("Rebuilt goal history for %d users.\n", len(users))
+	}
+}
+
+func printRebuild(auth0ID string, rebuild *services.GoalHistoryRebuild) {
+	fmt.Printf("%s (%s, target %d): %d created, %d updated, %d removed, %d unchanged\n",
+		auth0ID, rebuild.Interval, rebuild.Target, rebuild.Created, rebuild.Updated, rebuild.Removed, rebuild.Unchanged)
+	for _, change := range rebuild.Changes {
+		switch change.Action {
+		case services.GoalHistoryCreated:
+			fmt.Printf("  + %s\n", formatGoalHistory(change.After))
+		case services.GoalHistoryRemoved:
+			fmt.Printf("  - %s\n", formatGoalHistory(change.Before))
+		case services.GoalHistoryUpdated:
+			fmt.Printf("  - %s\n  + %s\n", formatGoalHistory(change.Before), formatGoalHistory(change.After))
+		}
+	}
+}
+
+func formatGoalHistory(history *models.GoalHistory) string {
+	return fmt.Sprintf("%s %s..%s achieved %d/%d completed=%t",
+		history.Interval, history.StartDate.Format("2006-01-02"), history.EndDate.Format("2006-01-02"),
+		history.Achieved, history.Target, history.WasCompleted)
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type AuthorController struct {
+	AuthorService services.AuthorService
+}
+
+func NewAuthorController(db *gorm.DB) *AuthorController {
+	return &AuthorController{
+		AuthorService: services.NewAuthorService(db),
+	}
+}
+
+// ListAuthors handles GET /api/authors
+func (c *AuthorController) ListAuthors(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	authors, err := c.AuthorService.ListAuthors(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "fetch authors", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"authors": authors,
+	})
+}
+
+// GetAuthor handles GET /api/authors/{id}
+func (c *AuthorController) GetAuthor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	author, err := c.AuthorService.GetAuthor(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "fetch author", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(author)
+}
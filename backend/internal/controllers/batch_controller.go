@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"gorm.io/gorm"
+)
+
+type BatchController struct {
+	BatchService services.BatchService
+}
+
+func NewBatchController(db *gorm.DB) *BatchController {
+	return &BatchController{
+		BatchService: services.NewBatchService(db),
+	}
+}
+
+// ApplyBatch handles POST /api/books/batch. An all-or-nothing batch that was
+// rolled back answers 422 with the per-operation results.
+func (c *BatchController) ApplyBatch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Mode       string                    `json:"mode"`
+		Operations []services.BatchOperation `json:"operations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	result, err := c.BatchService.ApplyBatch(r.Context(), userID, req.Mode, req.Operations)
+	if err != nil {
+		writeServiceError(w, "apply batch", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !result.Applied {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -3,8 +3,12 @@ package controllers
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
@@ -15,26 +19,52 @@ import (
 )
 
 type BookController struct {
-	BookService services.BookService
+	BookService        services.BookService
+	ReadThroughService services.ReadThroughService
+	DuplicateService   services.DuplicateService
 }
 
 func NewBookController(db *gorm.DB) *BookController {
 	return &BookController{
-		BookService: services.NewBookService(db),
+		BookService:        services.NewBookService(db),
+		ReadThroughService: services.NewReadThroughService(db),
+		DuplicateService:   services.NewDuplicateService(db),
 	}
 }
 
 // AddBook handles POST /api/books/add
 func (bc *BookController) AddBook(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Title      string     `json:"title"`
-		Author     string     `json:"author"`
-		CoverImage string     `json:"coverImage"`
-		Rating     float64    `json:"rating"`
-		PageCount  uint       `json:"pageCount"`
-		Genre      string     `json:"genre"`
-		StartedAt  *time.Time `json:"started_at"`
-		FinishedAt *time.Time `json:"finished_at"`
+		Title       string     `json:"title"`
+		Author      string     `json:"author"`
+		ISBN        string     `json:"isbn"`
+		CoverImage  string     `json:"coverImage"`
+		Rating      float64    `json:"rating"`
+		PageCount   uint       `json:"pageCount"`
+		Genre       string     `json:"genre"`
+		Status      string     `json:"status"`
+		StartedAt   *time.Time `json:"started_at"`
+		FinishedAt  *time.Time `json:"finished_at"`
+		StoppedPage *uint      `json:"stopped_page"`
+		Reread      bool       `json:"reread"`
+
+		Format          string `json:"format"`
+		ISBN10          string `json:"isbn10"`
+		ISBN13          string `json:"isbn13"`
+		Publisher       string `json:"publisher"`
+		PublishDate     string `json:"publish_date"`
+		Language        string `json:"language"`
+		DurationMinutes uint   `json:"duration_minutes"`
+		Narrator        string `json:"narrator"`
+
+		PurchasePrice    *float64   `json:"purchase_price"`
+		PurchaseCurrency string     `json:"purchase_currency"`
+		PurchasedAt      *time.Time `json:"purchased_at"`
+		PurchaseStore    string     `json:"purchase_store"`
+
+		CustomFields models.CustomFieldValues `json:"custom_fields"`
+
+		Authors authorCredits `json:"authors"`
 	}
 
 	// Decode the request payload
@@ -46,6 +76,9 @@ func (bc *BookController) AddBook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate required fields
+	if req.Author == "" {
+		req.Author = services.FormatAuthorNames(req.Authors.toModel())
+	}
 	if req.Title == "" || req.Author == "" {
 		http.Error(w, "Title and Author are required", http.StatusBadRequest)
 		return
@@ -59,7 +92,12 @@ func (bc *BookController) AddBook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if the book already exists in the user's collection
-	existingBook, err := bc.BookService.FindBookByTitleAndUser(r.Context(), req.Title, userID)
+	existingBook, err := bc.DuplicateService.FindDuplicate(r.Context(), userID, models.Book{
+		Title:   req.Title,
+		Author:  req.Author,
+		ISBN:    req.ISBN,
+		Authors: req.Authors.toModel(),
+	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to check existing books: %v", err), http.StatusInternalServerError)
 		return
@@ -84,6 +122,27 @@ func (bc *BookController) AddBook(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// Re-adding a book that was already read starts another read-through of it
+		if req.Reread {
+			startedAt := time.Now()
+			if req.StartedAt != nil {
+				startedAt = *req.StartedAt
+			}
+			book, err := bc.ReadThroughService.StartReread(r.Context(), userID, fmt.Sprintf("%d", existingBook.ID), startedAt, "")
+			if err != nil {
+				writeServiceError(w, "start re-read", err)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": "Started a new read of this book",
+				"book":    book,
+			})
+			return
+		}
+
 		// Book exists without a DeletedAt date
 		http.Error(w, "This book already exists in your collection", http.StatusConflict)
 		return
@@ -93,89 +152,52 @@ func (bc *BookController) AddBook(w http.ResponseWriter, r *http.Request) {
 	book := models.Book{
 		Title:      req.Title,
 		Author:     req.Author,
+		ISBN:       services.NormalizeISBN(req.ISBN),
 		CoverImage: req.CoverImage,
 		Rating:     req.Rating,
 		PageCount:  req.PageCount,
 		Genre:      req.Genre,
 		StartedAt:  req.StartedAt,
 		FinishedAt: req.FinishedAt,
+		Authors:    req.Authors.toModel(),
+
+		Format:          req.Format,
+		ISBN10:          req.ISBN10,
+		ISBN13:          req.ISBN13,
+		Publisher:       req.Publisher,
+		PublishDate:     req.PublishDate,
+		Language:        req.Language,
+		DurationMinutes: req.DurationMinutes,
+		Narrator:        req.Narrator,
+
+		PurchasePrice:    req.PurchasePrice,
+		PurchaseCurrency: req.PurchaseCurrency,
+		PurchasedAt:      req.PurchasedAt,
+		PurchaseStore:    req.PurchaseStore,
+
+		CustomFields: req.CustomFields,
+	}
+
+	// An explicit status fills in whichever timestamps it implies
+	if req.Status != "" {
+		if err := book.TransitionTo(req.Status, time.Now(), req.StoppedPage); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 	}
 
 	// Save the new book to the database
 	err = bc.BookService.AddBook(r.Context(), userID, book)
+	if services.IsValidationError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to save book: %v", err), http.StatusInternalServerError)
 		fmt.Println("Error saving book:", err)
 		return
 	}
 
-	// If the book is being added as finished, record it in goal history
-	if req.FinishedAt != nil {
-		fmt.Printf("New book added as finished. Recording goal history for user %s\n", userID)
-
-		// Get the user's streak settings to determine the goal interval
-		var streakSettings models.StreakSettings
-		if err := bc.BookService.GetDB().Where("auth0_id = ?", userID).First(&streakSettings).Error; err != nil {
-			// If no settings exist, use default yearly interval
-			fmt.Printf("No streak settings found, using default yearly interval\n")
-			streakSettings.GoalInterval = "yearly"
-		} else {
-			fmt.Printf("Found streak settings with interval: %s\n", streakSettings.GoalInterval)
-		}
-
-		// Get the user's reading goal
-		readingGoal, err := bc.BookService.GetReadingGoal(r.Context(), userID)
-		if err != nil {
-			fmt.Printf("Error getting reading goal: %v\n", err)
-			readingGoal = 0
-		}
-		fmt.Printf("User's reading goal: %d\n", readingGoal)
-
-		// Get the interval start date based on when the book was finished
-		intervalStart := getIntervalStart(*req.FinishedAt, streakSettings.GoalInterval)
-		fmt.Printf("Interval start date: %v\n", intervalStart)
-
-		// Count books completed in this interval
-		var booksInInterval int64
-		var user models.User
-		if err := bc.BookService.GetDB().Where("auth0_id = ?", userID).First(&user).Error; err != nil {
-			fmt.Printf("Error finding user: %v\n", err)
-			return
-		}
-		fmt.Printf("Found user with ID: %d\n", user.ID)
-
-		// Count books finished in the same interval as this book
-		if err := bc.BookService.GetDB().Model(&models.Book{}).
-			Where("user_id = ? AND finished_at IS NOT NULL AND finished_at >= ? AND finished_at <= ?",
-				user.ID,
-				intervalStart,
-				time.Date(req.FinishedAt.Year(), req.FinishedAt.Month(), req.FinishedAt.Day(), 23, 59, 59, 999999999, req.FinishedAt.Location()),
-			).
-			Count(&booksInInterval).Error; err != nil {
-			fmt.Printf("Error counting books in interval: %v\n", err)
-		}
-		fmt.Printf("Found %d books completed in interval\n", booksInInterval)
-
-		// Record the goal completion
-		goalHistory := models.GoalHistory{
-			Auth0ID:      userID,
-			Interval:     streakSettings.GoalInterval,
-			Target:       readingGoal,
-			Achieved:     int(booksInInterval),
-			StartDate:    intervalStart,
-			EndDate:      *req.FinishedAt,
-			WasCompleted: int(booksInInterval) >= readingGoal,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
-		}
-
-		if err := bc.BookService.GetDB().Create(&goalHistory).Error; err != nil {
-			fmt.Printf("Error recording goal history: %v\n", err)
-		} else {
-			fmt.Printf("Successfully recorded goal history: %+v\n", goalHistory)
-		}
-	}
-
 	// Return a success response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -184,6 +206,24 @@ func (bc *BookController) AddBook(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// authorCredits lists the people credited on a book, e.g.
+// [{"name": "Haruki Murakami"}, {"name": "Jay Rubin", "role": "translator"}]
+type authorCredits []struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+func (credits authorCredits) toModel() []models.BookAuthor {
+	if len(credits) == 0 {
+		return nil
+	}
+	authors := make([]models.BookAuthor, len(credits))
+	for i, credit := range credits {
+		authors[i] = models.BookAuthor{Role: credit.Role, Author: models.Author{Name: credit.Name}}
+	}
+	return authors
+}
+
 // GetUserBooks handles GET /api/books/collection
 func (bc *BookController) GetUserBooks(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
@@ -192,19 +232,152 @@ func (bc *BookController) GetUserBooks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	books, err := bc.BookService.GetUserBooks(r.Context(), userID)
+	query, err := parseBookQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := query.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := bc.BookService.QueryUserBooks(r.Context(), userID, query)
+	if services.IsValidationError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to fetch user books", http.StatusInternalServerError)
 		fmt.Println("Error fetching user books:", err)
 		return
 	}
 
-	response := map[string]interface{}{
-		"books": books,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// parseBookQuery reads the collection filters, sorting and paging from the query string
+func parseBookQuery(r *http.Request) (services.BookQuery, error) {
+	params := r.URL.Query()
+	query := services.BookQuery{
+		Search: params.Get("q"),
+		Sort:   params.Get("sort"),
+		Order:  strings.ToLower(params.Get("order")),
+		Cursor: params.Get("cursor"),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	query.Genres = splitListParam(params.Get("genre"))
+	query.Statuses = splitListParam(params.Get("status"))
+	query.Shelves = splitListParam(params.Get("shelf"))
+	query.ShelfMatch = strings.ToLower(params.Get("shelf_match"))
+	query.CustomFields = parseCustomFieldFilters(params)
+
+	var err error
+	if query.MinRating, err = parseFloatParam(params.Get("min_rating"), "min_rating"); err != nil {
+		return query, err
+	}
+	if query.MaxRating, err = parseFloatParam(params.Get("max_rating"), "max_rating"); err != nil {
+		return query, err
+	}
+	if query.StartedAfter, err = parseDateParam(params.Get("started_after"), "started_after", false); err != nil {
+		return query, err
+	}
+	if query.StartedBefore, err = parseDateParam(params.Get("started_before"), "started_before", true); err != nil {
+		return query, err
+	}
+	if query.FinishedAfter, err = parseDateParam(params.Get("finished_after"), "finished_after", false); err != nil {
+		return query, err
+	}
+	if query.FinishedBefore, err = parseDateParam(params.Get("finished_before"), "finished_before", true); err != nil {
+		return query, err
+	}
+	if query.Page, err = parseIntParam(params.Get("page"), "page"); err != nil {
+		return query, err
+	}
+	if query.PageSize, err = parseIntParam(params.Get("page_size"), "page_size"); err != nil {
+		return query, err
+	}
+
+	return query, nil
+}
+
+// parseCustomFieldFilters reads the custom field filters, given as field.<key>=value,
+// or field.<key>.min and field.<key>.max for ranges of numbers and dates
+func parseCustomFieldFilters(params url.Values) []services.CustomFieldFilter {
+	var filters []services.CustomFieldFilter
+	for name, values := range params {
+		key, ok := strings.CutPrefix(name, "field.")
+		if !ok || len(values) == 0 {
+			continue
+		}
+		op := services.CustomFieldEquals
+		if field, suffix, ok := strings.Cut(key, "."); ok {
+			key, op = field, suffix
+		}
+		filters = append(filters, services.CustomFieldFilter{Key: key, Op: op, Value: values[0]})
+	}
+
+	// Keep the generated query the same between requests
+	sort.Slice(filters, func(i, j int) bool {
+		if filters[i].Key != filters[j].Key {
+			return filters[i].Key < filters[j].Key
+		}
+		return filters[i].Op < filters[j].Op
+	})
+	return filters
+}
+
+// splitListParam splits a comma separated query value, dropping empty entries
+func splitListParam(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseFloatParam(value string, name string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return &f, nil
+}
+
+func parseIntParam(value string, name string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return i, nil
+}
+
+// parseDateParam accepts RFC3339 timestamps or plain YYYY-MM-DD dates. A plain date
+// used as an upper bound covers the whole day.
+func parseDateParam(value string, name string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
 }
 
 // DeleteBook handles DELETE /api/books/{id}
@@ -273,115 +446,100 @@ func (bc *BookController) UpdateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the existing book first
-	existingBook, err := bc.BookService.GetBookByID(r.Context(), userID, bookID)
+	// Parse request body. Only the fields present in it are changed.
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to fetch book: %v", err), http.StatusInternalServerError)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
-
-	// Parse request body
+	var sent map[string]json.RawMessage
 	var req struct {
-		Title      string     `json:"title"`
-		Author     string     `json:"author"`
-		CoverImage string     `json:"coverImage"`
-		Rating     float64    `json:"rating"`
-		PageCount  uint       `json:"pageCount"`
-		Genre      string     `json:"genre"`
-		StartedAt  *time.Time `json:"started_at"`
-		FinishedAt *time.Time `json:"finished_at"`
+		Title       *string    `json:"title"`
+		Author      *string    `json:"author"`
+		ISBN        *string    `json:"isbn"`
+		CoverImage  *string    `json:"coverImage"`
+		Rating      *float64   `json:"rating"`
+		PageCount   *uint      `json:"pageCount"`
+		Genre       *string    `json:"genre"`
+		Status      *string    `json:"status"`
+		StartedAt   *time.Time `json:"started_at"`
+		FinishedAt  *time.Time `json:"finished_at"`
+		StoppedPage *uint      `json:"stopped_page"`
+
+		Format          *string `json:"format"`
+		ISBN10          *string `json:"isbn10"`
+		ISBN13          *string `json:"isbn13"`
+		Publisher       *string `json:"publisher"`
+		PublishDate     *string `json:"publish_date"`
+		Language        *string `json:"language"`
+		DurationMinutes *uint   `json:"duration_minutes"`
+		Narrator        *string `json:"narrator"`
+
+		PurchasePrice    *float64   `json:"purchase_price"`
+		PurchaseCurrency *string    `json:"purchase_currency"`
+		PurchasedAt      *time.Time `json:"purchased_at"`
+		PurchaseStore    *string    `json:"purchase_store"`
+
+		CustomFields models.CustomFieldValues `json:"custom_fields"`
+
+		Authors authorCredits `json:"authors"`
+	}
+	if err := json.Unmarshal(body, &sent); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
 	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
 
-	// Update the book
-	book := models.Book{
-		Title:      req.Title,
-		Author:     req.Author,
-		CoverImage: req.CoverImage,
-		Rating:     req.Rating,
-		PageCount:  req.PageCount,
-		Genre:      req.Genre,
-		StartedAt:  req.StartedAt,
-		FinishedAt: req.FinishedAt,
+	patch := services.BookPatch{
+		Title:       req.Title,
+		Author:      req.Author,
+		ISBN:        req.ISBN,
+		CoverImage:  req.CoverImage,
+		Rating:      req.Rating,
+		PageCount:   req.PageCount,
+		Genre:       req.Genre,
+		Status:      req.Status,
+		StoppedPage: req.StoppedPage,
+		StartedAt:   nullable(sent, "started_at", req.StartedAt),
+		FinishedAt:  nullable(sent, "finished_at", req.FinishedAt),
+
+		Format:          req.Format,
+		ISBN10:          req.ISBN10,
+		ISBN13:          req.ISBN13,
+		Publisher:       req.Publisher,
+		PublishDate:     req.PublishDate,
+		Language:        req.Language,
+		DurationMinutes: req.DurationMinutes,
+		Narrator:        req.Narrator,
+
+		PurchasePrice:    nullable(sent, "purchase_price", req.PurchasePrice),
+		PurchaseCurrency: req.PurchaseCurrency,
+		PurchasedAt:      nullable(sent, "purchased_at", req.PurchasedAt),
+		PurchaseStore:    req.PurchaseStore,
+	}
+	if _, ok := sent["custom_fields"]; ok {
+		patch.CustomFields = models.CustomFieldValues{}
+		for key, value := range req.CustomFields {
+			patch.CustomFields[key] = value
+		}
+	}
+	if _, ok := sent["authors"]; ok {
+		patch.Authors = append([]models.BookAuthor{}, req.Authors.toModel()...)
 	}
 
-	err = bc.BookService.UpdateBook(r.Context(), userID, bookID, book)
+	err = bc.BookService.UpdateBook(r.Context(), userID, bookID, patch)
+	if services.IsValidationError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to update book: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// If the book was just finished (wasn't finished before but is now), record it in goal history
-	if existingBook.FinishedAt == nil && req.FinishedAt != nil {
-		fmt.Printf("Book was just finished. Recording goal history for user %s\n", userID)
-
-		// Get the user's streak settings to determine the goal interval
-		var streakSettings models.StreakSettings
-		if err := bc.BookService.GetDB().Where("auth0_id = ?", userID).First(&streakSettings).Error; err != nil {
-			// If no settings exist, use default yearly interval
-			fmt.Printf("No streak settings found, using default yearly interval\n")
-			streakSettings.GoalInterval = "yearly"
-		} else {
-			fmt.Printf("Found streak settings with interval: %s\n", streakSettings.GoalInterval)
-		}
-
-		// Get the user's reading goal
-		readingGoal, err := bc.BookService.GetReadingGoal(r.Context(), userID)
-		if err != nil {
-			fmt.Printf("Error getting reading goal: %v\n", err)
-			readingGoal = 0
-		}
-		fmt.Printf("User's reading goal: %d\n", readingGoal)
-
-		// Get the interval start date based on when the book was finished
-		intervalStart := getIntervalStart(*req.FinishedAt, streakSettings.GoalInterval)
-		fmt.Printf("Interval start date: %v\n", intervalStart)
-
-		// Count books completed in this interval
-		var booksInInterval int64
-		var user models.User
-		if err := bc.BookService.GetDB().Where("auth0_id = ?", userID).First(&user).Error; err != nil {
-			fmt.Printf("Error finding user: %v\n", err)
-			return
-		}
-		fmt.Printf("Found user with ID: %d\n", user.ID)
-
-		// Count books finished in the same interval as this book
-		if err := bc.BookService.GetDB().Model(&models.Book{}).
-			Where("user_id = ? AND finished_at IS NOT NULL AND finished_at >= ? AND finished_at <= ?",
-				user.ID,
-				intervalStart,
-				time.Date(req.FinishedAt.Year(), req.FinishedAt.Month(), req.FinishedAt.Day(), 23, 59, 59, 999999999, req.FinishedAt.Location()),
-			).
-			Count(&booksInInterval).Error; err != nil {
-			fmt.Printf("Error counting books in interval: %v\n", err)
-		}
-		fmt.Printf("Found %d books completed in interval\n", booksInInterval)
-
-		// Record the goal completion
-		goalHistory := models.GoalHistory{
-			Auth0ID:      userID,
-			Interval:     streakSettings.GoalInterval,
-			Target:       readingGoal,
-			Achieved:     int(booksInInterval),
-			StartDate:    intervalStart,
-			EndDate:      *req.FinishedAt,
-			WasCompleted: int(booksInInterval) >= readingGoal,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
-		}
-
-		if err := bc.BookService.GetDB().Create(&goalHistory).Error; err != nil {
-			fmt.Printf("Error recording goal history: %v\n", err)
-		} else {
-			fmt.Printf("Successfully recorded goal history: %+v\n", goalHistory)
-		}
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -389,29 +547,13 @@ func (bc *BookController) UpdateBook(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func getIntervalStart(date time.Time, interval string) time.Time {
-	// Convert to UTC to ensure consistent timezone handling
-	result := date.UTC()
-	switch interval {
-	case "daily":
-		// Get start of day in UTC
-		return time.Date(result.Year(), result.Month(), result.Day(), 0, 0, 0, 0, time.UTC)
-	case "weekly":
-		// Get start of week (Sunday) in UTC
-		for result.Weekday() != time.Sunday {
-			result = result.AddDate(0, 0, -1)
-		}
-		return time.Date(result.Year(), result.Month(), result.Day(), 0, 0, 0, 0, time.UTC)
-	case "monthly":
-		// Get start of month in UTC
-		return time.Date(result.Year(), result.Month(), 1, 0, 0, 0, 0, time.UTC)
-	case "yearly":
-		// Get start of year in UTC
-		return time.Date(result.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
-	default:
-		// Default to start of year in UTC
-		return time.Date(result.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+// nullable returns the patch value of a field that can be cleared with null,
+// or nil when the field was left out of the request
+func nullable[T any](sent map[string]json.RawMessage, key string, value *T) **T {
+	if _, ok := sent[key]; !ok {
+		return nil
 	}
+	return &value
 }
 
 // UpdateReadingGoal handles PUT /api/user/reading-goal
@@ -469,6 +611,49 @@ func (bc *BookController) GetReadingGoal(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// GetRatingScale handles GET /api/user/rating-scale
+func (bc *BookController) GetRatingScale(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	scale, err := bc.BookService.GetRatingScale(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "get rating scale", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scale)
+}
+
+// UpdateRatingScale handles PUT /api/user/rating-scale. Ratings already given
+// are converted to the new scale.
+func (bc *BookController) UpdateRatingScale(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.RatingScale
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	scale, err := bc.BookService.UpdateRatingScale(r.Context(), userID, req)
+	if err != nil {
+		writeServiceError(w, "update rating scale", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scale)
+}
+
 // GetRecentlyDeletedBooks returns books soft deleted within the last 30 days
 func (bc *BookController) GetRecentlyDeletedBooks(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(middleware.UserIDKey).(string)
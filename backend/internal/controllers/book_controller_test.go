@@ -8,8 +8,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
 	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -23,7 +25,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("failed to connect database: %v", err)
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Book{}, &models.StreakSettings{}, &models.GoalHistory{})
+	err = db.AutoMigrate(&models.User{}, &models.Book{}, &models.ReadingSession{}, &models.ReadThrough{}, &models.Shelf{}, &models.BookShelf{}, &models.Series{}, &models.Author{}, &models.BookAuthor{}, &models.Note{}, &models.Highlight{}, &models.Loan{}, &models.BookCopy{}, &models.WishlistItem{}, &models.CustomField{}, &models.BookSearch{}, &models.SmartShelf{}, &models.Review{}, &models.StreakSettings{}, &models.GoalHistory{})
 	if err != nil {
 		t.Fatalf("failed to migrate database: %v", err)
 	}
@@ -71,6 +73,50 @@ func TestAddBook(t *testing.T) {
 			},
 			expectedStatus: http.StatusConflict,
 		},
+		{
+			name: "Rating Between Steps",
+			book: models.Book{
+				Title:  "Unevenly Rated",
+				Author: "Test Author",
+				Rating: 4.3,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Rating Above Scale",
+			book: models.Book{
+				Title:  "Overrated",
+				Author: "Test Author",
+				Rating: 6,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Duplicate With Different Case And Spacing",
+			book: models.Book{
+				Title:  "test book! ",
+				Author: "Test  Author",
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "Same Title By Another Author",
+			book: models.Book{
+				Title:  "Test Book",
+				Author: "Another Author",
+				ISBN:   "0441172717",
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Duplicate ISBN In Another Form",
+			book: models.Book{
+				Title:  "Dune",
+				Author: "Frank Herbert",
+				ISBN:   "978-0-441-17271-9",
+			},
+			expectedStatus: http.StatusConflict,
+		},
 	}
 
 	for _, tt := range tests {
@@ -113,6 +159,47 @@ func TestGetUserBooks(t *testing.T) {
 	assert.Len(t, response["books"], 2)
 }
 
+func TestGetUserBooksWithQuery(t *testing.T) {
+	db := setupTestDB(t)
+	controller := NewBookController(db)
+	user := createTestUser(t, db)
+
+	books := []models.Book{
+		{Title: "Book 1", Author: "Author 1", Genre: "Fantasy", Rating: 4, UserID: user.ID},
+		{Title: "Book 2", Author: "Author 2", Genre: "Mystery", Rating: 2, UserID: user.ID},
+		{Title: "Book 3", Author: "Author 3", Genre: "Fantasy", Rating: 5, UserID: user.ID},
+	}
+	for _, book := range books {
+		db.Create(&book)
+	}
+
+	req := httptest.NewRequest("GET", "/api/books/collection?genre=fantasy&sort=rating&order=desc&page=1&page_size=1", nil)
+	req = req.WithContext(createTestContext(user.Auth0ID))
+	rr := httptest.NewRecorder()
+
+	controller.GetUserBooks(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Books      []models.Book `json:"books"`
+		Total      int64         `json:"total"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	assert.Equal(t, int64(2), response.Total)
+	assert.Len(t, response.Books, 1)
+	assert.Equal(t, "Book 3", response.Books[0].Title)
+	assert.NotEmpty(t, response.NextCursor)
+
+	// Invalid parameters are rejected
+	req = httptest.NewRequest("GET", "/api/books/collection?sort=password", nil)
+	req = req.WithContext(createTestContext(user.Auth0ID))
+	rr = httptest.NewRecorder()
+
+	controller.GetUserBooks(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
 func TestDeleteBook(t *testing.T) {
 	db := setupTestDB(t)
 	controller := NewBookController(db)
@@ -180,6 +267,223 @@ func TestUpdateBook(t *testing.T) {
 	assert.Equal(t, "Updated Author", result.Author)
 }
 
+func TestUpdateBookKeepsUnsentFields(t *testing.T) {
+	db := setupTestDB(t)
+	controller := NewBookController(db)
+	user := createTestUser(t, db)
+	ctx := context.Background()
+
+	_, err := services.NewCustomFieldService(db).CreateCustomField(ctx, user.Auth0ID, models.CustomField{Name: "Signed copy", Type: models.CustomFieldBool})
+	assert.NoError(t, err)
+
+	price := 12.5
+	assert.NoError(t, services.NewBookService(db).AddBook(ctx, user.Auth0ID, models.Book{
+		Title:         "Good Omens",
+		ISBN:          "9780060853983",
+		Format:        "paperback",
+		Publisher:     "Harper",
+		PurchasePrice: &price,
+		CustomFields:  models.CustomFieldValues{"signed_copy": true},
+		Authors: []models.BookAuthor{
+			{Author: models.Author{Name: "Neil Gaiman"}},
+			{Author: models.Author{Name: "Terry Pratchett"}},
+		},
+	}))
+	var book models.Book
+	assert.NoError(t, db.Where("title = ?", "Good Omens").First(&book).Error)
+
+	r := chi.NewRouter()
+	r.Patch("/api/books/{id}", controller.UpdateBook)
+
+	// The fields the book card sends when a book is edited
+	body := fmt.Sprintf(`{"title": "Good Omens", "author": %q, "coverImage": "", "rating": 4, "pageCount": 412, "genre": "Fantasy", "started_at": null, "finished_at": null}`, book.Author)
+	req := httptest.NewRequest("PATCH", fmt.Sprintf("/api/books/%d", book.ID), bytes.NewBufferString(body))
+	req = req.WithContext(createTestContext(user.Auth0ID))
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var result models.Book
+	assert.NoError(t, db.Preload("Authors", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).Preload("Authors.Author").First(&result, book.ID).Error)
+	assert.Equal(t, 4.0, result.Rating)
+	assert.Equal(t, uint(412), result.PageCount)
+	assert.Equal(t, "Fantasy", result.Genre)
+	assert.Equal(t, "9780060853983", result.ISBN)
+	assert.Equal(t, "paperback", result.Format)
+	assert.Equal(t, "Harper", result.Publisher)
+	if assert.NotNil(t, result.PurchasePrice) {
+		assert.Equal(t, 12.5, *result.PurchasePrice)
+	}
+	assert.Equal(t, true, result.CustomFields["signed_copy"])
+	if assert.Len(t, result.Authors, 2) {
+		assert.Equal(t, "Neil Gaiman", result.Authors[0].Author.Name)
+		assert.Equal(t, "Terry Pratchett", result.Authors[1].Author.Name)
+	}
+}
+
+func TestCustomFieldsInCollection(t *testing.T) {
+	db := setupTestDB(t)
+	controller := NewBookController(db)
+	user := createTestUser(t, db)
+
+	_, err := services.NewCustomFieldService(db).CreateCustomField(context.Background(), user.Auth0ID, models.CustomField{Name: "Signed copy", Type: models.CustomFieldBool})
+	assert.NoError(t, err)
+
+	signed := models.Book{Title: "Dune", Author: "Frank Herbert", UserID: user.ID}
+	db.Create(&signed)
+	db.Create(&models.Book{Title: "Emma", Author: "Jane Austen", UserID: user.ID})
+
+	r := chi.NewRouter()
+	r.Patch("/api/books/{id}", controller.UpdateBook)
+	r.Get("/api/books/collection", controller.GetUserBooks)
+
+	for body, code := range map[string]int{
+		`{"title": "Dune", "author": "Frank Herbert", "custom_fields": {"signed_copy": "yes"}}`: http.StatusBadRequest,
+		`{"title": "Dune", "author": "Frank Herbert", "custom_fields": {"signed_copy": true}}`:  http.StatusOK,
+	} {
+		req := httptest.NewRequest("PATCH", fmt.Sprintf("/api/books/%d", signed.ID), bytes.NewBufferString(body))
+		req = req.WithContext(createTestContext(user.Auth0ID))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, code, rr.Code, body)
+	}
+
+	req := httptest.NewRequest("GET", "/api/books/collection?field.signed_copy=true", nil)
+	req = req.WithContext(createTestContext(user.Auth0ID))
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Books []models.Book `json:"books"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	assert.Len(t, response.Books, 1)
+	assert.Equal(t, true, response.Books[0].CustomFields["signed_copy"])
+
+	for _, query := range []string{"field.mood=happy", "field.signed_copy.min=true", "field.signed_copy.after=1"} {
+		req = httptest.NewRequest("GET", "/api/books/collection?"+query, nil)
+		req = req.WithContext(createTestContext(user.Auth0ID))
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestUpdateBookStatus(t *testing.T) {
+	db := setupTestDB(t)
+	controller := NewBookController(db)
+	user := createTestUser(t, db)
+
+	book := models.Book{
+		Title:     "Long Book",
+		Author:    "Test Author",
+		PageCount: 900,
+		UserID:    user.ID,
+	}
+	db.Create(&book)
+	assert.Equal(t, models.StatusWantToRead, book.Status)
+
+	r := chi.NewRouter()
+	r.Patch("/api/books/{id}", controller.UpdateBook)
+
+	patch := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest("PATCH", fmt.Sprintf("/api/books/%d", book.ID), bytes.NewBuffer(payload))
+		req = req.WithContext(createTestContext(user.Auth0ID))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// A book that was never started can't be abandoned
+	rr := patch(map[string]interface{}{"title": book.Title, "author": book.Author, "pageCount": 900, "status": "did_not_finish"})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = patch(map[string]interface{}{"title": book.Title, "author": book.Author, "pageCount": 900, "status": "reading"})
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var result models.Book
+	db.First(&result, book.ID)
+	assert.Equal(t, models.StatusReading, result.Status)
+	assert.NotNil(t, result.StartedAt)
+
+	// Stopping past the last page is rejected
+	rr = patch(map[string]interface{}{"title": book.Title, "author": book.Author, "pageCount": 900, "started_at": result.StartedAt, "status": "did_not_finish", "stopped_page": 1000})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = patch(map[string]interface{}{"title": book.Title, "author": book.Author, "pageCount": 900, "started_at": result.StartedAt, "status": "did_not_finish", "stopped_page": 250})
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	db.First(&result, book.ID)
+	assert.Equal(t, models.StatusDidNotFinish, result.Status)
+	assert.Nil(t, result.FinishedAt)
+	assert.NotNil(t, result.StoppedAt)
+	assert.Equal(t, uint(250), *result.StoppedPage)
+}
+
+func TestRereadBook(t *testing.T) {
+	db := setupTestDB(t)
+	controller := NewBookController(db)
+	readController := NewReadThroughController(db)
+	user := createTestUser(t, db)
+
+	started := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	finished := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	book := models.Book{
+		Title:      "Favourite Book",
+		Author:     "Test Author",
+		Rating:     5,
+		UserID:     user.ID,
+		StartedAt:  &started,
+		FinishedAt: &finished,
+	}
+	db.Create(&book)
+
+	// Re-adding without asking for a re-read is still a conflict
+	body, _ := json.Marshal(map[string]interface{}{"title": book.Title, "author": book.Author})
+	req := httptest.NewRequest("POST", "/api/books/add", bytes.NewBuffer(body))
+	req = req.WithContext(createTestContext(user.Auth0ID))
+	rr := httptest.NewRecorder()
+	controller.AddBook(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Asking for a re-read archives the first read
+	body, _ = json.Marshal(map[string]interface{}{"title": book.Title, "author": book.Author, "reread": true})
+	req = httptest.NewRequest("POST", "/api/books/add", bytes.NewBuffer(body))
+	req = req.WithContext(createTestContext(user.Auth0ID))
+	rr = httptest.NewRecorder()
+	controller.AddBook(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var result models.Book
+	db.First(&result, book.ID)
+	assert.Equal(t, models.StatusReading, result.Status)
+	assert.Nil(t, result.FinishedAt)
+
+	r := chi.NewRouter()
+	r.Get("/api/books/{id}/reads", readController.ListReadThroughs)
+	r.Post("/api/books/{id}/reads", readController.StartReread)
+
+	req = httptest.NewRequest("GET", fmt.Sprintf("/api/books/%d/reads", book.ID), nil)
+	req = req.WithContext(createTestContext(user.Auth0ID))
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response map[string][]models.ReadThrough
+	json.NewDecoder(rr.Body).Decode(&response)
+	assert.Len(t, response["read_throughs"], 1)
+	assert.True(t, finished.Equal(*response["read_throughs"][0].FinishedAt))
+
+	// A book that is still being read can't be re-read
+	req = httptest.NewRequest("POST", fmt.Sprintf("/api/books/%d/reads", book.ID), nil)
+	req = req.WithContext(createTestContext(user.Auth0ID))
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
 func TestAddBookValidation(t *testing.T) {
 	db := setupTestDB(t)
 	controller := NewBookController(db)
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+)
+
+type BookLookupController struct {
+	Providers []services.MetadataProvider
+}
+
+func NewBookLookupController() *BookLookupController {
+	return &BookLookupController{
+		Providers: services.NewMetadataProviders(),
+	}
+}
+
+// LookupBook handles GET /api/books/lookup?isbn=&q=
+func (lc *BookLookupController) LookupBook(w http.ResponseWriter, r *http.Request) {
+	query := services.MetadataQuery{
+		ISBN:  strings.TrimSpace(r.URL.Query().Get("isbn")),
+		Query: strings.TrimSpace(r.URL.Query().Get("q")),
+	}
+	if query.ISBN == "" && query.Query == "" {
+		http.Error(w, "Either isbn or q is required", http.StatusBadRequest)
+		return
+	}
+
+	books, source, err := services.LookupMetadata(r.Context(), lc.Providers, query)
+	if err != nil {
+		fmt.Println("Error looking up book metadata:", err)
+		http.Error(w, "Failed to look up book metadata", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Source  string        `json:"source,omitempty"`
+		Results []models.Book `json:"results"`
+	}{
+		Source:  source,
+		Results: books,
+	})
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type CopyController struct {
+	CopyService services.CopyService
+}
+
+func NewCopyController(db *gorm.DB) *CopyController {
+	return &CopyController{
+		CopyService: services.NewCopyService(db),
+	}
+}
+
+type copyRequest struct {
+	Room           string     `json:"room"`
+	Shelf          string     `json:"shelf"`
+	Position       string     `json:"position"`
+	Condition      string     `json:"condition"`
+	ConditionNotes string     `json:"condition_notes"`
+	Barcode        string     `json:"barcode"`
+	AcquiredAt     *time.Time `json:"acquired_at"`
+}
+
+func (req copyRequest) toModel() models.BookCopy {
+	return models.BookCopy{
+		Room:           req.Room,
+		Shelf:          req.Shelf,
+		Position:       req.Position,
+		Condition:      req.Condition,
+		ConditionNotes: req.ConditionNotes,
+		Barcode:        req.Barcode,
+		AcquiredAt:     req.AcquiredAt,
+	}
+}
+
+// ListCopies handles GET /api/books/{id}/copies
+func (c *CopyController) ListCopies(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	copies, err := c.CopyService.ListCopies(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "fetch copies", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"copies": copies,
+	})
+}
+
+// AddCopy handles POST /api/books/{id}/copies
+func (c *CopyController) AddCopy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req copyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	bookCopy, err := c.CopyService.AddCopy(r.Context(), userID, chi.URLParam(r, "id"), req.toModel())
+	if err != nil {
+		writeServiceError(w, "add copy", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(bookCopy)
+}
+
+// UpdateCopy handles PATCH /api/books/{id}/copies/{copyID}
+func (c *CopyController) UpdateCopy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req copyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	bookCopy, err := c.CopyService.UpdateCopy(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "copyID"), req.toModel())
+	if err != nil {
+		writeServiceError(w, "update copy", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bookCopy)
+}
+
+// DeleteCopy handles DELETE /api/books/{id}/copies/{copyID}
+func (c *CopyController) DeleteCopy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.CopyService.DeleteCopy(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "copyID")); err != nil {
+		writeServiceError(w, "delete copy", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Copy deleted successfully",
+	})
+}
+
+// FindCopies handles GET /api/copies?q=&room=&shelf=
+func (c *CopyController) FindCopies(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	params := r.URL.Query()
+	copies, err := c.CopyService.FindCopies(r.Context(), userID, services.CopyQuery{
+		Search: params.Get("q"),
+		Room:   params.Get("room"),
+		Shelf:  params.Get("shelf"),
+	})
+	if err != nil {
+		writeServiceError(w, "find copies", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"copies": copies,
+	})
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type CoverController struct {
+	CoverService services.CoverService
+}
+
+func NewCoverController(db *gorm.DB) *CoverController {
+	return &CoverController{
+		CoverService: services.NewCoverService(db, services.NewBlobStore()),
+	}
+}
+
+// UploadCover handles POST /api/books/{id}/cover. The image is either the
+// "cover" file of a multipart form or the raw request body.
+func (c *CoverController) UploadCover(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Leave room for the multipart framing around the image
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxCoverBytes+1<<20)
+
+	var image io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("cover")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid cover upload: %v", err), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		image = file
+	}
+
+	data, err := io.ReadAll(image)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid cover upload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	book, err := c.CoverService.UploadCover(r.Context(), userID, chi.URLParam(r, "id"), data)
+	if err != nil {
+		writeServiceError(w, "upload cover", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(book)
+}
+
+// RemoveCover handles DELETE /api/books/{id}/cover
+func (c *CoverController) RemoveCover(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	book, err := c.CoverService.RemoveCover(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "remove cover", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(book)
+}
+
+// GetCover handles GET /api/covers/{coverID}/{size}. Covers are public so they
+// can be used in image tags, and never change as their ids come from their contents.
+func (c *CoverController) GetCover(w http.ResponseWriter, r *http.Request) {
+	cover, err := c.CoverService.GetCover(r.Context(), chi.URLParam(r, "coverID"), chi.URLParam(r, "size"))
+	if err != nil {
+		writeServiceError(w, "fetch cover", err)
+		return
+	}
+	defer cover.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	io.Copy(w, cover)
+}
+
+// ProxyCover handles GET /api/covers/proxy?url=&size=
+func (c *CoverController) ProxyCover(w http.ResponseWriter, r *http.Request) {
+	cover, err := c.CoverService.ProxyCover(r.Context(), r.URL.Query().Get("url"), r.URL.Query().Get("size"))
+	if errors.Is(err, services.ErrCoverFetchFailed) {
+		fmt.Println("Error proxying cover:", err)
+		http.Error(w, "Failed to fetch cover", http.StatusBadGateway)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, "proxy cover", err)
+		return
+	}
+	defer cover.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	io.Copy(w, cover)
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type CustomFieldController struct {
+	CustomFieldService services.CustomFieldService
+}
+
+func NewCustomFieldController(db *gorm.DB) *CustomFieldController {
+	return &CustomFieldController{
+		CustomFieldService: services.NewCustomFieldService(db),
+	}
+}
+
+type customFieldRequest struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Options []string `json:"options"`
+}
+
+func (req customFieldRequest) toModel() models.CustomField {
+	return models.CustomField{
+		Name:    req.Name,
+		Type:    req.Type,
+		Options: models.StringArray(req.Options),
+	}
+}
+
+// ListCustomFields handles GET /api/custom-fields
+func (c *CustomFieldController) ListCustomFields(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	fields, err := c.CustomFieldService.ListCustomFields(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "fetch custom fields", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"fields": fields,
+	})
+}
+
+// CreateCustomField handles POST /api/custom-fields
+func (c *CustomFieldController) CreateCustomField(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req customFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	field, err := c.CustomFieldService.CreateCustomField(r.Context(), userID, req.toModel())
+	if err != nil {
+		writeServiceError(w, "create custom field", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(field)
+}
+
+// UpdateCustomField handles PATCH /api/custom-fields/{id}
+func (c *CustomFieldController) UpdateCustomField(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req customFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	field, err := c.CustomFieldService.UpdateCustomField(r.Context(), userID, chi.URLParam(r, "id"), req.toModel())
+	if err != nil {
+		writeServiceError(w, "update custom field", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(field)
+}
+
+// DeleteCustomField handles DELETE /api/custom-fields/{id}
+func (c *CustomFieldController) DeleteCustomField(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.CustomFieldService.DeleteCustomField(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		writeServiceError(w, "delete custom field", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Custom field deleted successfully",
+	})
+}
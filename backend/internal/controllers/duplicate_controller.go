@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type DuplicateController struct {
+	DuplicateService services.DuplicateService
+}
+
+func NewDuplicateController(db *gorm.DB) *DuplicateController {
+	return &DuplicateController{
+		DuplicateService: services.NewDuplicateService(db),
+	}
+}
+
+// PossibleDuplicates handles GET /api/books/duplicates
+func (c *DuplicateController) PossibleDuplicates(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	duplicates, err := c.DuplicateService.PossibleDuplicates(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "find possible duplicates", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"duplicates": duplicates,
+	})
+}
+
+// MergeBooks handles POST /api/books/{id}/merge. The book given by duplicate_id
+// is merged into the book in the path and then removed.
+func (c *DuplicateController) MergeBooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		DuplicateID uint `json:"duplicate_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DuplicateID == 0 {
+		http.Error(w, "A duplicate_id is required", http.StatusBadRequest)
+		return
+	}
+
+	book, err := c.DuplicateService.MergeBooks(r.Context(), userID, chi.URLParam(r, "id"), fmt.Sprintf("%d", req.DuplicateID))
+	if err != nil {
+		writeServiceError(w, "merge books", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(book)
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"gorm.io/gorm"
+)
+
+// writeServiceError maps service errors onto HTTP status codes
+func writeServiceError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, services.ErrBlobNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrDuplicateShelf), errors.Is(err, services.ErrDuplicateSeries), errors.Is(err, services.ErrBookOnLoan),
+		errors.Is(err, services.ErrAlreadyOwned), errors.Is(err, services.ErrDuplicateCustomField), errors.Is(err, services.ErrDuplicateSmartShelf):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case services.IsValidationError(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		fmt.Printf("Error trying to %s: %v\n", action, err)
+		http.Error(w, fmt.Sprintf("Failed to %s: %v", action, err), http.StatusInternalServerError)
+	}
+}
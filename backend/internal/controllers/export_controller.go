@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"gorm.io/gorm"
+)
+
+type ExportController struct {
+	ExportService services.ExportService
+}
+
+func NewExportController(db *gorm.DB) *ExportController {
+	return &ExportController{
+		ExportService: services.NewExportService(db),
+	}
+}
+
+// ExportBooks handles GET /api/books/export?format=csv|json|goodreads. The json
+// format is a full backup including goal history and streak settings. Pass
+// ?include_deleted=true to include soft deleted books.
+func (c *ExportController) ExportBooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	exportFormat, err := services.GetExportFormat(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	includeDeleted := false
+	if value := r.URL.Query().Get("include_deleted"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid include_deleted value", http.StatusBadRequest)
+			return
+		}
+		includeDeleted = parsed
+	}
+
+	filename := fmt.Sprintf("book-collection-%s-%s.%s", format, time.Now().Format("2006-01-02"), exportFormat.Extension)
+	w.Header().Set("Content-Type", exportFormat.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	out := &countingWriter{w: w}
+	if err := c.ExportService.Export(r.Context(), userID, format, includeDeleted, out); err != nil {
+		// Once the export has started streaming the status can no longer
+		// change, so the error can only be logged
+		if out.n > 0 {
+			fmt.Printf("Error exporting books for user %s: %v\n", userID, err)
+			return
+		}
+		w.Header().Del("Content-Disposition")
+		writeServiceError(w, "export books", err)
+	}
+}
+
+// countingWriter records how many bytes have been written to the response
+type countingWriter struct {
+	w http.ResponseWriter
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
@@ -5,19 +5,22 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
 	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
 	"gorm.io/gorm"
 )
 
 type GoalHistoryController struct {
-	db *gorm.DB
+	db          *gorm.DB
+	GoalService services.GoalService
 }
 
 func NewGoalHistoryController(db *gorm.DB) *GoalHistoryController {
-	return &GoalHistoryController{db: db}
+	return &GoalHistoryController{db: db, GoalService: services.NewGoalService(db)}
 }
 
 // RecordGoalCompletion records the completion of a goal interval
@@ -82,6 +85,35 @@ func (c *GoalHistoryController) GetGoalStats(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(stats)
 }
 
+// RebuildGoalHistory regenerates the goal history from the user's finished books,
+// or only reports what would change when dry_run is set
+func (c *GoalHistoryController) RebuildGoalHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid dry_run value", http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+
+	rebuild, err := c.GoalService.RebuildGoalHistory(r.Context(), userID, dryRun)
+	if err != nil {
+		writeServiceError(w, "rebuild goal history", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rebuild)
+}
+
 func calculateGoalStats(histories []models.GoalHistory) models.GoalStats {
 	if len(histories) == 0 {
 		return models.GoalStats{}
@@ -103,8 +135,8 @@ func calculateGoalStats(histories []models.GoalHistory) models.GoalStats {
 		return histories[i].EndDate.After(histories[j].EndDate)
 	})
 
-	// Track the last completion date for streak calculation
-	var lastCompletionDate *time.Time
+	// Track the start of the last interval met for streak calculation
+	var lastStart *time.Time
 	var lastInterval string
 
 	for i, h := range histories {
@@ -123,39 +155,20 @@ func calculateGoalStats(histories []models.GoalHistory) models.GoalStats {
 				}
 			}
 
-			// Update streak based on interval type
-			var maxGap time.Duration
-			switch h.Interval {
-			case "daily":
-				maxGap = 24 * time.Hour
-			case "weekly":
-				maxGap = 7 * 24 * time.Hour
-			case "monthly":
-				maxGap = 31 * 24 * time.Hour
-			case "yearly":
-				maxGap = 365 * 24 * time.Hour
-			default:
-				maxGap = 7 * 24 * time.Hour
-			}
-
-			// Check if this goal completion continues the streak
-			if lastCompletionDate == nil || lastInterval != h.Interval {
+			// A goal continues the streak when the interval after it is the last one met
+			if lastStart == nil || lastInterval != h.Interval {
 				currentStreak = 1
+			} else if services.NextGoalIntervalStart(h.StartDate.UTC(), h.Interval).Equal(*lastStart) {
+				currentStreak++
 			} else {
-				// Calculate the time difference between goals
-				timeDiff := lastCompletionDate.Sub(h.EndDate)
-				if timeDiff <= maxGap {
-					currentStreak++
-				} else {
-					currentStreak = 1
-				}
+				currentStreak = 1
 			}
 
 			if currentStreak > longestStreak {
 				longestStreak = currentStreak
 			}
 
-			lastCompletionDate = &h.EndDate
+			lastStart = &h.StartDate
 			lastInterval = h.Interval
 
 			// Update last goal met time (most recent)
@@ -165,7 +178,7 @@ func calculateGoalStats(histories []models.GoalHistory) models.GoalStats {
 		} else {
 			if h.Interval == lastInterval {
 				currentStreak = 0
-				lastCompletionDate = nil
+				lastStart = nil
 				lastInterval = ""
 			}
 		}
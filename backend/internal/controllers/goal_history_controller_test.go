@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
 	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
@@ -172,3 +173,41 @@ func TestGetGoalStats(t *testing.T) {
 		assert.Equal(t, "", stats.BestInterval)
 	})
 }
+
+func TestGetGoalStatsFromBooks(t *testing.T) {
+	db := setupTestDB(t)
+	controller := NewGoalHistoryController(db)
+	books := services.NewBookService(db)
+	user := createTestUser(t, db)
+	ctx := context.Background()
+	assert.NoError(t, books.UpdateReadingGoal(ctx, user.Auth0ID, 1))
+
+	// Goals met in consecutive years form a streak, leap years included
+	for _, finished := range []time.Time{
+		time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC),
+	} {
+		finished := finished
+		assert.NoError(t, books.AddBook(ctx, user.Auth0ID, models.Book{Title: "Dune", Author: "Frank Herbert", FinishedAt: &finished}))
+	}
+
+	req := httptest.NewRequest("GET", "/api/user/goal-stats", nil)
+	req = req.WithContext(context.WithValue(context.Background(), middleware.UserIDKey, user.Auth0ID))
+	rr := httptest.NewRecorder()
+
+	controller.GetGoalStats(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var stats models.GoalStats
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&stats))
+	assert.Equal(t, 3, stats.CurrentGoalStreak)
+	assert.Equal(t, 3, stats.LongestGoalStreak)
+	assert.Equal(t, 3, stats.TotalGoalsMet)
+
+	// The last goal was met within 2025, not when the following interval starts
+	if assert.NotNil(t, stats.LastGoalMet) {
+		assert.Equal(t, time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), stats.LastGoalMet.UTC())
+	}
+}
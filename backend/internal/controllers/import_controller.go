@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// maxImportSize caps uploaded export files at 10MB
+const maxImportSize = 10 << 20
+
+type ImportController struct {
+	ImportService services.ImportService
+}
+
+func NewImportController(db *gorm.DB) *ImportController {
+	return &ImportController{
+		ImportService: services.NewImportService(db),
+	}
+}
+
+// importFile returns the uploaded export, sent either as a multipart "file" field or as the raw body
+func importFile(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	return r.Body, nil
+}
+
+// ImportBooks handles POST /api/books/import and POST /api/books/import/{format}.
+// Without a format in the path the format is detected from the file. Pass
+// ?dry_run=true to see what would change without saving anything.
+func (c *ImportController) ImportBooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid dry_run value", http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+
+	file, err := importFile(w, r)
+	if err != nil {
+		http.Error(w, "An export file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	report, err := c.ImportService.Import(r.Context(), userID, chi.URLParam(r, "format"), file, dryRun)
+	if err != nil {
+		writeServiceError(w, "import books", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
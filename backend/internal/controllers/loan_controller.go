@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type LoanController struct {
+	LoanService services.LoanService
+}
+
+func NewLoanController(db *gorm.DB) *LoanController {
+	return &LoanController{
+		LoanService: services.NewLoanService(db),
+	}
+}
+
+type loanRequest struct {
+	CopyID   *uint      `json:"copy_id"`
+	Borrower string     `json:"borrower"`
+	Contact  string     `json:"contact"`
+	LentAt   time.Time  `json:"lent_at"`
+	DueAt    *time.Time `json:"due_at"`
+}
+
+func (req loanRequest) toModel() models.Loan {
+	return models.Loan{
+		CopyID:   req.CopyID,
+		Borrower: req.Borrower,
+		Contact:  req.Contact,
+		LentAt:   req.LentAt,
+		DueAt:    req.DueAt,
+	}
+}
+
+// ListLoans handles GET /api/books/{id}/loans
+func (c *LoanController) ListLoans(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	loans, err := c.LoanService.ListLoans(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "fetch loans", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"loans": loans,
+	})
+}
+
+// LendBook handles POST /api/books/{id}/loans
+func (c *LoanController) LendBook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req loanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	loan, err := c.LoanService.LendBook(r.Context(), userID, chi.URLParam(r, "id"), req.toModel())
+	if err != nil {
+		writeServiceError(w, "lend book", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(loan)
+}
+
+// ReturnBook handles POST /api/books/{id}/loans/return
+func (c *LoanController) ReturnBook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// The body is optional, without it the book is returned today
+	var req struct {
+		CopyID     *uint     `json:"copy_id"`
+		ReturnedAt time.Time `json:"returned_at"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+	}
+
+	loan, err := c.LoanService.ReturnBook(r.Context(), userID, chi.URLParam(r, "id"), req.CopyID, req.ReturnedAt)
+	if err != nil {
+		writeServiceError(w, "return book", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loan)
+}
+
+// ListLentOut handles GET /api/loans
+func (c *LoanController) ListLentOut(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	loans, err := c.LoanService.ListLentOut(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "fetch lent out books", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"loans": loans,
+	})
+}
+
+// ListOverdue handles GET /api/loans/overdue
+func (c *LoanController) ListOverdue(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	loans, err := c.LoanService.ListOverdue(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "fetch overdue loans", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"loans": loans,
+	})
+}
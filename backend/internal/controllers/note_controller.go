@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type NoteController struct {
+	NoteService services.NoteService
+}
+
+func NewNoteController(db *gorm.DB) *NoteController {
+	return &NoteController{
+		NoteService: services.NewNoteService(db),
+	}
+}
+
+type noteRequest struct {
+	Text      string    `json:"text"`
+	Comment   string    `json:"comment"`
+	Page      *uint     `json:"page"`
+	Location  string    `json:"location"`
+	Chapter   string    `json:"chapter"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (req noteRequest) toNote() models.Note {
+	return models.Note{
+		Text:      req.Text,
+		Page:      req.Page,
+		Location:  req.Location,
+		Chapter:   req.Chapter,
+		CreatedAt: req.CreatedAt,
+	}
+}
+
+func (req noteRequest) toHighlight() models.Highlight {
+	return models.Highlight{
+		Text:      req.Text,
+		Comment:   req.Comment,
+		Page:      req.Page,
+		Location:  req.Location,
+		Chapter:   req.Chapter,
+		CreatedAt: req.CreatedAt,
+	}
+}
+
+// ListNotes handles GET /api/books/{id}/notes
+func (c *NoteController) ListNotes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	notes, err := c.NoteService.ListNotes(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "fetch notes", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"notes": notes,
+	})
+}
+
+// AddNote handles POST /api/books/{id}/notes
+func (c *NoteController) AddNote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req noteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	note, err := c.NoteService.AddNote(r.Context(), userID, chi.URLParam(r, "id"), req.toNote())
+	if err != nil {
+		writeServiceError(w, "add note", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(note)
+}
+
+// UpdateNote handles PATCH /api/books/{id}/notes/{noteID}
+func (c *NoteController) UpdateNote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req noteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	note, err := c.NoteService.UpdateNote(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "noteID"), req.toNote())
+	if err != nil {
+		writeServiceError(w, "update note", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(note)
+}
+
+// DeleteNote handles DELETE /api/books/{id}/notes/{noteID}
+func (c *NoteController) DeleteNote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	err := c.NoteService.DeleteNote(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "noteID"))
+	if err != nil {
+		writeServiceError(w, "delete note", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Note deleted successfully",
+	})
+}
+
+// ListHighlights handles GET /api/books/{id}/notes/highlights
+func (c *NoteController) ListHighlights(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	highlights, err := c.NoteService.ListHighlights(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "fetch highlights", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"highlights": highlights,
+	})
+}
+
+// AddHighlight handles POST /api/books/{id}/notes/highlights
+func (c *NoteController) AddHighlight(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req noteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	highlight, err := c.NoteService.AddHighlight(r.Context(), userID, chi.URLParam(r, "id"), req.toHighlight())
+	if err != nil {
+		writeServiceError(w, "add highlight", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(highlight)
+}
+
+// UpdateHighlight handles PATCH /api/books/{id}/notes/highlights/{highlightID}
+func (c *NoteController) UpdateHighlight(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req noteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	highlight, err := c.NoteService.UpdateHighlight(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "highlightID"), req.toHighlight())
+	if err != nil {
+		writeServiceError(w, "update highlight", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(highlight)
+}
+
+// DeleteHighlight handles DELETE /api/books/{id}/notes/highlights/{highlightID}
+func (c *NoteController) DeleteHighlight(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	err := c.NoteService.DeleteHighlight(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "highlightID"))
+	if err != nil {
+		writeServiceError(w, "delete highlight", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Highlight deleted successfully",
+	})
+}
+
+// SearchNotes handles GET /api/notes/search?q=
+func (c *NoteController) SearchNotes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	results, err := c.NoteService.SearchNotes(r.Context(), userID, r.URL.Query().Get("q"))
+	if err != nil {
+		writeServiceError(w, "search notes", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+	})
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type ReadThroughController struct {
+	ReadThroughService services.ReadThroughService
+}
+
+func NewReadThroughController(db *gorm.DB) *ReadThroughController {
+	return &ReadThroughController{
+		ReadThroughService: services.NewReadThroughService(db),
+	}
+}
+
+// ListReadThroughs handles GET /api/books/{id}/reads
+func (c *ReadThroughController) ListReadThroughs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reads, err := c.ReadThroughService.ListReadThroughs(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "fetch read-throughs", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"read_throughs": reads,
+	})
+}
+
+// StartReread handles POST /api/books/{id}/reads
+func (c *ReadThroughController) StartReread(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		StartedAt time.Time `json:"started_at"`
+		Notes     string    `json:"notes"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+	}
+
+	book, err := c.ReadThroughService.StartReread(r.Context(), userID, chi.URLParam(r, "id"), req.StartedAt, req.Notes)
+	if err != nil {
+		writeServiceError(w, "start re-read", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(book)
+}
+
+// DeleteReadThrough handles DELETE /api/books/{id}/reads/{readID}
+func (c *ReadThroughController) DeleteReadThrough(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	err := c.ReadThroughService.DeleteReadThrough(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "readID"))
+	if err != nil {
+		writeServiceError(w, "delete read-through", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Read-through deleted successfully",
+	})
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type ReadingSessionController struct {
+	SessionService services.ReadingSessionService
+}
+
+func NewReadingSessionController(db *gorm.DB) *ReadingSessionController {
+	return &ReadingSessionController{
+		SessionService: services.NewReadingSessionService(db),
+	}
+}
+
+type readingSessionRequest struct {
+	Date         time.Time `json:"date"`
+	StartPage    *uint     `json:"start_page"`
+	EndPage      *uint     `json:"end_page"`
+	StartPercent *float64  `json:"start_percent"`
+	EndPercent   *float64  `json:"end_percent"`
+	StartMinute  *uint     `json:"start_minute"`
+	EndMinute    *uint     `json:"end_minute"`
+	Minutes      uint      `json:"minutes"`
+}
+
+func (req readingSessionRequest) toModel() models.ReadingSession {
+	return models.ReadingSession{
+		Date:         req.Date,
+		StartPage:    req.StartPage,
+		EndPage:      req.EndPage,
+		StartPercent: req.StartPercent,
+		EndPercent:   req.EndPercent,
+		StartMinute:  req.StartMinute,
+		EndMinute:    req.EndMinute,
+		Minutes:      req.Minutes,
+	}
+}
+
+// ListSessions handles GET /api/books/{id}/sessions
+func (c *ReadingSessionController) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := c.SessionService.ListSessions(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "fetch reading sessions", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions": sessions,
+	})
+}
+
+// AddSession handles POST /api/books/{id}/sessions
+func (c *ReadingSessionController) AddSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req readingSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	session, err := c.SessionService.AddSession(r.Context(), userID, chi.URLParam(r, "id"), req.toModel())
+	if err != nil {
+		writeServiceError(w, "add reading session", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+// UpdateSession handles PATCH /api/books/{id}/sessions/{sessionID}
+func (c *ReadingSessionController) UpdateSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req readingSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	session, err := c.SessionService.UpdateSession(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "sessionID"), req.toModel())
+	if err != nil {
+		writeServiceError(w, "update reading session", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// DeleteSession handles DELETE /api/books/{id}/sessions/{sessionID}
+func (c *ReadingSessionController) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	err := c.SessionService.DeleteSession(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "sessionID"))
+	if err != nil {
+		writeServiceError(w, "delete reading session", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Reading session deleted successfully",
+	})
+}
+
+// GetReadingSpeed handles GET /api/books/{id}/reading-speed
+func (c *ReadingSessionController) GetReadingSpeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	speed, err := c.SessionService.GetReadingSpeed(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "calculate reading speed", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(speed)
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadingSessions(t *testing.T) {
+	db := setupTestDB(t)
+	controller := NewReadingSessionController(db)
+	bookController := NewBookController(db)
+	user := createTestUser(t, db)
+
+	book := models.Book{
+		Title:     "Session Book",
+		Author:    "Test Author",
+		PageCount: 400,
+		UserID:    user.ID,
+	}
+	db.Create(&book)
+
+	r := chi.NewRouter()
+	r.Get("/api/books/{id}/sessions", controller.ListSessions)
+	r.Post("/api/books/{id}/sessions", controller.AddSession)
+	r.Patch("/api/books/{id}/sessions/{sessionID}", controller.UpdateSession)
+	r.Delete("/api/books/{id}/sessions/{sessionID}", controller.DeleteSession)
+
+	send := func(method string, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		req = req.WithContext(createTestContext(user.Auth0ID))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	sessionsPath := fmt.Sprintf("/api/books/%d/sessions", book.ID)
+
+	t.Run("Rejects progress beyond the page count", func(t *testing.T) {
+		rr := send("POST", sessionsPath, map[string]interface{}{"start_page": 1, "end_page": 500})
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Rejects unknown books", func(t *testing.T) {
+		rr := send("POST", "/api/books/999999/sessions", map[string]interface{}{"end_page": 10})
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	var created models.ReadingSession
+	t.Run("Adds a session and starts the book", func(t *testing.T) {
+		rr := send("POST", sessionsPath, map[string]interface{}{"start_page": 1, "end_page": 100, "minutes": 45})
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		json.NewDecoder(rr.Body).Decode(&created)
+		assert.Equal(t, book.ID, created.BookID)
+
+		var result models.Book
+		db.First(&result, book.ID)
+		assert.Equal(t, models.StatusReading, result.Status)
+		assert.NotNil(t, result.StartedAt)
+	})
+
+	t.Run("Derives current page on the book", func(t *testing.T) {
+		rr := send("POST", sessionsPath, map[string]interface{}{"start_percent": 25, "end_percent": 50})
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		found, err := bookController.BookService.GetBookByID(createTestContext(user.Auth0ID), user.Auth0ID, fmt.Sprintf("%d", book.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, uint(200), *found.CurrentPage)
+		assert.Equal(t, 50.0, *found.ProgressPercent)
+	})
+
+	t.Run("Updates, lists and deletes sessions", func(t *testing.T) {
+		rr := send("PATCH", fmt.Sprintf("%s/%d", sessionsPath, created.ID), map[string]interface{}{"start_page": 1, "end_page": 120})
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = send("DELETE", fmt.Sprintf("%s/%d", sessionsPath, created.ID), nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = send("GET", sessionsPath, nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response map[string][]models.ReadingSession
+		json.NewDecoder(rr.Body).Decode(&response)
+		assert.Len(t, response["sessions"], 1)
+	})
+}
+
+func TestAudiobookSessions(t *testing.T) {
+	db := setupTestDB(t)
+	controller := NewReadingSessionController(db)
+	bookController := NewBookController(db)
+	user := createTestUser(t, db)
+
+	book := models.Book{
+		Title:           "Audio Book",
+		Author:          "Test Author",
+		Format:          models.FormatAudiobook,
+		DurationMinutes: 600,
+		PageCount:       300,
+		UserID:          user.ID,
+	}
+	db.Create(&book)
+
+	r := chi.NewRouter()
+	r.Post("/api/books/{id}/sessions", controller.AddSession)
+	r.Get("/api/books/{id}/reading-speed", controller.GetReadingSpeed)
+
+	send := func(method string, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		req = req.WithContext(createTestContext(user.Auth0ID))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	sessionsPath := fmt.Sprintf("/api/books/%d/sessions", book.ID)
+
+	t.Run("Rejects pages", func(t *testing.T) {
+		rr := send("POST", sessionsPath, map[string]interface{}{"start_page": 1, "end_page": 20})
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Rejects minutes beyond the duration", func(t *testing.T) {
+		rr := send("POST", sessionsPath, map[string]interface{}{"start_minute": 0, "end_minute": 700})
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Derives the current minute", func(t *testing.T) {
+		rr := send("POST", sessionsPath, map[string]interface{}{"start_minute": 0, "end_minute": 150, "minutes": 100})
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		found, err := bookController.BookService.GetBookByID(createTestContext(user.Auth0ID), user.Auth0ID, fmt.Sprintf("%d", book.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, uint(150), *found.CurrentMinute)
+		assert.Equal(t, 25.0, *found.ProgressPercent)
+		assert.Nil(t, found.CurrentPage)
+	})
+
+	t.Run("Reports reading speed in minutes", func(t *testing.T) {
+		rr := send("GET", fmt.Sprintf("/api/books/%d/reading-speed", book.ID), nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var speed struct {
+			Unit             string  `json:"unit"`
+			PerHour          float64 `json:"per_hour"`
+			Remaining        float64 `json:"remaining"`
+			MinutesRemaining uint    `json:"minutes_remaining"`
+		}
+		json.NewDecoder(rr.Body).Decode(&speed)
+		assert.Equal(t, models.UnitMinutes, speed.Unit)
+		assert.Equal(t, 90.0, speed.PerHour)
+		assert.Equal(t, 450.0, speed.Remaining)
+		assert.Equal(t, uint(300), speed.MinutesRemaining)
+	})
+}
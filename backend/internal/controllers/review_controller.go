@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type ReviewController struct {
+	ReviewService services.ReviewService
+}
+
+func NewReviewController(db *gorm.DB) *ReviewController {
+	return &ReviewController{
+		ReviewService: services.NewReviewService(db),
+	}
+}
+
+type reviewRequest struct {
+	Body          string               `json:"body"`
+	Spoiler       bool                 `json:"spoiler"`
+	ReviewedAt    time.Time            `json:"reviewed_at"`
+	AspectRatings models.AspectRatings `json:"aspect_ratings"`
+}
+
+func (req reviewRequest) toModel() models.Review {
+	return models.Review{
+		Body:          req.Body,
+		Spoiler:       req.Spoiler,
+		ReviewedAt:    req.ReviewedAt,
+		AspectRatings: req.AspectRatings,
+	}
+}
+
+// ListReviews handles GET /api/books/{id}/reviews
+func (c *ReviewController) ListReviews(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reviews, err := c.ReviewService.ListReviews(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "fetch reviews", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"reviews": reviews,
+	})
+}
+
+// AddReview handles POST /api/books/{id}/reviews
+func (c *ReviewController) AddReview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req reviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	review, err := c.ReviewService.AddReview(r.Context(), userID, chi.URLParam(r, "id"), req.toModel())
+	if err != nil {
+		writeServiceError(w, "add review", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(review)
+}
+
+// UpdateReview handles PATCH /api/books/{id}/reviews/{reviewID}
+func (c *ReviewController) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req reviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	review, err := c.ReviewService.UpdateReview(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "reviewID"), req.toModel())
+	if err != nil {
+		writeServiceError(w, "update review", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// DeleteReview handles DELETE /api/books/{id}/reviews/{reviewID}
+func (c *ReviewController) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	err := c.ReviewService.DeleteReview(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "reviewID"))
+	if err != nil {
+		writeServiceError(w, "delete review", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Review deleted successfully",
+	})
+}
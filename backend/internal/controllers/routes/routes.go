@@ -56,6 +56,25 @@ func SetupRouter(r *chi.Mux, db *gorm.DB) {
 	subscriptionController := controllers.NewSubscriptionController(db)
 	streakSettingsController := controllers.NewStreakSettingsController(db)
 	goalHistoryController := controllers.NewGoalHistoryController(db)
+	bookLookupController := controllers.NewBookLookupController()
+	readingSessionController := controllers.NewReadingSessionController(db)
+	readThroughController := controllers.NewReadThroughController(db)
+	importController := controllers.NewImportController(db)
+	exportController := controllers.NewExportController(db)
+	shelfController := controllers.NewShelfController(db)
+	seriesController := controllers.NewSeriesController(db)
+	authorController := controllers.NewAuthorController(db)
+	duplicateController := controllers.NewDuplicateController(db)
+	batchController := controllers.NewBatchController(db)
+	noteController := controllers.NewNoteController(db)
+	reviewController := controllers.NewReviewController(db)
+	loanController := controllers.NewLoanController(db)
+	wishlistController := controllers.NewWishlistController(db)
+	coverController := controllers.NewCoverController(db)
+	copyController := controllers.NewCopyController(db)
+	customFieldController := controllers.NewCustomFieldController(db)
+	searchController := controllers.NewSearchController(db)
+	smartShelfController := controllers.NewSmartShelfController(db)
 
 	// Create auth middleware
 	authMiddleware := middleware.NewAuthMiddleware(db)
@@ -65,10 +84,151 @@ func SetupRouter(r *chi.Mux, db *gorm.DB) {
 		r.Use(authMiddleware.Handler)
 		r.Get("/collection", bookController.GetUserBooks)
 		r.Get("/recently-deleted", bookController.GetRecentlyDeletedBooks)
+		r.Get("/lookup", bookLookupController.LookupBook)
+		r.Post("/import", importController.ImportBooks)
+		r.Post("/import/{format}", importController.ImportBooks)
+		r.Get("/export", exportController.ExportBooks)
+		r.Get("/duplicates", duplicateController.PossibleDuplicates)
+		r.Get("/value", wishlistController.CollectionValue)
 		r.Post("/add", bookController.AddBook)
+		r.Post("/batch", batchController.ApplyBatch)
 		r.Delete("/{id}", bookController.DeleteBook)
 		r.Patch("/{id}", bookController.UpdateBook)
 		r.Put("/{id}/restore", bookController.RestoreBook)
+		r.Post("/{id}/merge", duplicateController.MergeBooks)
+		r.Post("/{id}/cover", coverController.UploadCover)
+		r.Delete("/{id}/cover", coverController.RemoveCover)
+
+		// Reading session routes
+		r.Get("/{id}/sessions", readingSessionController.ListSessions)
+		r.Post("/{id}/sessions", readingSessionController.AddSession)
+		r.Patch("/{id}/sessions/{sessionID}", readingSessionController.UpdateSession)
+		r.Delete("/{id}/sessions/{sessionID}", readingSessionController.DeleteSession)
+		r.Get("/{id}/reading-speed", readingSessionController.GetReadingSpeed)
+
+		// Re-read routes
+		r.Get("/{id}/reads", readThroughController.ListReadThroughs)
+		r.Post("/{id}/reads", readThroughController.StartReread)
+		r.Delete("/{id}/reads/{readID}", readThroughController.DeleteReadThrough)
+
+		// Series routes
+		r.Put("/{id}/series", seriesController.SetBookSeries)
+
+		// Note and highlight routes
+		r.Get("/{id}/notes", noteController.ListNotes)
+		r.Post("/{id}/notes", noteController.AddNote)
+		r.Get("/{id}/notes/highlights", noteController.ListHighlights)
+		r.Post("/{id}/notes/highlights", noteController.AddHighlight)
+		r.Patch("/{id}/notes/highlights/{highlightID}", noteController.UpdateHighlight)
+		r.Delete("/{id}/notes/highlights/{highlightID}", noteController.DeleteHighlight)
+		r.Patch("/{id}/notes/{noteID}", noteController.UpdateNote)
+		r.Delete("/{id}/notes/{noteID}", noteController.DeleteNote)
+
+		// Review routes
+		r.Get("/{id}/reviews", reviewController.ListReviews)
+		r.Post("/{id}/reviews", reviewController.AddReview)
+		r.Patch("/{id}/reviews/{reviewID}", reviewController.UpdateReview)
+		r.Delete("/{id}/reviews/{reviewID}", reviewController.DeleteReview)
+
+		// Loan routes
+		r.Get("/{id}/loans", loanController.ListLoans)
+		r.Post("/{id}/loans", loanController.LendBook)
+		r.Post("/{id}/loans/return", loanController.ReturnBook)
+
+		// Copy routes
+		r.Get("/{id}/copies", copyController.ListCopies)
+		r.Post("/{id}/copies", copyController.AddCopy)
+		r.Patch("/{id}/copies/{copyID}", copyController.UpdateCopy)
+		r.Delete("/{id}/copies/{copyID}", copyController.DeleteCopy)
+	})
+
+	// Copies across the whole library, to find where a book is kept
+	r.Route("/api/copies", func(r chi.Router) {
+		r.Use(authMiddleware.Handler)
+		r.Get("/", copyController.FindCopies)
+	})
+
+	// Loans across all books
+	r.Route("/api/loans", func(r chi.Router) {
+		r.Use(authMiddleware.Handler)
+		r.Get("/", loanController.ListLentOut)
+		r.Get("/overdue", loanController.ListOverdue)
+	})
+
+	// Full-text search over the user's books, notes and highlights
+	r.Route("/api/search", func(r chi.Router) {
+		r.Use(authMiddleware.Handler)
+		r.Get("/", searchController.Search)
+	})
+
+	// Note search across all books
+	r.Route("/api/notes", func(r chi.Router) {
+		r.Use(authMiddleware.Handler)
+		r.Get("/search", noteController.SearchNotes)
+	})
+
+	// Cover routes. Uploaded covers are public so they work in image tags.
+	r.Route("/api/covers", func(r chi.Router) {
+		r.Get("/{coverID}/{size}", coverController.GetCover)
+		r.With(authMiddleware.Handler).Get("/proxy", coverController.ProxyCover)
+	})
+
+	// Wishlist routes
+	r.Route("/api/wishlist", func(r chi.Router) {
+		r.Use(authMiddleware.Handler)
+		r.Get("/", wishlistController.ListWishlist)
+		r.Post("/", wishlistController.AddWishlistItem)
+		r.Patch("/{id}", wishlistController.UpdateWishlistItem)
+		r.Delete("/{id}", wishlistController.DeleteWishlistItem)
+		r.Post("/{id}/price", wishlistController.RefreshPrice)
+		r.Post("/{id}/purchase", wishlistController.PurchaseItem)
+	})
+
+	// Shelf routes
+	r.Route("/api/shelves", func(r chi.Router) {
+		r.Use(authMiddleware.Handler)
+		r.Get("/", shelfController.ListShelves)
+		r.Post("/", shelfController.CreateShelf)
+		r.Patch("/{id}", shelfController.RenameShelf)
+		r.Delete("/{id}", shelfController.DeleteShelf)
+		r.Post("/{id}/books", shelfController.AddBooks)
+		r.Delete("/{id}/books", shelfController.RemoveBooks)
+	})
+
+	// Smart shelf routes, saved filters evaluated on every request
+	r.Route("/api/smart-shelves", func(r chi.Router) {
+		r.Use(authMiddleware.Handler)
+		r.Get("/", smartShelfController.ListSmartShelves)
+		r.Post("/", smartShelfController.CreateSmartShelf)
+		r.Get("/{id}/books", smartShelfController.GetSmartShelfBooks)
+		r.Delete("/{id}", smartShelfController.DeleteSmartShelf)
+	})
+
+	// Series routes
+	r.Route("/api/series", func(r chi.Router) {
+		r.Use(authMiddleware.Handler)
+		r.Get("/", seriesController.ListSeries)
+		r.Post("/", seriesController.CreateSeries)
+		r.Get("/{id}", seriesController.GetSeries)
+		r.Patch("/{id}", seriesController.UpdateSeries)
+		r.Delete("/{id}", seriesController.DeleteSeries)
+		r.Get("/{id}/next", seriesController.NextUnread)
+	})
+
+	// Custom field routes
+	r.Route("/api/custom-fields", func(r chi.Router) {
+		r.Use(authMiddleware.Handler)
+		r.Get("/", customFieldController.ListCustomFields)
+		r.Post("/", customFieldController.CreateCustomField)
+		r.Patch("/{id}", customFieldController.UpdateCustomField)
+		r.Delete("/{id}", customFieldController.DeleteCustomField)
+	})
+
+	// Author routes
+	r.Route("/api/authors", func(r chi.Router) {
+		r.Use(authMiddleware.Handler)
+		r.Get("/", authorController.ListAuthors)
+		r.Get("/{id}", authorController.GetAuthor)
 	})
 
 	// User routes
@@ -76,12 +236,15 @@ func SetupRouter(r *chi.Mux, db *gorm.DB) {
 		r.Use(authMiddleware.Handler)
 		r.Get("/reading-goal", bookController.GetReadingGoal)
 		r.Put("/reading-goal", bookController.UpdateReadingGoal)
+		r.Get("/rating-scale", bookController.GetRatingScale)
+		r.Put("/rating-scale", bookController.UpdateRatingScale)
 
 		r.Get("/streak-settings", streakSettingsController.GetStreakSettings)
 		r.Post("/streak-settings", streakSettingsController.UpdateStreakSettings)
 
 		// Goal History routes
 		r.Post("/goal-history", goalHistoryController.RecordGoalCompletion)
+		r.Post("/goal-history/rebuild", goalHistoryController.RebuildGoalHistory)
 		r.Get("/goal-stats", goalHistoryController.GetGoalStats)
 	})
 
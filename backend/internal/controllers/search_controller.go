@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"gorm.io/gorm"
+)
+
+type SearchController struct {
+	SearchService services.SearchService
+}
+
+func NewSearchController(db *gorm.DB) *SearchController {
+	return &SearchController{
+		SearchService: services.NewSearchService(db),
+	}
+}
+
+// Search handles GET /api/search?q=&limit=
+func (c *SearchController) Search(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	params := r.URL.Query()
+	limit, err := parseIntParam(params.Get("limit"), "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := c.SearchService.Search(r.Context(), userID, params.Get("q"), limit)
+	if err != nil {
+		writeServiceError(w, "search books", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+	})
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type SeriesController struct {
+	SeriesService services.SeriesService
+}
+
+func NewSeriesController(db *gorm.DB) *SeriesController {
+	return &SeriesController{
+		SeriesService: services.NewSeriesService(db),
+	}
+}
+
+type seriesRequest struct {
+	Name         string `json:"name"`
+	TotalVolumes uint   `json:"total_volumes"`
+}
+
+func (req seriesRequest) toModel() models.Series {
+	return models.Series{
+		Name:         req.Name,
+		TotalVolumes: req.TotalVolumes,
+	}
+}
+
+// ListSeries handles GET /api/series
+func (c *SeriesController) ListSeries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	series, err := c.SeriesService.ListSeries(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "fetch series", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"series": series,
+	})
+}
+
+// GetSeries handles GET /api/series/{id}
+func (c *SeriesController) GetSeries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	series, err := c.SeriesService.GetSeries(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "fetch series", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// CreateSeries handles POST /api/series
+func (c *SeriesController) CreateSeries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req seriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	series, err := c.SeriesService.CreateSeries(r.Context(), userID, req.toModel())
+	if err != nil {
+		writeServiceError(w, "create series", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(series)
+}
+
+// UpdateSeries handles PATCH /api/series/{id}
+func (c *SeriesController) UpdateSeries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req seriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	series, err := c.SeriesService.UpdateSeries(r.Context(), userID, chi.URLParam(r, "id"), req.toModel())
+	if err != nil {
+		writeServiceError(w, "update series", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// DeleteSeries handles DELETE /api/series/{id}
+func (c *SeriesController) DeleteSeries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.SeriesService.DeleteSeries(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		writeServiceError(w, "delete series", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Series deleted successfully",
+	})
+}
+
+// NextUnread handles GET /api/series/{id}/next
+func (c *SeriesController) NextUnread(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	book, err := c.SeriesService.NextUnread(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "fetch next book in series", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"book": book,
+	})
+}
+
+// SetBookSeries handles PUT /api/books/{id}/series. A null series_id takes the
+// book out of its series.
+func (c *SeriesController) SetBookSeries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		SeriesID *uint    `json:"series_id"`
+		Position *float64 `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	book, err := c.SeriesService.SetBookSeries(r.Context(), userID, chi.URLParam(r, "id"), req.SeriesID, req.Position)
+	if err != nil {
+		writeServiceError(w, "update book series", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(book)
+}
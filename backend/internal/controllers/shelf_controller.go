@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type ShelfController struct {
+	ShelfService services.ShelfService
+}
+
+func NewShelfController(db *gorm.DB) *ShelfController {
+	return &ShelfController{
+		ShelfService: services.NewShelfService(db),
+	}
+}
+
+type shelfRequest struct {
+	Name string `json:"name"`
+}
+
+type shelfBooksRequest struct {
+	BookIDs []uint `json:"book_ids"`
+}
+
+// ListShelves handles GET /api/shelves
+func (c *ShelfController) ListShelves(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	shelves, err := c.ShelfService.ListShelves(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "fetch shelves", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"shelves": shelves,
+	})
+}
+
+// CreateShelf handles POST /api/shelves
+func (c *ShelfController) CreateShelf(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req shelfRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	shelf, err := c.ShelfService.CreateShelf(r.Context(), userID, req.Name)
+	if err != nil {
+		writeServiceError(w, "create shelf", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shelf)
+}
+
+// RenameShelf handles PATCH /api/shelves/{id}
+func (c *ShelfController) RenameShelf(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req shelfRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	shelf, err := c.ShelfService.RenameShelf(r.Context(), userID, chi.URLParam(r, "id"), req.Name)
+	if err != nil {
+		writeServiceError(w, "rename shelf", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shelf)
+}
+
+// DeleteShelf handles DELETE /api/shelves/{id}
+func (c *ShelfController) DeleteShelf(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.ShelfService.DeleteShelf(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		writeServiceError(w, "delete shelf", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Shelf deleted successfully",
+	})
+}
+
+// AddBooks handles POST /api/shelves/{id}/books
+func (c *ShelfController) AddBooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req shelfBooksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	shelf, err := c.ShelfService.AddBooks(r.Context(), userID, chi.URLParam(r, "id"), req.BookIDs)
+	if err != nil {
+		writeServiceError(w, "add books to shelf", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shelf)
+}
+
+// RemoveBooks handles DELETE /api/shelves/{id}/books
+func (c *ShelfController) RemoveBooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req shelfBooksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	shelf, err := c.ShelfService.RemoveBooks(r.Context(), userID, chi.URLParam(r, "id"), req.BookIDs)
+	if err != nil {
+		writeServiceError(w, "remove books from shelf", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shelf)
+}
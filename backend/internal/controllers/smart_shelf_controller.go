@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type SmartShelfController struct {
+	SmartShelfService services.SmartShelfService
+}
+
+func NewSmartShelfController(db *gorm.DB) *SmartShelfController {
+	return &SmartShelfController{
+		SmartShelfService: services.NewSmartShelfService(db),
+	}
+}
+
+type smartShelfRequest struct {
+	Name   string             `json:"name"`
+	Filter models.ShelfFilter `json:"filter"`
+}
+
+// ListSmartShelves handles GET /api/smart-shelves
+func (c *SmartShelfController) ListSmartShelves(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	shelves, err := c.SmartShelfService.ListSmartShelves(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "fetch smart shelves", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"smart_shelves": shelves,
+	})
+}
+
+// CreateSmartShelf handles POST /api/smart-shelves
+func (c *SmartShelfController) CreateSmartShelf(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req smartShelfRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	shelf, err := c.SmartShelfService.CreateSmartShelf(r.Context(), userID, req.Name, req.Filter)
+	if err != nil {
+		writeServiceError(w, "create smart shelf", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shelf)
+}
+
+// GetSmartShelfBooks handles GET /api/smart-shelves/{id}/books?page=&page_size=&cursor=
+func (c *SmartShelfController) GetSmartShelfBooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	params := r.URL.Query()
+	paging := services.BookQuery{Cursor: params.Get("cursor")}
+	var err error
+	if paging.Page, err = parseIntParam(params.Get("page"), "page"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if paging.PageSize, err = parseIntParam(params.Get("page_size"), "page_size"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := c.SmartShelfService.EvaluateSmartShelf(r.Context(), userID, chi.URLParam(r, "id"), paging)
+	if err != nil {
+		writeServiceError(w, "evaluate smart shelf", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// DeleteSmartShelf handles DELETE /api/smart-shelves/{id}
+func (c *SmartShelfController) DeleteSmartShelf(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.SmartShelfService.DeleteSmartShelf(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		writeServiceError(w, "delete smart shelf", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Smart shelf deleted successfully",
+	})
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
+	"github.com/RyanFloresTT/Book-Collection-Backend/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type WishlistController struct {
+	WishlistService services.WishlistService
+}
+
+func NewWishlistController(db *gorm.DB) *WishlistController {
+	return &WishlistController{
+		WishlistService: services.NewWishlistService(db),
+	}
+}
+
+type wishlistItemRequest struct {
+	Title       string   `json:"title"`
+	Author      string   `json:"author"`
+	ISBN        string   `json:"isbn"`
+	CoverImage  string   `json:"coverImage"`
+	Format      string   `json:"format"`
+	TargetPrice *float64 `json:"target_price"`
+	Currency    string   `json:"currency"`
+}
+
+func (req wishlistItemRequest) toModel() models.WishlistItem {
+	return models.WishlistItem{
+		Title:       req.Title,
+		Author:      req.Author,
+		ISBN:        req.ISBN,
+		CoverImage:  req.CoverImage,
+		Format:      req.Format,
+		TargetPrice: req.TargetPrice,
+		Currency:    req.Currency,
+	}
+}
+
+type purchaseRequest struct {
+	Price       *float64   `json:"price"`
+	Currency    string     `json:"currency"`
+	PurchasedAt *time.Time `json:"purchased_at"`
+	Store       string     `json:"store"`
+	Format      string     `json:"format"`
+	Status      string     `json:"status"`
+}
+
+func (req purchaseRequest) toPurchase() services.Purchase {
+	return services.Purchase{
+		Price:       req.Price,
+		Currency:    req.Currency,
+		PurchasedAt: req.PurchasedAt,
+		Store:       req.Store,
+		Format:      req.Format,
+		Status:      req.Status,
+	}
+}
+
+// ListWishlist handles GET /api/wishlist
+func (c *WishlistController) ListWishlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	items, err := c.WishlistService.ListWishlist(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "fetch wishlist", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items": items,
+	})
+}
+
+// AddWishlistItem handles POST /api/wishlist
+func (c *WishlistController) AddWishlistItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req wishlistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	item, err := c.WishlistService.AddWishlistItem(r.Context(), userID, req.toModel())
+	if err != nil {
+		writeServiceError(w, "add wishlist item", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// UpdateWishlistItem handles PATCH /api/wishlist/{id}
+func (c *WishlistController) UpdateWishlistItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req wishlistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	item, err := c.WishlistService.UpdateWishlistItem(r.Context(), userID, chi.URLParam(r, "id"), req.toModel())
+	if err != nil {
+		writeServiceError(w, "update wishlist item", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// DeleteWishlistItem handles DELETE /api/wishlist/{id}
+func (c *WishlistController) DeleteWishlistItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	err := c.WishlistService.DeleteWishlistItem(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, "delete wishlist item", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Wishlist item deleted successfully",
+	})
+}
+
+// RefreshPrice handles POST /api/wishlist/{id}/price
+func (c *WishlistController) RefreshPrice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	item, err := c.WishlistService.RefreshPrice(r.Context(), userID, chi.URLParam(r, "id"))
+	if errors.Is(err, services.ErrPriceLookupFailed) {
+		fmt.Println("Error looking up wishlist price:", err)
+		http.Error(w, "Failed to look up the current price", http.StatusBadGateway)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, "refresh price", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// PurchaseItem handles POST /api/wishlist/{id}/purchase
+func (c *WishlistController) PurchaseItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req purchaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	book, err := c.WishlistService.PurchaseItem(r.Context(), userID, chi.URLParam(r, "id"), req.toPurchase())
+	if err != nil {
+		writeServiceError(w, "record purchase", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(book)
+}
+
+// CollectionValue handles GET /api/books/value
+func (c *WishlistController) CollectionValue(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	value, err := c.WishlistService.CollectionValue(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, "calculate collection value", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
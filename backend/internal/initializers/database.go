@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	services "github.com/RyanFloresTT/Book-Collection-Backend/internal/service"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -21,11 +22,81 @@ func InitializeDatabase() *gorm.DB {
 	}
 
 	// Auto-migrate models
+	err = db.AutoMigrate(&models.Series{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate series model: %v", err)
+	}
+
 	err = db.AutoMigrate(&models.Book{})
 	if err != nil {
 		log.Fatalf("Failed to auto-migrate book model: %v", err)
 	}
 
+	err = db.AutoMigrate(&models.ReadingSession{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate reading session model: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.ReadThrough{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate read-through model: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Review{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate review model: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Note{}, &models.Highlight{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate note models: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Loan{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate loan model: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.BookCopy{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate copy model: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.WishlistItem{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate wishlist model: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.CustomField{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate custom field model: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.BookSearch{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate book search model: %v", err)
+	}
+
+	err = services.SetupSearchIndex(db)
+	if err != nil {
+		log.Fatalf("Failed to set up the search index: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Author{}, &models.BookAuthor{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate author models: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Shelf{}, &models.BookShelf{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate shelf models: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.SmartShelf{})
+	if err != nil {
+		log.Fatalf("Failed to auto-migrate smart shelf model: %v", err)
+	}
+
 	err = db.AutoMigrate(&models.User{})
 	if err != nil {
 		log.Fatalf("Failed to auto-migrate user model: %v", err)
@@ -51,10 +122,54 @@ func InitializeDatabase() *gorm.DB {
 		log.Fatalf("Failed to auto-migrate goal stats model: %v", err)
 	}
 
+	err = BackfillBookStatus(db)
+	if err != nil {
+		log.Fatalf("Failed to back-fill book status: %v", err)
+	}
+
+	err = services.BackfillBookAuthors(db)
+	if err != nil {
+		log.Fatalf("Failed to back-fill book authors: %v", err)
+	}
+
+	err = services.BackfillBookNarrators(db)
+	if err != nil {
+		log.Fatalf("Failed to back-fill book narrators: %v", err)
+	}
+
+	err = services.BackfillBookISBNs(db)
+	if err != nil {
+		log.Fatalf("Failed to back-fill book ISBNs: %v", err)
+	}
+
+	err = services.BackfillSearchIndex(db)
+	if err != nil {
+		log.Fatalf("Failed to back-fill the search index: %v", err)
+	}
+
 	log.Println("Database connected and models migrated")
 	return db
 }
 
+// BackfillBookStatus sets the reading status of books created before the column existed
+func BackfillBookStatus(db *gorm.DB) error {
+	missing := "status IS NULL OR status = ''"
+
+	if err := db.Model(&models.Book{}).Unscoped().
+		Where(missing).Where("finished_at IS NOT NULL").
+		Update("status", models.StatusFinished).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.Book{}).Unscoped().
+		Where(missing).Where("started_at IS NOT NULL").
+		Update("status", models.StatusReading).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.Book{}).Unscoped().
+		Where(missing).
+		Update("status", models.StatusWantToRead).Error
+}
+
 func CloseDatabase(db *gorm.DB) {
 	sqlDB, err := db.DB()
 	if err != nil {
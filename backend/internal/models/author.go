@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// Roles a person can have on a book
+const (
+	RoleAuthor     = "author"
+	RoleTranslator = "translator"
+	RoleNarrator   = "narrator"
+	RoleEditor     = "editor"
+)
+
+// IsValidAuthorRole reports whether role is one of the known author roles
+func IsValidAuthorRole(role string) bool {
+	switch role {
+	case RoleAuthor, RoleTranslator, RoleNarrator, RoleEditor:
+		return true
+	}
+	return false
+}
+
+// Author is a person credited on books. Authors are shared between users and
+// matched on their normalized name, so "J.R.R. Tolkien" and "J. R. R. Tolkien"
+// are the same author.
+type Author struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Name           string    `json:"name" gorm:"not null"`
+	NormalizedName string    `json:"-" gorm:"uniqueIndex;not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// BookAuthor credits an author on a book in a given role. Position keeps the
+// order the credits were given in.
+type BookAuthor struct {
+	BookID   uint   `json:"-" gorm:"primaryKey"`
+	AuthorID uint   `json:"author_id" gorm:"primaryKey"`
+	Role     string `json:"role" gorm:"primaryKey"`
+	Position int    `json:"position"`
+	Author   Author `json:"author" gorm:"foreignKey:AuthorID"`
+}
@@ -8,15 +8,67 @@ import (
 
 type Book struct {
 	gorm.Model
-	Title      string     `json:"title" gorm:"not null"`
-	Author     string     `json:"author" gorm:"not null"`
-	CoverImage string     `json:"coverImage"`
-	Rating     float64    `json:"rating"`
-	PageCount  uint       `json:"page_count"`
-	Genre      string     `json:"genre"`
-	UserID     uint       `json:"user_id"`
-	StartedAt  *time.Time `json:"started_at"`
-	FinishedAt *time.Time `json:"finished_at"`
+	Title       string     `json:"title" gorm:"not null"`
+	Author      string     `json:"author" gorm:"not null"`
+	ISBN        string     `json:"isbn" gorm:"column:isbn;index"`
+	CoverImage  string     `json:"coverImage"`
+	Rating      float64    `json:"rating"`
+	PageCount   uint       `json:"page_count"`
+	Genre       string     `json:"genre"`
+	UserID      uint       `json:"user_id" gorm:"index"`
+	Status      string     `json:"status" gorm:"index"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	StoppedAt   *time.Time `json:"stopped_at"`
+	StoppedPage *uint      `json:"stopped_page"`
+
+	// Edition details. ISBN is kept as entered, with its ISBN-10 and ISBN-13
+	// forms filled in when it can be converted. PublishDate may be just a year
+	// or a year and month, and Language is an ISO 639 code.
+	Format          string `json:"format" gorm:"index"`
+	ISBN10          string `json:"isbn10" gorm:"column:isbn10"`
+	ISBN13          string `json:"isbn13" gorm:"column:isbn13;index"`
+	Publisher       string `json:"publisher"`
+	PublishDate     string `json:"publish_date"`
+	Language        string `json:"language"`
+	DurationMinutes uint   `json:"duration_minutes"`
+	Narrator        string `json:"narrator"`
+
+	// What the user paid for their copy, in PurchaseCurrency
+	PurchasePrice    *float64   `json:"purchase_price"`
+	PurchaseCurrency string     `json:"purchase_currency"`
+	PurchasedAt      *time.Time `json:"purchased_at"`
+	PurchaseStore    string     `json:"purchase_store"`
+
+	// Values of the user's custom fields by key, see CustomField
+	CustomFields CustomFieldValues `json:"custom_fields" gorm:"type:jsonb;default:'{}'"`
+
+	SeriesID       *uint    `json:"series_id" gorm:"index"`
+	SeriesPosition *float64 `json:"series_position"`
+	Series         *Series  `json:"series,omitempty" gorm:"foreignKey:SeriesID"`
+
+	// Author and Narrator hold the display names of the book's authors and
+	// narrators, while Authors has every credited person along with their role
+	Authors      []BookAuthor  `json:"authors,omitempty" gorm:"foreignKey:BookID"`
+	ReadThroughs []ReadThrough `json:"read_throughs,omitempty" gorm:"foreignKey:BookID"`
+	Shelves      []Shelf       `json:"shelves,omitempty" gorm:"many2many:book_shelves"`
+	Copies       []BookCopy    `json:"copies,omitempty" gorm:"foreignKey:BookID"`
+
+	// Derived from the book's reading sessions, not stored
+	CurrentPage     *uint    `json:"current_page,omitempty" gorm:"-"`
+	CurrentMinute   *uint    `json:"current_minute,omitempty" gorm:"-"`
+	ProgressPercent *float64 `json:"progress_percent,omitempty" gorm:"-"`
+
+	// Derived from the book's loans, not stored
+	OnLoan bool `json:"on_loan" gorm:"-"`
+}
+
+// BeforeCreate fills in the reading status from the timestamps when none was given
+func (b *Book) BeforeCreate(tx *gorm.DB) error {
+	if b.Status == "" {
+		b.Status = b.InferStatus()
+	}
+	return nil
 }
 
 type GoogleBookResponse struct {
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// BookSearch is the full-text search document of a book, kept up to date as the
+// book and its notes change. Notes holds the text of the book's notes and
+// highlights. On Postgres the table also has a weighted tsvector column over
+// these fields with a GIN index, added outside of AutoMigrate.
+type BookSearch struct {
+	BookID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	UserID    uint   `gorm:"index;not null"`
+	Title     string `gorm:"not null"`
+	Author    string `gorm:"not null"`
+	Genre     string
+	Notes     string
+	UpdatedAt time.Time
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// The condition grades of a physical copy, from best to worst
+const (
+	ConditionNew        = "new"
+	ConditionLikeNew    = "like_new"
+	ConditionVeryGood   = "very_good"
+	ConditionGood       = "good"
+	ConditionAcceptable = "acceptable"
+	ConditionPoor       = "poor"
+)
+
+// Conditions lists every condition grade, from best to worst
+var Conditions = []string{ConditionNew, ConditionLikeNew, ConditionVeryGood, ConditionGood, ConditionAcceptable, ConditionPoor}
+
+// IsValidCondition reports whether c is one of the condition grades
+func IsValidCondition(c string) bool {
+	for _, condition := range Conditions {
+		if c == condition {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxCopyFieldLength caps the length of the location and barcode of a copy
+const MaxCopyFieldLength = 100
+
+// BookCopy is one physical copy of a book. The Book holds the bibliographic
+// record, while each copy has its own place in the library and condition.
+// Books without copies are treated as a single copy with no known location.
+type BookCopy struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	BookID         uint       `json:"book_id" gorm:"index;not null"`
+	UserID         uint       `json:"user_id" gorm:"index;not null"`
+	Room           string     `json:"room" gorm:"index"`
+	Shelf          string     `json:"shelf"`
+	Position       string     `json:"position"`
+	Condition      string     `json:"condition"`
+	ConditionNotes string     `json:"condition_notes"`
+	Barcode        string     `json:"barcode" gorm:"index"`
+	AcquiredAt     *time.Time `json:"acquired_at"`
+	Book           *Book      `json:"book,omitempty" gorm:"foreignKey:BookID"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Derived from the copy's loans, not stored
+	OnLoan bool `json:"on_loan" gorm:"-"`
+}
+
+// Location describes where the copy is kept, e.g. "Library / B3 / 12"
+func (c *BookCopy) Location() string {
+	parts := []string{}
+	for _, part := range []string{c.Room, c.Shelf, c.Position} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " / ")
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// The types a custom field can have
+const (
+	CustomFieldText   = "text"
+	CustomFieldNumber = "number"
+	CustomFieldDate   = "date"
+	CustomFieldBool   = "bool"
+	CustomFieldEnum   = "enum"
+)
+
+// CustomFieldTypes lists every custom field type
+var CustomFieldTypes = []string{CustomFieldText, CustomFieldNumber, CustomFieldDate, CustomFieldBool, CustomFieldEnum}
+
+const (
+	// MaxCustomFieldNameLength caps the length of custom field names and enum options
+	MaxCustomFieldNameLength = 100
+	// MaxCustomFieldTextLength caps the length of text values
+	MaxCustomFieldTextLength = 1000
+	// MaxCustomFields caps the number of custom fields a user can define
+	MaxCustomFields = 50
+)
+
+// IsValidCustomFieldType reports whether t is one of the custom field types
+func IsValidCustomFieldType(t string) bool {
+	for _, fieldType := range CustomFieldTypes {
+		if t == fieldType {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomField is a field the user added to their books, like "Signed copy" or
+// "Book club pick". Values are kept on each book under the field's Key, which
+// stays the same when the field is renamed. Options lists the allowed values
+// of enum fields.
+type CustomField struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	UserID    uint        `json:"user_id" gorm:"index;not null"`
+	Key       string      `json:"key" gorm:"not null"`
+	Name      string      `json:"name" gorm:"not null"`
+	Type      string      `json:"type" gorm:"not null"`
+	Options   StringArray `json:"options" gorm:"type:jsonb;default:'[]'"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type StringArray []string
+
+func (a *StringArray) Scan(value interface{}) error {
+	if value == nil {
+		*a = StringArray{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		if len(v) == 0 {
+			*a = StringArray{}
+			return nil
+		}
+		return json.Unmarshal(v, a)
+	case string:
+		if v == "" {
+			*a = StringArray{}
+			return nil
+		}
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return errors.New("unsupported type for StringArray")
+	}
+}
+
+func (a StringArray) Value() (driver.Value, error) {
+	if a == nil {
+		return json.Marshal([]string{})
+	}
+	return json.Marshal(a)
+}
+
+// CustomFieldValues holds the custom field values of a book by field key.
+// Numbers are float64, booleans bool, and text, enum and date values strings,
+// with dates as YYYY-MM-DD.
+type CustomFieldValues map[string]interface{}
+
+func (v *CustomFieldValues) Scan(value interface{}) error {
+	if value == nil {
+		*v = CustomFieldValues{}
+		return nil
+	}
+
+	switch data := value.(type) {
+	case []byte:
+		if len(data) == 0 {
+			*v = CustomFieldValues{}
+			return nil
+		}
+		return json.Unmarshal(data, v)
+	case string:
+		if data == "" {
+			*v = CustomFieldValues{}
+			return nil
+		}
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return errors.New("unsupported type for CustomFieldValues")
+	}
+}
+
+func (v CustomFieldValues) Value() (driver.Value, error) {
+	if v == nil {
+		return json.Marshal(map[string]interface{}{})
+	}
+	return json.Marshal(map[string]interface{}(v))
+}
@@ -0,0 +1,40 @@
+package models
+
+// Formats a book can be owned or read in
+const (
+	FormatHardcover = "hardcover"
+	FormatPaperback = "paperback"
+	FormatEbook     = "ebook"
+	FormatAudiobook = "audiobook"
+)
+
+// IsValidFormat reports whether format is one of the known formats. Books
+// saved before formats were tracked have no format.
+func IsValidFormat(format string) bool {
+	switch format {
+	case "", FormatHardcover, FormatPaperback, FormatEbook, FormatAudiobook:
+		return true
+	}
+	return false
+}
+
+// Units progress through a book is measured in
+const (
+	UnitPages   = "pages"
+	UnitPercent = "percent"
+	UnitMinutes = "minutes"
+)
+
+// ProgressUnit is the unit progress through the book is naturally measured
+// in: minutes of audio for audiobooks, percent for ebooks, whose pages depend
+// on the device, and pages for everything else
+func (b *Book) ProgressUnit() string {
+	switch b.Format {
+	case FormatAudiobook:
+		return UnitMinutes
+	case FormatEbook:
+		return UnitPercent
+	default:
+		return UnitPages
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// Loan records a book lent to someone. The book is on loan until ReturnedAt is
+// set. CopyID says which copy was lent when the book has several.
+type Loan struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	BookID     uint       `json:"book_id" gorm:"index;not null"`
+	CopyID     *uint      `json:"copy_id" gorm:"index"`
+	UserID     uint       `json:"user_id" gorm:"index;not null"`
+	Borrower   string     `json:"borrower" gorm:"not null"`
+	Contact    string     `json:"contact"`
+	LentAt     time.Time  `json:"lent_at"`
+	DueAt      *time.Time `json:"due_at"`
+	ReturnedAt *time.Time `json:"returned_at" gorm:"index"`
+	Book       *Book      `json:"book,omitempty" gorm:"foreignKey:BookID"`
+	Copy       *BookCopy  `json:"copy,omitempty" gorm:"foreignKey:CopyID"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// IsOverdue reports whether the loan is still out past its due date
+func (l *Loan) IsOverdue(now time.Time) bool {
+	return l.ReturnedAt == nil && l.DueAt != nil && l.DueAt.Before(now)
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// MaxNoteLength is the longest text a note or highlight may hold
+const MaxNoteLength = 10000
+
+// Note is the user's own writing about a book, optionally tied to a place in it
+type Note struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	BookID    uint      `json:"book_id" gorm:"index;not null"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	Text      string    `json:"text" gorm:"not null"`
+	Page      *uint     `json:"page"`
+	Location  string    `json:"location"`
+	Chapter   string    `json:"chapter"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Highlight is a passage quoted from a book, with an optional comment on it.
+// Location holds e-reader positions such as "Loc 1234" for books without pages.
+type Highlight struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	BookID    uint      `json:"book_id" gorm:"index;not null"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	Text      string    `json:"text" gorm:"not null"`
+	Comment   string    `json:"comment"`
+	Page      *uint     `json:"page"`
+	Location  string    `json:"location"`
+	Chapter   string    `json:"chapter"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
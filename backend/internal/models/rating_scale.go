@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// Limits on the rating scale a user can choose
+const (
+	MaxRatingScale = 100
+	MaxRatingSteps = 1000
+)
+
+// RatingScale is the range and granularity a user rates books in, e.g. 0-5 in
+// half steps. A rating of 0 means the book hasn't been rated.
+type RatingScale struct {
+	Max  float64 `json:"max"`
+	Step float64 `json:"step"`
+}
+
+// DefaultRatingScale is used for users who haven't chosen a scale
+var DefaultRatingScale = RatingScale{Max: 5, Step: 0.5}
+
+// ratingTolerance absorbs floating point error when checking a rating is on a step
+const ratingTolerance = 1e-9
+
+// Validate checks that the scale has a sensible range and the step divides it evenly
+func (s RatingScale) Validate() error {
+	if s.Max <= 0 || s.Max > MaxRatingScale {
+		return fmt.Errorf("rating scale maximum must be between 0 and %d", MaxRatingScale)
+	}
+	if s.Step <= 0 || s.Step > s.Max {
+		return fmt.Errorf("rating step must be greater than 0 and at most the maximum")
+	}
+	steps := s.Max / s.Step
+	if math.Abs(steps-math.Round(steps)) > ratingTolerance*steps {
+		return fmt.Errorf("rating step %g does not divide the maximum %g evenly", s.Step, s.Max)
+	}
+	if math.Round(steps) > MaxRatingSteps {
+		return fmt.Errorf("a rating scale cannot have more than %d steps", MaxRatingSteps)
+	}
+	return nil
+}
+
+// Check reports an error when the rating is outside the scale or between its steps
+func (s RatingScale) Check(rating float64) error {
+	if math.IsNaN(rating) || rating < 0 || rating > s.Max+ratingTolerance {
+		return fmt.Errorf("rating must be between 0 and %g", s.Max)
+	}
+	steps := rating / s.Step
+	if math.Abs(steps-math.Round(steps)) > ratingTolerance*math.Max(steps, 1) {
+		return fmt.Errorf("rating must be in steps of %g", s.Step)
+	}
+	return nil
+}
+
+// Convert moves a rating from another scale onto this one, rounding it to the nearest step
+func (s RatingScale) Convert(rating float64, from RatingScale) float64 {
+	if rating == 0 || from.Max == 0 {
+		return rating
+	}
+	converted := math.Round(rating/from.Max*s.Max/s.Step) * s.Step
+	return math.Min(math.Max(converted, s.Step), s.Max)
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// ReadThrough is a completed earlier read of a book. The book itself always holds
+// the current read, so a book read three times has two read-throughs.
+type ReadThrough struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	BookID     uint       `json:"book_id" gorm:"index;not null"`
+	UserID     uint       `json:"user_id" gorm:"index;not null"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at" gorm:"index"`
+	Rating     float64    `json:"rating"`
+	Notes      string     `json:"notes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// ReadingSession records progress made on a book in a single sitting, as pages,
+// as a percentage for books without a reliable page count, or as the position
+// in minutes for audiobooks. Minutes is the time spent in the session.
+type ReadingSession struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	BookID       uint      `json:"book_id" gorm:"index;not null"`
+	UserID       uint      `json:"user_id" gorm:"index;not null"`
+	Date         time.Time `json:"date"`
+	StartPage    *uint     `json:"start_page"`
+	EndPage      *uint     `json:"end_page"`
+	StartPercent *float64  `json:"start_percent"`
+	EndPercent   *float64  `json:"end_percent"`
+	StartMinute  *uint     `json:"start_minute"`
+	EndMinute    *uint     `json:"end_minute"`
+	Minutes      uint      `json:"minutes"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
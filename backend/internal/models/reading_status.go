@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	StatusWantToRead   = "want_to_read"
+	StatusReading      = "reading"
+	StatusFinished     = "finished"
+	StatusDidNotFinish = "did_not_finish"
+	StatusPaused       = "paused"
+)
+
+// statusTransitions lists the statuses a book may move to from each status
+var statusTransitions = map[string][]string{
+	StatusWantToRead:   {StatusReading, StatusFinished},
+	StatusReading:      {StatusWantToRead, StatusFinished, StatusDidNotFinish, StatusPaused},
+	StatusPaused:       {StatusReading, StatusFinished, StatusDidNotFinish},
+	StatusFinished:     {StatusReading, StatusWantToRead},
+	StatusDidNotFinish: {StatusReading, StatusWantToRead},
+}
+
+// IsValidStatus reports whether status is one of the known reading statuses
+func IsValidStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+// CanTransition reports whether a book may move from one status to another
+func CanTransition(from string, to string) bool {
+	if from == "" || from == to {
+		return IsValidStatus(to)
+	}
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// InferStatus derives a status from the started and finished timestamps
+func (b *Book) InferStatus() string {
+	switch {
+	case b.FinishedAt != nil:
+		return StatusFinished
+	case b.StartedAt != nil:
+		return StatusReading
+	default:
+		return StatusWantToRead
+	}
+}
+
+// TransitionTo moves the book to a new status, validating the transition and
+// keeping the timestamps consistent with it. page is only used when stopping
+// a book (did-not-finish or paused).
+func (b *Book) TransitionTo(status string, at time.Time, page *uint) error {
+	if !IsValidStatus(status) {
+		return fmt.Errorf("invalid status: %s", status)
+	}
+	if !CanTransition(b.Status, status) {
+		return fmt.Errorf("cannot change status from %s to %s", b.Status, status)
+	}
+	if page != nil && b.PageCount > 0 && *page > b.PageCount {
+		return fmt.Errorf("page %d is beyond the book's page count of %d", *page, b.PageCount)
+	}
+
+	switch status {
+	case StatusWantToRead:
+		b.StartedAt = nil
+		b.FinishedAt = nil
+		b.StoppedAt = nil
+		b.StoppedPage = nil
+	case StatusReading:
+		if b.StartedAt == nil || b.Status == StatusFinished || b.Status == StatusDidNotFinish {
+			b.StartedAt = &at
+		}
+		b.FinishedAt = nil
+		b.StoppedAt = nil
+		b.StoppedPage = nil
+	case StatusFinished:
+		if b.StartedAt == nil {
+			b.StartedAt = &at
+		}
+		if b.FinishedAt == nil {
+			b.FinishedAt = &at
+		}
+		b.StoppedAt = nil
+		b.StoppedPage = nil
+	case StatusDidNotFinish, StatusPaused:
+		if b.StartedAt == nil {
+			b.StartedAt = &at
+		}
+		b.FinishedAt = nil
+		b.StoppedAt = &at
+		b.StoppedPage = page
+	}
+
+	b.Status = status
+	return nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// MaxReviewLength is the longest review body that can be saved
+const MaxReviewLength = 50000
+
+// Aspects a review can rate separately from the book's overall rating
+const (
+	AspectPlot          = "plot"
+	AspectCharacters    = "characters"
+	AspectProse         = "prose"
+	AspectPacing        = "pacing"
+	AspectWorldbuilding = "worldbuilding"
+)
+
+// IsValidReviewAspect reports whether aspect is one of the aspects a review can rate
+func IsValidReviewAspect(aspect string) bool {
+	switch aspect {
+	case AspectPlot, AspectCharacters, AspectProse, AspectPacing, AspectWorldbuilding:
+		return true
+	}
+	return false
+}
+
+// AspectRatings maps review aspects to their rating, stored as a JSON object
+type AspectRatings map[string]float64
+
+func (a *AspectRatings) Scan(value interface{}) error {
+	if value == nil {
+		*a = AspectRatings{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		if len(v) == 0 {
+			*a = AspectRatings{}
+			return nil
+		}
+		return json.Unmarshal(v, a)
+	case string:
+		if v == "" {
+			*a = AspectRatings{}
+			return nil
+		}
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return errors.New("unsupported type for AspectRatings")
+	}
+}
+
+func (a AspectRatings) Value() (driver.Value, error) {
+	if a == nil {
+		return json.Marshal(map[string]float64{})
+	}
+	return json.Marshal(a)
+}
+
+// Review is a written review of a book. The body is markdown and is kept as written.
+type Review struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	BookID        uint          `json:"book_id" gorm:"index;not null"`
+	UserID        uint          `json:"user_id" gorm:"index;not null"`
+	Body          string        `json:"body" gorm:"type:text"`
+	Spoiler       bool          `json:"spoiler"`
+	ReviewedAt    time.Time     `json:"reviewed_at"`
+	AspectRatings AspectRatings `json:"aspect_ratings" gorm:"type:jsonb;default:'{}'"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// Series groups books that are read in order. Books link to it with a position,
+// which may be fractional for novellas set between volumes, like 2.5.
+type Series struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"index;not null"`
+	Name         string    `json:"name" gorm:"not null"`
+	TotalVolumes uint      `json:"total_volumes"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// Shelf is a user-defined shelf or tag. Unlike the reading status a book can be
+// on any number of shelves.
+type Shelf struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	Name      string    `json:"name" gorm:"not null"`
+	Books     []Book    `json:"-" gorm:"many2many:book_shelves"`
+	BookCount int64     `json:"book_count" gorm:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MaxShelfNameLength caps the length of shelf names
+const MaxShelfNameLength = 100
+
+// BookShelf is a row of the join table between books and shelves
+type BookShelf struct {
+	BookID  uint `gorm:"primaryKey"`
+	ShelfID uint `gorm:"primaryKey"`
+}
+
+func (BookShelf) TableName() string {
+	return "book_shelves"
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// The operators of smart shelf conditions. Text fields compare ignoring case,
+// and contains only applies to text. In takes a list of values, and exists
+// takes true or false to match books with or without a value.
+const (
+	FilterEquals      = "eq"
+	FilterNotEquals   = "ne"
+	FilterGreater     = "gt"
+	FilterGreaterOrEq = "gte"
+	FilterLess        = "lt"
+	FilterLessOrEq    = "lte"
+	FilterContains    = "contains"
+	FilterIn          = "in"
+	FilterExists      = "exists"
+)
+
+// MaxSmartShelfConditions caps the number of conditions of a smart shelf
+const MaxSmartShelfConditions = 20
+
+// SmartShelf is a saved filter over the user's books, like "unread fantasy over
+// 400 pages, rated 4+". Unlike a Shelf it has no books of its own, the filter
+// is evaluated again every time the shelf is opened.
+type SmartShelf struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	UserID    uint        `json:"user_id" gorm:"index;not null"`
+	Name      string      `json:"name" gorm:"not null"`
+	Filter    ShelfFilter `json:"filter" gorm:"type:jsonb;not null"`
+	BookCount int64       `json:"book_count" gorm:"-"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// ShelfFilter is the definition of a smart shelf. Books have to match every
+// condition, and are ordered by one of the collection's sort keys.
+type ShelfFilter struct {
+	Conditions []FilterCondition `json:"conditions"`
+	Sort       string            `json:"sort,omitempty"`
+	Order      string            `json:"order,omitempty"`
+}
+
+// FilterCondition compares a book field with a value. Field is the JSON name of
+// a Book field, or custom_fields.<key> for one of the user's custom fields.
+type FilterCondition struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+func (f *ShelfFilter) Scan(value interface{}) error {
+	if value == nil {
+		*f = ShelfFilter{}
+		return nil
+	}
+
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, f)
+	case string:
+		return json.Unmarshal([]byte(data), f)
+	default:
+		return errors.New("unsupported type for ShelfFilter")
+	}
+}
+
+func (f ShelfFilter) Value() (driver.Value, error) {
+	if f.Conditions == nil {
+		f.Conditions = []FilterCondition{}
+	}
+	return json.Marshal(f)
+}
@@ -9,4 +9,17 @@ type User struct {
 	Books            []Book `json:"books" gorm:"foreignKey:UserID"`
 	StripeCustomerID string `json:"stripeCustomerId"`
 	ReadingGoal      int    `json:"readingGoal" gorm:"default:0"`
+
+	// The scale the user rates books in, see RatingScale
+	RatingMax  float64 `json:"ratingMax" gorm:"default:5"`
+	RatingStep float64 `json:"ratingStep" gorm:"default:0.5"`
+}
+
+// RatingScale returns the user's rating scale, falling back to the default for
+// users saved before scales could be chosen
+func (u *User) RatingScale() RatingScale {
+	if u.RatingMax <= 0 || u.RatingStep <= 0 {
+		return DefaultRatingScale
+	}
+	return RatingScale{Max: u.RatingMax, Step: u.RatingStep}
 }
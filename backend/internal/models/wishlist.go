@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// DefaultCurrency is used for prices given without a currency
+const DefaultCurrency = "USD"
+
+// WishlistItem is a book the user wants to own but doesn't have yet.
+// TargetPrice is what they would pay for it, in Currency, while CurrentPrice is
+// the last price found for it, in CurrentCurrency.
+type WishlistItem struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"index;not null"`
+	Title           string     `json:"title" gorm:"not null"`
+	Author          string     `json:"author" gorm:"not null"`
+	ISBN            string     `json:"isbn" gorm:"column:isbn"`
+	CoverImage      string     `json:"coverImage"`
+	Format          string     `json:"format"`
+	TargetPrice     *float64   `json:"target_price"`
+	Currency        string     `json:"currency"`
+	CurrentPrice    *float64   `json:"current_price"`
+	CurrentCurrency string     `json:"current_currency"`
+	BuyLink         string     `json:"buy_link"`
+	PriceCheckedAt  *time.Time `json:"price_checked_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Derived from the prices, not stored
+	AtTargetPrice bool `json:"at_target_price" gorm:"-"`
+}
+
+// IsAtTargetPrice reports whether the last price found is at or below the target price
+func (w *WishlistItem) IsAtTargetPrice() bool {
+	return w.TargetPrice != nil && w.CurrentPrice != nil &&
+		w.CurrentCurrency == w.Currency && *w.CurrentPrice <= *w.TargetPrice
+}
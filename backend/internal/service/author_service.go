@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuthorSummary is an author with the number of the user's books they are credited on
+type AuthorSummary struct {
+	models.Author
+	BookCount int64 `json:"book_count"`
+}
+
+// AuthorBook is one of the user's books and the author's role on it
+type AuthorBook struct {
+	Role string      `json:"role"`
+	Book models.Book `json:"book"`
+}
+
+// AuthorDetail is an author and the user's books they are credited on
+type AuthorDetail struct {
+	models.Author
+	Books []AuthorBook `json:"books"`
+}
+
+type AuthorService interface {
+	ListAuthors(ctx context.Context, userID string) ([]AuthorSummary, error)
+	GetAuthor(ctx context.Context, userID string, authorID string) (*AuthorDetail, error)
+}
+
+type authorService struct {
+	DB *gorm.DB
+}
+
+func NewAuthorService(db *gorm.DB) AuthorService {
+	return &authorService{
+		DB: db,
+	}
+}
+
+// ListAuthors returns the authors credited on the user's books, by name
+func (s *authorService) ListAuthors(ctx context.Context, userID string) ([]AuthorSummary, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	authors := []AuthorSummary{}
+	if err := s.DB.Model(&models.Author{}).
+		Select("authors.*, COUNT(DISTINCT book_authors.book_id) AS book_count").
+		Joins("JOIN book_authors ON book_authors.author_id = authors.id").
+		Joins("JOIN books ON books.id = book_authors.book_id AND books.deleted_at IS NULL").
+		Where("books.user_id = ?", user.ID).
+		Group("authors.id").
+		Order("authors.normalized_name asc").
+		Scan(&authors).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch authors: %v", err)
+	}
+	return authors, nil
+}
+
+// GetAuthor returns an author with the user's books they are credited on. An
+// author the user has no books by is reported as not found.
+func (s *authorService) GetAuthor(ctx context.Context, userID string, authorID string) (*AuthorDetail, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var author models.Author
+	if err := s.DB.Where("id = ?", authorID).First(&author).Error; err != nil {
+		return nil, err
+	}
+
+	var credits []models.BookAuthor
+	if err := s.DB.Joins("JOIN books ON books.id = book_authors.book_id AND books.deleted_at IS NULL").
+		Where("book_authors.author_id = ? AND books.user_id = ?", author.ID, user.ID).
+		Find(&credits).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch author credits: %v", err)
+	}
+	if len(credits) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	bookIDs := make([]uint, len(credits))
+	for i, credit := range credits {
+		bookIDs[i] = credit.BookID
+	}
+	var books []models.Book
+	if err := s.DB.Preload("Authors", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position asc")
+	}).Preload("Authors.Author").
+		Where("id IN ?", bookIDs).
+		Order("title asc, id asc").
+		Find(&books).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch author books: %v", err)
+	}
+
+	// A person can have more than one role on the same book
+	roles := map[uint][]string{}
+	for _, credit := range credits {
+		roles[credit.BookID] = append(roles[credit.BookID], credit.Role)
+	}
+	detail := &AuthorDetail{Author: author, Books: []AuthorBook{}}
+	for _, book := range books {
+		for _, role := range roles[book.ID] {
+			detail.Books = append(detail.Books, AuthorBook{Role: role, Book: book})
+		}
+	}
+	return detail, nil
+}
+
+// authorNameSuffixes are name parts that follow a comma without the name being in "Last, First" form
+var authorNameSuffixes = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true, "phd": true, "md": true,
+}
+
+// authorSeparators split a display string into the names of several authors
+var authorSeparators = regexp.MustCompile(`\s*;\s*|\s+&\s+|\s+and\s+`)
+
+// authorInitials matches first names given only as initials, such as "J. R. R."
+var authorInitials = regexp.MustCompile(`^(\p{Lu}\.\s*)+$`)
+
+// isSingleName reports whether the two sides of a comma belong to one name, as
+// in "Tolkien, J.R.R." or "King, Jr.", rather than being a list of names
+func isSingleName(before string, after string) bool {
+	after = strings.TrimSpace(after)
+	return strings.TrimSpace(before) != "" && after != "" &&
+		(!strings.Contains(after, " ") || authorInitials.MatchString(after))
+}
+
+// isNameSuffix reports whether part of a name is a suffix such as "Jr."
+func isNameSuffix(part string) bool {
+	return authorNameSuffixes[strings.ToLower(strings.Trim(strings.TrimSpace(part), "."))]
+}
+
+// CleanAuthorName tidies the whitespace in a name and turns "Tolkien, J.R.R." into "J.R.R. Tolkien"
+func CleanAuthorName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+
+	parts := strings.Split(name, ",")
+	if len(parts) == 2 && isSingleName(parts[0], parts[1]) {
+		last := strings.TrimSpace(parts[0])
+		first := strings.TrimSpace(parts[1])
+		if !isNameSuffix(first) {
+			name = first + " " + last
+		}
+	}
+	return name
+}
+
+// NormalizeAuthorName returns the key authors are matched on. It ignores case,
+// punctuation and spacing, so "J.R.R. Tolkien", "J. R. R. Tolkien" and
+// "Tolkien, J.R.R." all normalize to "j r r tolkien".
+func NormalizeAuthorName(name string) string {
+	name = CleanAuthorName(name)
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '\'' || r == '’':
+			// O'Brien and OBrien are the same name
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// ParseAuthorNames splits a display string such as "Neil Gaiman & Terry Pratchett"
+// or "Neil Gaiman, Terry Pratchett" into names
+func ParseAuthorNames(author string) []string {
+	names := []string{}
+	for _, group := range authorSeparators.Split(author, -1) {
+		// Commas list several names unless they are part of a single one
+		parts := strings.Split(group, ",")
+		if len(parts) == 2 && isSingleName(parts[0], parts[1]) {
+			parts = []string{group}
+		}
+		listed := []string{}
+		for _, part := range parts {
+			if len(listed) > 0 && isNameSuffix(part) {
+				listed[len(listed)-1] += "," + part
+				continue
+			}
+			listed = append(listed, part)
+		}
+		for _, name := range listed {
+			if name = CleanAuthorName(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// authorCredits builds the credits of a book in one role from its display string
+func authorCredits(names string, role string) []models.BookAuthor {
+	parsed := ParseAuthorNames(names)
+	credits := make([]models.BookAuthor, len(parsed))
+	for i, name := range parsed {
+		credits[i] = models.BookAuthor{Role: role, Author: models.Author{Name: name}}
+	}
+	return credits
+}
+
+// FormatAuthorNames builds the display string of a book from the names credited as authors
+func FormatAuthorNames(credits []models.BookAuthor) string {
+	return formatCreditNames(credits, models.RoleAuthor)
+}
+
+// FormatNarratorNames builds the narrator display string of a book from the names credited as narrators
+func FormatNarratorNames(credits []models.BookAuthor) string {
+	return formatCreditNames(credits, models.RoleNarrator)
+}
+
+func formatCreditNames(credits []models.BookAuthor, role string) string {
+	names := []string{}
+	for _, credit := range credits {
+		if credit.Role == role {
+			names = append(names, CleanAuthorName(credit.Author.Name))
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// validateAuthorCredits checks the credits given for a book, defaulting the role to author
+func validateAuthorCredits(credits []models.BookAuthor) ([]models.BookAuthor, error) {
+	valid := make([]models.BookAuthor, 0, len(credits))
+	for _, credit := range credits {
+		if credit.Role == "" {
+			credit.Role = models.RoleAuthor
+		}
+		if !models.IsValidAuthorRole(credit.Role) {
+			return nil, newValidationError("invalid author role: %s", credit.Role)
+		}
+		if NormalizeAuthorName(credit.Author.Name) == "" {
+			return nil, newValidationError("author name is required")
+		}
+		valid = append(valid, credit)
+	}
+	return valid, nil
+}
+
+// findOrCreateAuthor returns the author with the same normalized name, creating it if needed
+func findOrCreateAuthor(tx *gorm.DB, name string) (*models.Author, error) {
+	author := models.Author{Name: CleanAuthorName(name), NormalizedName: NormalizeAuthorName(name)}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&author).Error; err != nil {
+		return nil, fmt.Errorf("failed to create author: %v", err)
+	}
+	if author.ID == 0 {
+		if err := tx.Where("normalized_name = ?", author.NormalizedName).First(&author).Error; err != nil {
+			return nil, fmt.Errorf("failed to find author: %v", err)
+		}
+	}
+	return &author, nil
+}
+
+// setBookAuthors replaces the book's credits in the given roles, keeping credits in
+// other roles after the new ones. Credits are de-duplicated on the normalized name
+// and role.
+func setBookAuthors(tx *gorm.DB, bookID uint, credits []models.BookAuthor, roles []string) error {
+	if err := tx.Where("book_id = ? AND role IN ?", bookID, roles).Delete(&models.BookAuthor{}).Error; err != nil {
+		return fmt.Errorf("failed to clear book authors: %v", err)
+	}
+	var kept []models.BookAuthor
+	if err := tx.Where("book_id = ?", bookID).Order("position asc").Find(&kept).Error; err != nil {
+		return fmt.Errorf("failed to fetch book authors: %v", err)
+	}
+
+	position := 0
+	seen := map[string]bool{}
+	for _, credit := range credits {
+		author, err := findOrCreateAuthor(tx, credit.Author.Name)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("%d:%s", author.ID, credit.Role)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		row := models.BookAuthor{BookID: bookID, AuthorID: author.ID, Role: credit.Role, Position: position}
+		if err := tx.Omit("Author").Create(&row).Error; err != nil {
+			return fmt.Errorf("failed to add book author: %v", err)
+		}
+		position++
+	}
+
+	for _, credit := range kept {
+		if err := tx.Model(&credit).Update("position", position).Error; err != nil {
+			return fmt.Errorf("failed to reorder book authors: %v", err)
+		}
+		position++
+	}
+	return nil
+}
+
+// saveBookAuthors stores the credits of a newly saved or updated book. Explicit
+// credits replace all of the book's credits, otherwise the authors and
+// narrators are taken from the display strings and other roles, like
+// translators, are kept.
+func saveBookAuthors(tx *gorm.DB, book models.Book) error {
+	if len(book.Authors) > 0 {
+		return setBookAuthors(tx, book.ID, book.Authors, []string{
+			models.RoleAuthor, models.RoleTranslator, models.RoleNarrator, models.RoleEditor,
+		})
+	}
+	if err := setBookAuthors(tx, book.ID, authorCredits(book.Author, models.RoleAuthor), []string{models.RoleAuthor}); err != nil {
+		return err
+	}
+	return setBookAuthors(tx, book.ID, authorCredits(book.Narrator, models.RoleNarrator), []string{models.RoleNarrator})
+}
+
+// BackfillBookAuthors creates author credits for books saved before authors
+// were tracked, from their author display string
+func BackfillBookAuthors(db *gorm.DB) error {
+	var books []models.Book
+	result := db.Unscoped().
+		Where("NOT EXISTS (SELECT 1 FROM book_authors WHERE book_authors.book_id = books.id)").
+		FindInBatches(&books, 200, func(tx *gorm.DB, _ int) error {
+			for _, book := range books {
+				if err := saveBookAuthors(db, models.Book{Model: book.Model, Author: book.Author, Narrator: book.Narrator}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return result.Error
+	}
+	return nil
+}
+
+// BackfillBookNarrators fills in the narrator display string of books whose
+// narrators were only credited before the string was stored
+func BackfillBookNarrators(db *gorm.DB) error {
+	var credits []models.BookAuthor
+	if err := db.Preload("Author").
+		Joins("JOIN books ON books.id = book_authors.book_id AND (books.narrator IS NULL OR books.narrator = '')").
+		Where("book_authors.role = ?", models.RoleNarrator).
+		Order("book_authors.book_id asc, book_authors.position asc").
+		Find(&credits).Error; err != nil {
+		return err
+	}
+
+	byBook := map[uint][]models.BookAuthor{}
+	for _, credit := range credits {
+		byBook[credit.BookID] = append(byBook[credit.BookID], credit)
+	}
+	for bookID, bookCredits := range byBook {
+		if err := db.Unscoped().Model(&models.Book{}).Where("id = ?", bookID).
+			UpdateColumn("narrator", FormatNarratorNames(bookCredits)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAuthorName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"J.R.R. Tolkien", "j r r tolkien"},
+		{"J. R. R. Tolkien", "j r r tolkien"},
+		{"Tolkien, J.R.R.", "j r r tolkien"},
+		{"  Ursula K.  Le Guin ", "ursula k le guin"},
+		{"Martin Luther King, Jr.", "martin luther king jr"},
+		{"Flann O'Brien", "flann obrien"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, NormalizeAuthorName(tt.name), tt.name)
+	}
+
+	assert.Equal(t, "J.R.R. Tolkien", CleanAuthorName("Tolkien, J.R.R."))
+	assert.Equal(t, "J. R. R. Tolkien", CleanAuthorName("Tolkien, J. R. R."))
+	assert.Equal(t, []string{"Neil Gaiman", "Terry Pratchett"}, ParseAuthorNames("Neil Gaiman & Terry Pratchett"))
+	assert.Equal(t, []string{"Neil Gaiman", "Terry Pratchett"}, ParseAuthorNames("Neil Gaiman, Terry Pratchett"))
+	assert.Equal(t, []string{"Martin Luther King, Jr."}, ParseAuthorNames("Martin Luther King, Jr."))
+	assert.Equal(t, []string{"Neil Gaiman"}, ParseAuthorNames("Gaiman, Neil"))
+
+	// The display string of a book parses back into its authors
+	credits := []models.BookAuthor{
+		{Role: models.RoleAuthor, Author: models.Author{Name: "J.R.R. Tolkien"}},
+		{Role: models.RoleAuthor, Author: models.Author{Name: "Christopher Tolkien"}},
+		{Role: models.RoleAuthor, Author: models.Author{Name: "Martin Luther King, Jr."}},
+	}
+	assert.Equal(t, []string{"J.R.R. Tolkien", "Christopher Tolkien", "Martin Luther King, Jr."}, ParseAuthorNames(FormatAuthorNames(credits)))
+}
+
+func TestBookAuthors(t *testing.T) {
+	db := setupTestDB(t)
+	bookService := NewBookService(db)
+	authorService := NewAuthorService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	assert.NoError(t, bookService.AddBook(ctx, user.Auth0ID, models.Book{Title: "The Hobbit", Author: "J.R.R. Tolkien"}))
+	assert.NoError(t, bookService.AddBook(ctx, user.Auth0ID, models.Book{Title: "The Silmarillion", Author: "J. R. R. Tolkien"}))
+	assert.NoError(t, bookService.AddBook(ctx, user.Auth0ID, models.Book{
+		Title: "Norwegian Wood",
+		Authors: []models.BookAuthor{
+			{Author: models.Author{Name: "Haruki Murakami"}},
+			{Role: models.RoleTranslator, Author: models.Author{Name: "Jay Rubin"}},
+		},
+	}))
+
+	err := bookService.AddBook(ctx, user.Auth0ID, models.Book{
+		Title:   "Bad Role",
+		Authors: []models.BookAuthor{{Role: "illustrator", Author: models.Author{Name: "Someone"}}},
+	})
+	assert.True(t, IsValidationError(err))
+
+	var authors int64
+	db.Model(&models.Author{}).Count(&authors)
+	assert.Equal(t, int64(3), authors)
+
+	summaries, err := authorService.ListAuthors(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Len(t, summaries, 3)
+	assert.Equal(t, "J.R.R. Tolkien", summaries[1].Name)
+	assert.Equal(t, int64(2), summaries[1].BookCount)
+
+	detail, err := authorService.GetAuthor(ctx, user.Auth0ID, fmt.Sprintf("%d", summaries[1].ID))
+	assert.NoError(t, err)
+	assert.Len(t, detail.Books, 2)
+	assert.Equal(t, models.RoleAuthor, detail.Books[0].Role)
+
+	// The display string comes from the credited authors when none is given
+	book, err := bookService.FindBookByTitleAndUser(ctx, "Norwegian Wood", user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Haruki Murakami", book.Author)
+
+	// Updating the display string keeps the translator
+	author := "Haruki Murakami"
+	assert.NoError(t, bookService.UpdateBook(ctx, user.Auth0ID, fmt.Sprintf("%d", book.ID), BookPatch{Author: &author}))
+	var credits []models.BookAuthor
+	assert.NoError(t, db.Preload("Author").Where("book_id = ?", book.ID).Order("position asc").Find(&credits).Error)
+	assert.Len(t, credits, 2)
+	assert.Equal(t, "Haruki Murakami", credits[0].Author.Name)
+	assert.Equal(t, models.RoleTranslator, credits[1].Role)
+	assert.Equal(t, "Jay Rubin", credits[1].Author.Name)
+
+	// Authors without books in the collection aren't shown
+	otherUser := &models.User{Auth0ID: "other-auth0-id", Email: "other@example.com"}
+	assert.NoError(t, db.Create(otherUser).Error)
+	_, err = authorService.GetAuthor(ctx, otherUser.Auth0ID, fmt.Sprintf("%d", summaries[1].ID))
+	assert.Error(t, err)
+}
+
+func TestBackfillBookAuthors(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+
+	books := []models.Book{
+		{Title: "Good Omens", Author: "Neil Gaiman & Terry Pratchett", UserID: user.ID},
+		{Title: "Stardust", Author: "Gaiman, Neil", UserID: user.ID},
+	}
+	assert.NoError(t, db.Create(&books).Error)
+
+	assert.NoError(t, BackfillBookAuthors(db))
+	assert.NoError(t, BackfillBookAuthors(db))
+
+	var credits int64
+	db.Model(&models.BookAuthor{}).Count(&credits)
+	assert.Equal(t, int64(3), credits)
+
+	var authors []models.Author
+	assert.NoError(t, db.Order("normalized_name asc").Find(&authors).Error)
+	assert.Len(t, authors, 2)
+	assert.Equal(t, "Neil Gaiman", authors[0].Name)
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Operations that can be applied to books in a batch
+const (
+	BatchDelete      = "delete"
+	BatchRestore     = "restore"
+	BatchPatch       = "patch"
+	BatchMoveToShelf = "move_to_shelf"
+)
+
+// Batch modes. All-or-nothing rolls back every operation when one fails, while
+// best-effort keeps the operations that succeeded.
+const (
+	BatchAllOrNothing = "all_or_nothing"
+	BatchBestEffort   = "best_effort"
+)
+
+// Statuses of the individual operations of a batch
+const (
+	BatchItemOK         = "ok"
+	BatchItemFailed     = "failed"
+	BatchItemRolledBack = "rolled_back"
+	BatchItemSkipped    = "skipped"
+)
+
+// MaxBatchOperations is the most operations a single batch may contain
+const MaxBatchOperations = 500
+
+// BatchBookPatch holds the book fields a patch operation changes. Fields left
+// out of the request are not touched.
+type BatchBookPatch struct {
+	Title       *string  `json:"title"`
+	Author      *string  `json:"author"`
+	ISBN        *string  `json:"isbn"`
+	CoverImage  *string  `json:"coverImage"`
+	Rating      *float64 `json:"rating"`
+	PageCount   *uint    `json:"page_count"`
+	Genre       *string  `json:"genre"`
+	Format      *string  `json:"format"`
+	Status      *string  `json:"status"`
+	StoppedPage *uint    `json:"stopped_page"`
+
+	// CustomFields sets the given custom field values, removing those set to null
+	CustomFields models.CustomFieldValues `json:"custom_fields"`
+}
+
+// BatchOperation is one change in a batch. Patch is used by patch operations,
+// ShelfID by move_to_shelf, which also takes the book off FromShelfID when given.
+type BatchOperation struct {
+	Op          string          `json:"op"`
+	BookID      uint            `json:"book_id"`
+	Patch       *BatchBookPatch `json:"patch,omitempty"`
+	ShelfID     uint            `json:"shelf_id,omitempty"`
+	FromShelfID uint            `json:"from_shelf_id,omitempty"`
+}
+
+// BatchItemResult is the outcome of one operation of a batch
+type BatchItemResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	BookID uint   `json:"book_id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResult is the outcome of a batch. Applied is false when an
+// all-or-nothing batch was rolled back.
+type BatchResult struct {
+	Mode      string            `json:"mode"`
+	Applied   bool              `json:"applied"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+type BatchService interface {
+	ApplyBatch(ctx context.Context, userID string, mode string, operations []BatchOperation) (*BatchResult, error)
+}
+
+type batchService struct {
+	DB *gorm.DB
+}
+
+func NewBatchService(db *gorm.DB) BatchService {
+	return &batchService{
+		DB: db,
+	}
+}
+
+// errBatchAborted rolls back an all-or-nothing batch after one of its operations failed
+var errBatchAborted = errors.New("batch aborted")
+
+// ApplyBatch applies the operations in order in a single transaction. Each
+// operation runs in its own savepoint, so in best-effort mode a failed
+// operation leaves no partial changes behind.
+func (s *batchService) ApplyBatch(ctx context.Context, userID string, mode string, operations []BatchOperation) (*BatchResult, error) {
+	if mode == "" {
+		mode = BatchAllOrNothing
+	}
+	if mode != BatchAllOrNothing && mode != BatchBestEffort {
+		return nil, newValidationError("invalid batch mode: %s", mode)
+	}
+	if len(operations) == 0 {
+		return nil, newValidationError("at least one operation is required")
+	}
+	if len(operations) > MaxBatchOperations {
+		return nil, newValidationError("a batch cannot contain more than %d operations", MaxBatchOperations)
+	}
+
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{Mode: mode, Results: make([]BatchItemResult, 0, len(operations))}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		for i, op := range operations {
+			item := BatchItemResult{Index: i, Op: op.Op, BookID: op.BookID, Status: BatchItemOK}
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				return applyBatchOperation(ctx, tx, user, op)
+			})
+			if err != nil {
+				item.Status = BatchItemFailed
+				item.Error = err.Error()
+			}
+			result.Results = append(result.Results, item)
+
+			if err != nil && mode == BatchAllOrNothing {
+				return errBatchAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchAborted) {
+		return nil, fmt.Errorf("failed to apply batch: %v", err)
+	}
+
+	if errors.Is(err, errBatchAborted) {
+		for i := range result.Results {
+			if result.Results[i].Status == BatchItemOK {
+				result.Results[i].Status = BatchItemRolledBack
+			}
+		}
+		for i := len(result.Results); i < len(operations); i++ {
+			op := operations[i]
+			result.Results = append(result.Results, BatchItemResult{Index: i, Op: op.Op, BookID: op.BookID, Status: BatchItemSkipped})
+		}
+	} else {
+		result.Applied = true
+	}
+
+	for _, item := range result.Results {
+		switch item.Status {
+		case BatchItemOK:
+			result.Succeeded++
+		case BatchItemFailed:
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+func applyBatchOperation(ctx context.Context, tx *gorm.DB, user *models.User, op BatchOperation) error {
+	if op.BookID == 0 {
+		return newValidationError("book_id is required")
+	}
+
+	switch op.Op {
+	case BatchDelete:
+		book, err := findBatchBook(tx, user.ID, op.BookID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(book).Error; err != nil {
+			return fmt.Errorf("failed to delete book: %v", err)
+		}
+		return recomputeBatchBookGoals(ctx, tx, user, book)
+
+	case BatchRestore:
+		book, err := findBatchBook(tx.Unscoped(), user.ID, op.BookID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(book).Unscoped().Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore book: %v", err)
+		}
+		return recomputeBatchBookGoals(ctx, tx, user, book)
+
+	case BatchPatch:
+		if op.Patch == nil {
+			return newValidationError("patch operations need a patch")
+		}
+		book, err := findBatchBook(tx, user.ID, op.BookID)
+		if err != nil {
+			return err
+		}
+		return patchBook(ctx, tx, user, book, *op.Patch)
+
+	case BatchMoveToShelf:
+		if op.ShelfID == 0 {
+			return newValidationError("shelf_id is required")
+		}
+		if _, err := findBatchBook(tx, user.ID, op.BookID); err != nil {
+			return err
+		}
+		var shelf models.Shelf
+		if err := tx.Where("id = ? AND user_id = ?", op.ShelfID, user.ID).First(&shelf).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return newValidationError("shelf %d not found", op.ShelfID)
+			}
+			return err
+		}
+		if op.FromShelfID != 0 && op.FromShelfID != op.ShelfID {
+			if err := tx.Where("shelf_id = ? AND book_id = ? AND shelf_id IN (?)", op.FromShelfID, op.BookID,
+				tx.Model(&models.Shelf{}).Select("id").Where("user_id = ?", user.ID)).
+				Delete(&models.BookShelf{}).Error; err != nil {
+				return fmt.Errorf("failed to remove book from shelf: %v", err)
+			}
+		}
+		return addBooksToShelf(tx, shelf.ID, []uint{op.BookID})
+
+	default:
+		return newValidationError("invalid batch operation: %s", op.Op)
+	}
+}
+
+// findBatchBook returns one of the user's books, reporting a missing book as a
+// validation error so it only fails its own operation
+func findBatchBook(tx *gorm.DB, userID uint, bookID uint) (*models.Book, error) {
+	var book models.Book
+	if err := tx.Where("id = ? AND user_id = ?", bookID, userID).First(&book).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newValidationError("book %d not found", bookID)
+		}
+		return nil, err
+	}
+	return &book, nil
+}
+
+// patchBook applies the fields of a patch to the book and saves it
+func patchBook(ctx context.Context, tx *gorm.DB, user *models.User, book *models.Book, patch BatchBookPatch) error {
+	if patch.Title != nil {
+		title := strings.TrimSpace(*patch.Title)
+		if title == "" {
+			return newValidationError("title cannot be empty")
+		}
+		book.Title = title
+	}
+	if patch.Author != nil {
+		author := strings.TrimSpace(*patch.Author)
+		if author == "" {
+			return newValidationError("author cannot be empty")
+		}
+		book.Author = author
+	}
+	if patch.ISBN != nil {
+		book.ISBN, book.ISBN10, book.ISBN13 = *patch.ISBN, "", ""
+		if err := fillISBNs(book); err != nil {
+			return err
+		}
+	}
+	if patch.CoverImage != nil {
+		book.CoverImage = *patch.CoverImage
+	}
+	if patch.Rating != nil {
+		if err := checkRating(user, *patch.Rating); err != nil {
+			return err
+		}
+		book.Rating = *patch.Rating
+	}
+	if patch.PageCount != nil {
+		book.PageCount = *patch.PageCount
+	}
+	if patch.Genre != nil {
+		book.Genre = *patch.Genre
+	}
+	if patch.Format != nil {
+		format := strings.ToLower(strings.TrimSpace(*patch.Format))
+		if !models.IsValidFormat(format) {
+			return newValidationError("invalid format: %s", format)
+		}
+		book.Format = format
+	}
+	if patch.CustomFields != nil {
+		values := models.CustomFieldValues{}
+		for key, value := range book.CustomFields {
+			values[key] = value
+		}
+		for key, value := range patch.CustomFields {
+			values[key] = value
+		}
+		var err error
+		if book.CustomFields, err = validateCustomFieldValues(tx, user.ID, values); err != nil {
+			return err
+		}
+	}
+	previouslyFinished := book.FinishedAt
+	if patch.Status != nil && *patch.Status != book.Status {
+		if err := book.TransitionTo(*patch.Status, time.Now(), patch.StoppedPage); err != nil {
+			return newValidationError("%v", err)
+		}
+	}
+
+	if err := tx.Model(book).Updates(map[string]interface{}{
+		"title":        book.Title,
+		"author":       book.Author,
+		"isbn":         book.ISBN,
+		"isbn10":       book.ISBN10,
+		"isbn13":       book.ISBN13,
+		"cover_image":  book.CoverImage,
+		"rating":       book.Rating,
+		"page_count":   book.PageCount,
+		"genre":        book.Genre,
+		"format":       book.Format,
+		"status":       book.Status,
+		"started_at":   book.StartedAt,
+		"finished_at":  book.FinishedAt,
+		"stopped_at":   book.StoppedAt,
+		"stopped_page": book.StoppedPage,
+
+		"custom_fields": book.CustomFields,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update book: %v", err)
+	}
+
+	if patch.Author != nil {
+		if err := saveBookAuthors(tx, models.Book{Model: book.Model, Author: book.Author, Narrator: book.Narrator}); err != nil {
+			return err
+		}
+	}
+	if err := indexBook(tx, book.ID); err != nil {
+		return err
+	}
+	return NewGoalService(tx).RecomputeGoalHistory(ctx, user.Auth0ID, finishedOn(previouslyFinished, book.FinishedAt))
+}
+
+// recomputeBatchBookGoals recomputes the goal history of every interval the
+// book was finished in, for when it is deleted or restored
+func recomputeBatchBookGoals(ctx context.Context, tx *gorm.DB, user *models.User, book *models.Book) error {
+	finished, err := bookFinishDates(tx, book)
+	if err != nil {
+		return err
+	}
+	return NewGoalService(tx).RecomputeGoalHistory(ctx, user.Auth0ID, finished)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyBatchAllOrNothing(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBatchService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	books := []models.Book{
+		{Title: "Dune", Author: "Frank Herbert", UserID: user.ID},
+		{Title: "Emma", Author: "Jane Austen", UserID: user.ID},
+	}
+	assert.NoError(t, db.Create(&books).Error)
+
+	finished := models.StatusFinished
+	result, err := service.ApplyBatch(ctx, user.Auth0ID, "", []BatchOperation{
+		{Op: BatchDelete, BookID: books[0].ID},
+		{Op: BatchPatch, BookID: books[1].ID, Patch: &BatchBookPatch{Status: &finished}},
+		{Op: BatchDelete, BookID: 9999},
+		{Op: BatchDelete, BookID: books[1].ID},
+	})
+	assert.NoError(t, err)
+	assert.False(t, result.Applied)
+	assert.Equal(t, BatchAllOrNothing, result.Mode)
+	assert.Equal(t, []string{BatchItemRolledBack, BatchItemRolledBack, BatchItemFailed, BatchItemSkipped}, batchStatuses(result))
+	assert.Equal(t, "book 9999 not found", result.Results[2].Error)
+
+	// Nothing was changed
+	var remaining []models.Book
+	assert.NoError(t, db.Order("id").Find(&remaining).Error)
+	assert.Len(t, remaining, 2)
+	assert.Equal(t, models.StatusWantToRead, remaining[1].Status)
+
+	result, err = service.ApplyBatch(ctx, user.Auth0ID, BatchAllOrNothing, []BatchOperation{
+		{Op: BatchDelete, BookID: books[0].ID},
+		{Op: BatchPatch, BookID: books[1].ID, Patch: &BatchBookPatch{Status: &finished}},
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.Applied)
+	assert.Equal(t, 2, result.Succeeded)
+
+	var emma models.Book
+	assert.NoError(t, db.First(&emma, books[1].ID).Error)
+	assert.Equal(t, models.StatusFinished, emma.Status)
+	assert.NotNil(t, emma.FinishedAt)
+}
+
+func TestApplyBatchBestEffort(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBatchService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+	other := &models.User{Auth0ID: "other-auth0-id", Email: "other@example.com"}
+	assert.NoError(t, db.Create(other).Error)
+
+	books := []models.Book{
+		{Title: "Dune", Author: "Frank Herbert", UserID: user.ID},
+		{Title: "Emma", Author: "Jane Austen", UserID: user.ID},
+		{Title: "Not Mine", Author: "Someone Else", UserID: other.ID},
+	}
+	assert.NoError(t, db.Create(&books).Error)
+	assert.NoError(t, db.Delete(&books[1]).Error)
+
+	shelves := NewShelfService(db)
+	toRead, err := shelves.CreateShelf(ctx, user.Auth0ID, "To Read")
+	assert.NoError(t, err)
+	favourites, err := shelves.CreateShelf(ctx, user.Auth0ID, "Favourites")
+	assert.NoError(t, err)
+	assert.NoError(t, addBooksToShelf(db, toRead.ID, []uint{books[0].ID}))
+
+	title := "Dune Messiah"
+	author := "Herbert, Frank"
+	empty := " "
+	result, err := service.ApplyBatch(ctx, user.Auth0ID, BatchBestEffort, []BatchOperation{
+		{Op: BatchPatch, BookID: books[0].ID, Patch: &BatchBookPatch{Title: &title, Author: &author}},
+		{Op: BatchPatch, BookID: books[0].ID, Patch: &BatchBookPatch{Title: &empty}},
+		{Op: BatchMoveToShelf, BookID: books[0].ID, ShelfID: favourites.ID, FromShelfID: toRead.ID},
+		{Op: BatchRestore, BookID: books[1].ID},
+		{Op: BatchDelete, BookID: books[2].ID},
+		{Op: "archive", BookID: books[0].ID},
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.Applied)
+	assert.Equal(t, []string{BatchItemOK, BatchItemFailed, BatchItemOK, BatchItemOK, BatchItemFailed, BatchItemFailed}, batchStatuses(result))
+	assert.Equal(t, 3, result.Succeeded)
+	assert.Equal(t, 3, result.Failed)
+
+	var dune models.Book
+	assert.NoError(t, db.Preload("Shelves").Preload("Authors.Author").First(&dune, books[0].ID).Error)
+	assert.Equal(t, "Dune Messiah", dune.Title)
+	assert.Equal(t, "Herbert, Frank", dune.Author)
+	assert.Len(t, dune.Authors, 1)
+	assert.Equal(t, "Frank Herbert", dune.Authors[0].Author.Name)
+	assert.Len(t, dune.Shelves, 1)
+	assert.Equal(t, "Favourites", dune.Shelves[0].Name)
+
+	var restored models.Book
+	assert.NoError(t, db.First(&restored, books[1].ID).Error)
+
+	// Other users' books are left alone
+	var notMine models.Book
+	assert.NoError(t, db.First(&notMine, books[2].ID).Error)
+}
+
+func TestApplyBatchValidation(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBatchService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	_, err := service.ApplyBatch(ctx, user.Auth0ID, BatchAllOrNothing, nil)
+	assert.True(t, IsValidationError(err))
+
+	_, err = service.ApplyBatch(ctx, user.Auth0ID, "sometimes", []BatchOperation{{Op: BatchDelete, BookID: 1}})
+	assert.True(t, IsValidationError(err))
+
+	_, err = service.ApplyBatch(ctx, user.Auth0ID, BatchBestEffort, make([]BatchOperation, MaxBatchOperations+1))
+	assert.True(t, IsValidationError(err))
+}
+
+func batchStatuses(result *BatchResult) []string {
+	statuses := make([]string, len(result.Results))
+	for i, item := range result.Results {
+		statuses[i] = item.Status
+	}
+	return statuses
+}
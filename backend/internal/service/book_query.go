@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageSize = 25
+	MaxPageSize     = 100
+)
+
+// likeEscaper escapes the LIKE wildcards so search terms match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// bookSortColumns maps the sort keys accepted by the API to book columns
+var bookSortColumns = map[string]string{
+	"title":       "title",
+	"author":      "author",
+	"rating":      "rating",
+	"page_count":  "page_count",
+	"genre":       "genre",
+	"status":      "status",
+	"started_at":  "started_at",
+	"finished_at": "finished_at",
+	"created_at":  "created_at",
+}
+
+// BookQuery describes the filters, ordering and paging applied to a user's collection
+type BookQuery struct {
+	Search         string
+	Genres         []string
+	MinRating      *float64
+	MaxRating      *float64
+	StartedAfter   *time.Time
+	StartedBefore  *time.Time
+	FinishedAfter  *time.Time
+	FinishedBefore *time.Time
+	Statuses       []string
+	Shelves        []string
+	ShelfMatch     string
+	CustomFields   []CustomFieldFilter
+	Conditions     []models.FilterCondition
+	Sort           string
+	Order          string
+	Page           int
+	PageSize       int
+	Cursor         string
+}
+
+// The operators of custom field filters. Min and max only apply to number and date fields.
+const (
+	CustomFieldEquals = "eq"
+	CustomFieldMin    = "min"
+	CustomFieldMax    = "max"
+)
+
+// CustomFieldFilter matches books by the value of one of the user's custom fields
+type CustomFieldFilter struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+func (f CustomFieldFilter) comparison() string {
+	switch f.Op {
+	case CustomFieldMin:
+		return ">="
+	case CustomFieldMax:
+		return "<="
+	default:
+		return "="
+	}
+}
+
+// BookPage is a single page of a user's collection along with paging metadata
+type BookPage struct {
+	Books      []models.Book `json:"books"`
+	Total      int64         `json:"total"`
+	Page       int           `json:"page,omitempty"`
+	PageSize   int           `json:"page_size,omitempty"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// IsPaginated reports whether the caller asked for a single page rather than the whole collection
+func (q BookQuery) IsPaginated() bool {
+	return q.Page > 0 || q.PageSize > 0 || q.Cursor != ""
+}
+
+// Validate checks the sort key, order and status values
+func (q BookQuery) Validate() error {
+	if q.Sort != "" {
+		if _, ok := bookSortColumns[q.Sort]; !ok {
+			return fmt.Errorf("invalid sort key: %s", q.Sort)
+		}
+	}
+	if q.Order != "" && q.Order != "asc" && q.Order != "desc" {
+		return fmt.Errorf("invalid sort order: %s", q.Order)
+	}
+	for _, status := range q.Statuses {
+		if status != "read" && status != "unread" && !models.IsValidStatus(status) {
+			return fmt.Errorf("invalid status: %s", status)
+		}
+	}
+	if q.ShelfMatch != "" && q.ShelfMatch != "and" && q.ShelfMatch != "or" {
+		return fmt.Errorf("invalid shelf_match: %s, expected and or or", q.ShelfMatch)
+	}
+	for _, filter := range q.CustomFields {
+		if !customFieldKey.MatchString(filter.Key) {
+			return fmt.Errorf("invalid custom field: %s", filter.Key)
+		}
+		if filter.Op != CustomFieldEquals && filter.Op != CustomFieldMin && filter.Op != CustomFieldMax {
+			return fmt.Errorf("invalid custom field filter: %s", filter.Op)
+		}
+	}
+	for _, condition := range q.Conditions {
+		if _, _, err := filterClause(condition); err != nil {
+			return err
+		}
+	}
+	if q.MinRating != nil && q.MaxRating != nil && *q.MinRating > *q.MaxRating {
+		return fmt.Errorf("min_rating cannot be greater than max_rating")
+	}
+	if q.Page < 0 || q.PageSize < 0 {
+		return fmt.Errorf("page and page_size must be positive")
+	}
+	if q.Cursor != "" {
+		if _, err := decodeCursor(q.Cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply adds the filter conditions of the query to tx
+func (q BookQuery) apply(tx *gorm.DB) *gorm.DB {
+	if search := strings.TrimSpace(q.Search); search != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(search)) + "%"
+		tx = tx.Where("LOWER(title) LIKE ? ESCAPE '\\' OR LOWER(author) LIKE ? ESCAPE '\\'", pattern, pattern)
+	}
+	if len(q.Genres) > 0 {
+		genres := make([]string, 0, len(q.Genres))
+		for _, genre := range q.Genres {
+			genres = append(genres, strings.ToLower(genre))
+		}
+		tx = tx.Where("LOWER(genre) IN ?", genres)
+	}
+	if q.MinRating != nil {
+		tx = tx.Where("rating >= ?", *q.MinRating)
+	}
+	if q.MaxRating != nil {
+		tx = tx.Where("rating <= ?", *q.MaxRating)
+	}
+	if q.StartedAfter != nil {
+		tx = tx.Where("started_at >= ?", *q.StartedAfter)
+	}
+	if q.StartedBefore != nil {
+		tx = tx.Where("started_at <= ?", *q.StartedBefore)
+	}
+	if q.FinishedAfter != nil {
+		tx = tx.Where("finished_at >= ?", *q.FinishedAfter)
+	}
+	if q.FinishedBefore != nil {
+		tx = tx.Where("finished_at <= ?", *q.FinishedBefore)
+	}
+	if len(q.Statuses) > 0 {
+		// "read" and "unread" are kept as aliases from before books had an explicit status
+		statuses := []string{}
+		includeUnread := false
+		for _, status := range q.Statuses {
+			switch status {
+			case "read":
+				statuses = append(statuses, models.StatusFinished)
+			case "unread":
+				includeUnread = true
+			default:
+				statuses = append(statuses, status)
+			}
+		}
+		switch {
+		case includeUnread && len(statuses) > 0:
+			tx = tx.Where("status IN ? OR status <> ?", statuses, models.StatusFinished)
+		case includeUnread:
+			tx = tx.Where("status <> ?", models.StatusFinished)
+		default:
+			tx = tx.Where("status IN ?", statuses)
+		}
+	}
+	if len(q.Shelves) > 0 {
+		names := []string{}
+		seen := map[string]bool{}
+		for _, shelf := range q.Shelves {
+			name := strings.ToLower(strings.TrimSpace(shelf))
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+
+		// Shelves belong to the same user as the books, so matching on the name is enough
+		onShelves := tx.Session(&gorm.Session{NewDB: true}).
+			Model(&models.BookShelf{}).
+			Select("book_shelves.book_id").
+			Joins("JOIN shelves ON shelves.id = book_shelves.shelf_id").
+			Where("LOWER(shelves.name) IN ?", names)
+		if q.ShelfMatch == "and" {
+			onShelves = onShelves.Group("book_shelves.book_id").Having("COUNT(DISTINCT shelves.id) = ?", len(names))
+		}
+		tx = tx.Where("id IN (?)", onShelves)
+	}
+	for _, condition := range q.Conditions {
+		// Conditions are checked by Validate
+		if where, args, err := filterClause(condition); err == nil {
+			tx = tx.Where(where, args...)
+		}
+	}
+	return tx
+}
+
+// filter returns the user's non-deleted books matching the filters of the query
+func (q BookQuery) filter(db *gorm.DB, userID uint) (*gorm.DB, error) {
+	return applyCustomFieldFilters(db, q.apply(db.Model(&models.Book{}).Where("user_id = ?", userID)), userID, q.CustomFields)
+}
+
+// orderClause builds the ORDER BY clause, always breaking ties on id so pages are stable
+func (q BookQuery) orderClause() string {
+	column, ok := bookSortColumns[q.Sort]
+	if !ok {
+		return "id asc"
+	}
+	order := "asc"
+	if q.Order == "desc" {
+		order = "desc"
+	}
+	return fmt.Sprintf("%s %s, id %s", column, order, order)
+}
+
+// pageSize returns the effective page size, clamped to MaxPageSize
+func (q BookQuery) pageSize() int {
+	if q.PageSize <= 0 {
+		return DefaultPageSize
+	}
+	if q.PageSize > MaxPageSize {
+		return MaxPageSize
+	}
+	return q.PageSize
+}
+
+// offset returns the row offset for the requested cursor or page
+func (q BookQuery) offset() int {
+	if q.Cursor != "" {
+		offset, _ := decodeCursor(q.Cursor)
+		return offset
+	}
+	if q.Page > 1 {
+		return (q.Page - 1) * q.pageSize()
+	}
+	return 0
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
+
+// QueryUserBooks returns the user's non-deleted books matching the query
+func (s *bookService) QueryUserBooks(ctx context.Context, auth0ID string, query BookQuery) (*BookPage, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUserByAuth0ID(ctx, auth0ID)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := query.filter(s.DB, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &BookPage{Books: []models.Book{}}
+	if err := base.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count books: %v", err)
+	}
+
+	tx := base.Session(&gorm.Session{}).
+		Preload("ReadThroughs", func(db *gorm.DB) *gorm.DB { return db.Order("finished_at asc, id asc") }).
+		Preload("Shelves", func(db *gorm.DB) *gorm.DB { return db.Order("LOWER(name) asc") }).
+		Preload("Series").
+		Preload("Copies", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Preload("Authors", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		Preload("Authors.Author").
+		Order(query.orderClause())
+	if query.IsPaginated() {
+		size := query.pageSize()
+		offset := query.offset()
+		tx = tx.Limit(size).Offset(offset)
+
+		page.PageSize = size
+		page.Page = offset/size + 1
+		if int64(offset+size) < page.Total {
+			page.NextCursor = encodeCursor(offset + size)
+		}
+	}
+
+	if err := tx.Find(&page.Books).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch books: %v", err)
+	}
+	if err := attachProgress(s.DB, page.Books); err != nil {
+		return nil, err
+	}
+	if err := attachLoans(s.DB, page.Books); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
 	"gorm.io/gorm"
@@ -11,15 +13,19 @@ import (
 type BookService interface {
 	AddBook(ctx context.Context, userID string, book models.Book) error
 	GetUserBooks(ctx context.Context, userID string) ([]models.Book, error)
+	QueryUserBooks(ctx context.Context, userID string, query BookQuery) (*BookPage, error)
 	DeleteBook(ctx context.Context, userID string, bookID uint) error
-	UpdateBook(ctx context.Context, userID string, bookID string, book models.Book) error
+	UpdateBook(ctx context.Context, userID string, bookID string, patch BookPatch) error
 	GetOrCreateUser(ctx context.Context, auth0ID string) (*models.User, error)
 	FindBookByTitleAndUser(ctx context.Context, title string, userID string) (*models.Book, error)
 	RestoreBook(ctx context.Context, bookID string) error
 	UpdateReadingGoal(ctx context.Context, userID string, goal int) error
 	GetReadingGoal(ctx context.Context, userID string) (int, error)
+	GetRatingScale(ctx context.Context, userID string) (*models.RatingScale, error)
+	UpdateRatingScale(ctx context.Context, userID string, scale models.RatingScale) (*models.RatingScale, error)
 	GetBookByID(ctx context.Context, userID string, bookID string) (*models.Book, error)
 	GetRecentlyDeletedBooks(ctx context.Context, userID string) ([]models.Book, error)
+	CountFinishedBooks(ctx context.Context, userID string, start time.Time, end time.Time) (int64, error)
 	GetDB() *gorm.DB
 }
 
@@ -59,16 +65,52 @@ func (s *bookService) AddBook(ctx context.Context, userID string, book models.Bo
 		return err
 	}
 
+	// Insert the book along with the goal history of when it was finished
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := createBook(tx, user, &book); err != nil {
+			return err
+		}
+		return NewGoalService(tx).RecomputeGoalHistory(ctx, userID, finishedOn(book.FinishedAt))
+	})
+}
+
+// createBook validates a new book for the user and inserts it along with its
+// author credits and search document. Goal history is left to the caller so
+// that several books can be recomputed at once.
+func createBook(tx *gorm.DB, user *models.User, book *models.Book) error {
 	// Set the UserID to associate the book with the user
 	book.UserID = user.ID
 
-	// Insert the book into the database
-	err = s.DB.Create(&book).Error
-	if err != nil {
-		return fmt.Errorf("failed to create book: %v", err)
+	var err error
+	if err := checkRating(user, book.Rating); err != nil {
+		return err
+	}
+	if book.Authors, err = validateAuthorCredits(book.Authors); err != nil {
+		return err
+	}
+	if book.Author == "" {
+		book.Author = FormatAuthorNames(book.Authors)
+	}
+	if book.Narrator == "" {
+		book.Narrator = FormatNarratorNames(book.Authors)
+	}
+	if err := normalizeEdition(book); err != nil {
+		return err
+	}
+	if err := normalizePurchase(book); err != nil {
+		return err
+	}
+	if book.CustomFields, err = validateCustomFieldValues(tx, user.ID, book.CustomFields); err != nil {
+		return err
 	}
 
-	return nil
+	if err := tx.Omit("Authors").Create(book).Error; err != nil {
+		return fmt.Errorf("failed to create book: %v", err)
+	}
+	if err := saveBookAuthors(tx, *book); err != nil {
+		return err
+	}
+	return indexBook(tx, book.ID)
 }
 
 // GetOrCreateUser finds or creates a user by their Auth0 ID
@@ -105,16 +147,25 @@ func (s *bookService) DeleteBook(ctx context.Context, userID string, bookID uint
 		return err
 	}
 
-	result := s.DB.Where("id = ? AND user_id = ?", bookID, user.ID).Delete(&models.Book{})
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
-	}
-	return nil
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var book models.Book
+		if err := tx.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&book).Error; err != nil {
+			return err
+		}
+		// Reads of deleted books no longer count towards the goal
+		finished, err := bookFinishDates(tx, &book)
+		if err != nil {
+			return err
+		}
+		return NewGoalService(tx).RecomputeGoalHistory(ctx, userID, finished)
+	})
 }
 
+// FindBookByTitleAndUser looks for a book with the same title in the user's collection,
+// including recently deleted books so they can be restored instead of duplicated
 func (s *bookService) FindBookByTitleAndUser(ctx context.Context, title string, userID string) (*models.Book, error) {
 	user, err := s.GetUserByAuth0ID(ctx, userID)
 	if err != nil {
@@ -122,7 +173,10 @@ func (s *bookService) FindBookByTitleAndUser(ctx context.Context, title string,
 	}
 
 	var book models.Book
-	err = s.DB.Where("title = ? AND user_id = ?", title, user.ID).First(&book).Error
+	err = s.DB.Unscoped().
+		Where("title = ? AND user_id = ?", title, user.ID).
+		Order("deleted_at IS NOT NULL").
+		First(&book).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -139,44 +193,246 @@ func (s *bookService) RestoreBook(ctx context.Context, bookID string) error {
 		return fmt.Errorf("failed to find book: %v", err)
 	}
 
-	// Then restore it by clearing DeletedAt
-	if err := s.DB.Model(&book).Unscoped().Update("deleted_at", nil).Error; err != nil {
-		return fmt.Errorf("failed to restore book: %v", err)
-	}
+	// Then restore it by clearing DeletedAt, counting its reads towards the goal again
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&book).Unscoped().Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore book: %v", err)
+		}
 
-	return nil
+		var user models.User
+		if err := tx.First(&user, book.UserID).Error; err != nil {
+			return fmt.Errorf("failed to find user: %v", err)
+		}
+		finished, err := bookFinishDates(tx, &book)
+		if err != nil {
+			return err
+		}
+		return NewGoalService(tx).RecomputeGoalHistory(ctx, user.Auth0ID, finished)
+	})
 }
 
-// UpdateBook updates all book fields
-func (s *bookService) UpdateBook(ctx context.Context, userID string, bookID string, book models.Book) error {
+// BookPatch holds the book fields an update changes. Fields left nil are not
+// touched, while the dates and purchase price that can be cleared take a
+// pointer to nil to do so.
+type BookPatch struct {
+	Title      *string
+	Author     *string
+	ISBN       *string
+	CoverImage *string
+	Rating     *float64
+	PageCount  *uint
+	Genre      *string
+
+	// Status moves the book to a new reading status, with StoppedPage recording
+	// where a paused or abandoned book was left. Without it the status follows
+	// the dates.
+	Status      *string
+	StoppedPage *uint
+	StartedAt   **time.Time
+	FinishedAt  **time.Time
+
+	Format          *string
+	ISBN10          *string
+	ISBN13          *string
+	Publisher       *string
+	PublishDate     *string
+	Language        *string
+	DurationMinutes *uint
+	Narrator        *string
+
+	PurchasePrice    **float64
+	PurchaseCurrency *string
+	PurchasedAt      **time.Time
+	PurchaseStore    *string
+
+	// CustomFields replaces the book's custom field values when not nil
+	CustomFields models.CustomFieldValues
+
+	// Authors replaces the book's credits when not nil
+	Authors []models.BookAuthor
+}
+
+// UpdateBook applies the fields of the patch to the book
+func (s *bookService) UpdateBook(ctx context.Context, userID string, bookID string, patch BookPatch) error {
 	user, err := s.GetUserByAuth0ID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
-	// Update the book if it belongs to the user
-	result := s.DB.Model(&models.Book{}).
-		Where("id = ? AND user_id = ?", bookID, user.ID).
-		Updates(map[string]interface{}{
-			"title":       book.Title,
-			"author":      book.Author,
-			"cover_image": book.CoverImage,
-			"rating":      book.Rating,
-			"page_count":  book.PageCount,
-			"genre":       book.Genre,
-			"started_at":  book.StartedAt,
-			"finished_at": book.FinishedAt,
-		})
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var book models.Book
+		if err := tx.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("book not found or not owned by user")
+			}
+			return err
+		}
+
+		// Updates copies the new values onto book, so keep the old finish date for the goal history
+		previouslyFinished := book.FinishedAt
+		previousAuthor, previousNarrator := book.Author, book.Narrator
+
+		updates, err := patch.apply(tx, user, &book)
+		if err != nil {
+			return err
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&book).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update book: %v", err)
+			}
+		}
+
+		// Credits are only rebuilt from the display strings when those changed
+		if patch.Authors != nil || book.Author != previousAuthor || book.Narrator != previousNarrator {
+			if err := saveBookAuthors(tx, models.Book{Model: book.Model, Author: book.Author, Narrator: book.Narrator, Authors: patch.Authors}); err != nil {
+				return err
+			}
+		}
+		if err := indexBook(tx, book.ID); err != nil {
+			return err
+		}
+		return NewGoalService(tx).RecomputeGoalHistory(ctx, userID, finishedOn(previouslyFinished, book.FinishedAt))
+	})
+}
+
+// apply validates the patch, sets its fields on the book and returns the
+// columns to update. Only the columns of fields in the patch are included.
+func (patch BookPatch) apply(tx *gorm.DB, user *models.User, book *models.Book) (map[string]interface{}, error) {
+	updates := map[string]interface{}{}
+	var err error
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to update book: %v", result.Error)
+	if patch.Title != nil {
+		book.Title = *patch.Title
+		updates["title"] = book.Title
+	}
+	if patch.CoverImage != nil {
+		book.CoverImage = *patch.CoverImage
+		updates["cover_image"] = book.CoverImage
+	}
+	if patch.Rating != nil {
+		if err := checkRating(user, *patch.Rating); err != nil {
+			return nil, err
+		}
+		book.Rating = *patch.Rating
+		updates["rating"] = book.Rating
+	}
+	if patch.PageCount != nil {
+		book.PageCount = *patch.PageCount
+		updates["page_count"] = book.PageCount
+	}
+	if patch.Genre != nil {
+		book.Genre = *patch.Genre
+		updates["genre"] = book.Genre
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("book not found or not owned by user")
+	// Credits given without display strings fill them in
+	if patch.Authors != nil {
+		if patch.Authors, err = validateAuthorCredits(patch.Authors); err != nil {
+			return nil, err
+		}
+		if patch.Author == nil {
+			book.Author = FormatAuthorNames(patch.Authors)
+			updates["author"] = book.Author
+		}
+		if patch.Narrator == nil {
+			book.Narrator = FormatNarratorNames(patch.Authors)
+			updates["narrator"] = book.Narrator
+		}
+	}
+	if patch.Author != nil {
+		book.Author = *patch.Author
+		if book.Author == "" {
+			book.Author = FormatAuthorNames(patch.Authors)
+		}
+		updates["author"] = book.Author
+	}
+	if patch.Narrator != nil {
+		book.Narrator = *patch.Narrator
+		if book.Narrator == "" {
+			book.Narrator = FormatNarratorNames(patch.Authors)
+		}
 	}
 
-	return nil
+	if patch.Format != nil || patch.ISBN != nil || patch.ISBN10 != nil || patch.ISBN13 != nil ||
+		patch.Publisher != nil || patch.PublishDate != nil || patch.Language != nil ||
+		patch.DurationMinutes != nil || patch.Narrator != nil {
+		// A new ISBN replaces the other forms unless they are given too
+		if patch.ISBN != nil || patch.ISBN10 != nil || patch.ISBN13 != nil {
+			book.ISBN, book.ISBN10, book.ISBN13 = valueOr(patch.ISBN, ""), valueOr(patch.ISBN10, ""), valueOr(patch.ISBN13, "")
+		}
+		book.Format = valueOr(patch.Format, book.Format)
+		book.Publisher = valueOr(patch.Publisher, book.Publisher)
+		book.PublishDate = valueOr(patch.PublishDate, book.PublishDate)
+		book.Language = valueOr(patch.Language, book.Language)
+		book.DurationMinutes = valueOr(patch.DurationMinutes, book.DurationMinutes)
+		if err := normalizeEdition(book); err != nil {
+			return nil, err
+		}
+		updates["isbn"] = book.ISBN
+		updates["isbn10"] = book.ISBN10
+		updates["isbn13"] = book.ISBN13
+		updates["format"] = book.Format
+		updates["publisher"] = book.Publisher
+		updates["publish_date"] = book.PublishDate
+		updates["language"] = book.Language
+		updates["duration_minutes"] = book.DurationMinutes
+		updates["narrator"] = book.Narrator
+	}
+
+	if patch.PurchasePrice != nil || patch.PurchaseCurrency != nil || patch.PurchasedAt != nil || patch.PurchaseStore != nil {
+		book.PurchasePrice = valueOr(patch.PurchasePrice, book.PurchasePrice)
+		book.PurchaseCurrency = valueOr(patch.PurchaseCurrency, book.PurchaseCurrency)
+		book.PurchasedAt = valueOr(patch.PurchasedAt, book.PurchasedAt)
+		book.PurchaseStore = valueOr(patch.PurchaseStore, book.PurchaseStore)
+		if err := normalizePurchase(book); err != nil {
+			return nil, err
+		}
+		updates["purchase_price"] = book.PurchasePrice
+		updates["purchase_currency"] = book.PurchaseCurrency
+		updates["purchased_at"] = book.PurchasedAt
+		updates["purchase_store"] = book.PurchaseStore
+	}
+
+	if patch.CustomFields != nil {
+		if book.CustomFields, err = validateCustomFieldValues(tx, user.ID, patch.CustomFields); err != nil {
+			return nil, err
+		}
+		updates["custom_fields"] = book.CustomFields
+	}
+
+	if patch.Status != nil || patch.StartedAt != nil || patch.FinishedAt != nil {
+		book.StartedAt = valueOr(patch.StartedAt, book.StartedAt)
+		book.FinishedAt = valueOr(patch.FinishedAt, book.FinishedAt)
+
+		status := valueOr(patch.Status, "")
+		if status != "" && status != book.Status {
+			if err := book.TransitionTo(status, time.Now(), patch.StoppedPage); err != nil {
+				return nil, newValidationError("%v", err)
+			}
+		} else if status == "" {
+			// Without an explicit status, follow the timestamps unless the book was stopped
+			stopped := book.Status == models.StatusDidNotFinish || book.Status == models.StatusPaused
+			if !stopped || book.FinishedAt != nil {
+				book.Status = book.InferStatus()
+				book.StoppedAt = nil
+				book.StoppedPage = nil
+			}
+		}
+		updates["status"] = book.Status
+		updates["started_at"] = book.StartedAt
+		updates["finished_at"] = book.FinishedAt
+		updates["stopped_at"] = book.StoppedAt
+		updates["stopped_page"] = book.StoppedPage
+	}
+	return updates, nil
+}
+
+// valueOr returns the value a patch field points at, or fallback when it was left out
+func valueOr[T any](value *T, fallback T) T {
+	if value == nil {
+		return fallback
+	}
+	return *value
 }
 
 // UpdateReadingGoal updates the user's reading goal
@@ -205,6 +461,47 @@ func (s *bookService) GetReadingGoal(ctx context.Context, auth0ID string) (int,
 	return user.ReadingGoal, nil
 }
 
+// GetRatingScale gets the scale the user rates books in
+func (s *bookService) GetRatingScale(ctx context.Context, auth0ID string) (*models.RatingScale, error) {
+	user, err := s.GetUserByAuth0ID(ctx, auth0ID)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := user.RatingScale()
+	return &scale, nil
+}
+
+// UpdateRatingScale changes the scale the user rates books in. Existing ratings
+// are converted to the new scale so they stay valid, e.g. 4 out of 5 becomes 8 out of 10.
+func (s *bookService) UpdateRatingScale(ctx context.Context, auth0ID string, scale models.RatingScale) (*models.RatingScale, error) {
+	user, err := s.GetUserByAuth0ID(ctx, auth0ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := scale.Validate(); err != nil {
+		return nil, newValidationError("%v", err)
+	}
+
+	current := user.RatingScale()
+	if scale == current {
+		return &scale, nil
+	}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"rating_max":  scale.Max,
+			"rating_step": scale.Step,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update rating scale: %v", err)
+		}
+		return convertRatings(tx, user, current, scale)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &scale, nil
+}
+
 // GetBookByID retrieves a book by its ID and user ID
 func (s *bookService) GetBookByID(ctx context.Context, userID string, bookID string) (*models.Book, error) {
 	user, err := s.GetUserByAuth0ID(ctx, userID)
@@ -213,10 +510,19 @@ func (s *bookService) GetBookByID(ctx context.Context, userID string, bookID str
 	}
 
 	var book models.Book
-	if err := s.DB.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+	if err := s.DB.Preload("Copies", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
 		return nil, err
 	}
-	return &book, nil
+
+	books := []models.Book{book}
+	if err := attachProgress(s.DB, books); err != nil {
+		return nil, err
+	}
+	if err := attachLoans(s.DB, books); err != nil {
+		return nil, err
+	}
+	return &books[0], nil
 }
 
 // GetRecentlyDeletedBooks retrieves books that have been soft deleted within the last 30 days
@@ -237,9 +543,39 @@ func (s *bookService) GetRecentlyDeletedBooks(ctx context.Context, userID string
 	return books, nil
 }
 
+// CountFinishedBooks counts the reads the user finished between start and end, treating
+// every completed read-through of a re-read book as another finished book
+func (s *bookService) CountFinishedBooks(ctx context.Context, userID string, start time.Time, end time.Time) (int64, error) {
+	user, err := s.GetUserByAuth0ID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var books int64
+	if err := s.DB.Model(&models.Book{}).
+		Where("user_id = ? AND finished_at IS NOT NULL AND finished_at >= ? AND finished_at <= ?", user.ID, start, end).
+		Count(&books).Error; err != nil {
+		return 0, fmt.Errorf("failed to count finished books: %v", err)
+	}
+
+	var rereads int64
+	if err := s.DB.Model(&models.ReadThrough{}).
+		Joins("JOIN books ON books.id = read_throughs.book_id AND books.deleted_at IS NULL").
+		Where("read_throughs.user_id = ? AND read_throughs.finished_at IS NOT NULL AND read_throughs.finished_at >= ? AND read_throughs.finished_at <= ?", user.ID, start, end).
+		Count(&rereads).Error; err != nil {
+		return 0, fmt.Errorf("failed to count finished read-throughs: %v", err)
+	}
+
+	return books + rereads, nil
+}
+
 func (s *bookService) GetUserByAuth0ID(ctx context.Context, auth0ID string) (*models.User, error) {
+	return findUserByAuth0ID(s.DB, auth0ID)
+}
+
+func findUserByAuth0ID(db *gorm.DB, auth0ID string) (*models.User, error) {
 	var user models.User
-	if err := s.DB.Where("auth0_id = ?", auth0ID).First(&user).Error; err != nil {
+	if err := db.Where("auth0_id = ?", auth0ID).First(&user).Error; err != nil {
 		return nil, fmt.Errorf("failed to find user: %v", err)
 	}
 	return &user, nil
@@ -19,7 +19,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Migrate the schema
-	err = db.AutoMigrate(&models.User{}, &models.Book{})
+	err = db.AutoMigrate(&models.User{}, &models.Book{}, &models.ReadingSession{}, &models.ReadThrough{}, &models.Shelf{}, &models.BookShelf{}, &models.Series{}, &models.Author{}, &models.BookAuthor{}, &models.Note{}, &models.Highlight{}, &models.Loan{}, &models.BookCopy{}, &models.WishlistItem{}, &models.CustomField{}, &models.BookSearch{}, &models.SmartShelf{}, &models.Review{}, &models.GoalHistory{}, &models.StreakSettings{})
 	if err != nil {
 		t.Fatalf("failed to migrate database: %v", err)
 	}
@@ -155,10 +155,11 @@ func TestUpdateBook(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Update the book
-	updatedBook := models.Book{
-		Title:     "Updated Title",
-		Author:    "Updated Author",
-		PageCount: 200,
+	title, author, pageCount := "Updated Title", "Updated Author", uint(200)
+	updatedBook := BookPatch{
+		Title:     &title,
+		Author:    &author,
+		PageCount: &pageCount,
 	}
 	err = service.UpdateBook(ctx, user.Auth0ID, fmt.Sprintf("%d", book.ID), updatedBook)
 	assert.NoError(t, err)
@@ -280,3 +281,110 @@ func TestGetBookByID(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, foundBook)
 }
+
+func TestQueryUserBooks(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBookService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	finished := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	books := []models.Book{
+		{Title: "The Hobbit", Author: "J.R.R. Tolkien", Genre: "Fantasy", Rating: 5, UserID: user.ID, FinishedAt: &finished},
+		{Title: "Dune", Author: "Frank Herbert", Genre: "Science Fiction", Rating: 4, UserID: user.ID},
+		{Title: "The Silmarillion", Author: "J.R.R. Tolkien", Genre: "Fantasy", Rating: 3, UserID: user.ID},
+	}
+	for _, book := range books {
+		err := db.Create(&book).Error
+		assert.NoError(t, err)
+	}
+
+	// Free-text search matches title or author
+	page, err := service.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Search: "tolkien"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), page.Total)
+
+	// Genre and rating range
+	minRating := 4.0
+	page, err = service.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Genres: []string{"fantasy"}, MinRating: &minRating})
+	assert.NoError(t, err)
+	assert.Len(t, page.Books, 1)
+	assert.Equal(t, "The Hobbit", page.Books[0].Title)
+
+	// Read status
+	page, err = service.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Statuses: []string{"unread"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), page.Total)
+
+	// Sorting and pagination
+	page, err = service.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Sort: "title", PageSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Len(t, page.Books, 2)
+	assert.Equal(t, "Dune", page.Books[0].Title)
+	assert.NotEmpty(t, page.NextCursor)
+
+	page, err = service.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Sort: "title", PageSize: 2, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, page.Books, 1)
+	assert.Equal(t, "The Silmarillion", page.Books[0].Title)
+	assert.Empty(t, page.NextCursor)
+
+	// Invalid sort key
+	_, err = service.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Sort: "deleted_at"})
+	assert.Error(t, err)
+}
+
+func TestQueryUserBooksSearchIsLiteral(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBookService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	books := []models.Book{
+		{Title: "100% Wolf", Author: "Jayne Lyons", UserID: user.ID},
+		{Title: "1000 Books to Read", Author: "James Mustich", UserID: user.ID},
+		{Title: "Snake_Case Style", Author: "Ada Lovelace", UserID: user.ID},
+	}
+	for _, book := range books {
+		assert.NoError(t, db.Create(&book).Error)
+	}
+
+	// % and _ are matched as written rather than as wildcards
+	page, err := service.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Search: "100%"})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Books, 1) {
+		assert.Equal(t, "100% Wolf", page.Books[0].Title)
+	}
+
+	page, err = service.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Search: "_"})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Books, 1) {
+		assert.Equal(t, "Snake_Case Style", page.Books[0].Title)
+	}
+}
+
+func TestCountFinishedBooks(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBookService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	march := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	lastYear := time.Date(2023, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	book := models.Book{Title: "Re-read", Author: "Author", UserID: user.ID, FinishedAt: &june}
+	assert.NoError(t, db.Create(&book).Error)
+	other := models.Book{Title: "Other", Author: "Author", UserID: user.ID, FinishedAt: &lastYear}
+	assert.NoError(t, db.Create(&other).Error)
+
+	// An earlier read of the same book in the same year counts separately
+	assert.NoError(t, db.Create(&models.ReadThrough{BookID: book.ID, UserID: user.ID, FinishedAt: &march}).Error)
+
+	count, err := service.CountFinishedBooks(ctx, user.Auth0ID,
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// CopyQuery filters the copies across a user's library. Search matches the
+// title, author or ISBN of the book, Room and Shelf match the location ignoring case.
+type CopyQuery struct {
+	Search string
+	Room   string
+	Shelf  string
+}
+
+type CopyService interface {
+	ListCopies(ctx context.Context, userID string, bookID string) ([]models.BookCopy, error)
+	AddCopy(ctx context.Context, userID string, bookID string, bookCopy models.BookCopy) (*models.BookCopy, error)
+	UpdateCopy(ctx context.Context, userID string, bookID string, copyID string, bookCopy models.BookCopy) (*models.BookCopy, error)
+	DeleteCopy(ctx context.Context, userID string, bookID string, copyID string) error
+	FindCopies(ctx context.Context, userID string, query CopyQuery) ([]models.BookCopy, error)
+}
+
+type copyService struct {
+	DB *gorm.DB
+}
+
+func NewCopyService(db *gorm.DB) CopyService {
+	return &copyService{
+		DB: db,
+	}
+}
+
+// findUserBook loads a non-deleted book owned by the user
+func (s *copyService) findUserBook(auth0ID string, bookID string) (*models.User, *models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, auth0ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var book models.Book
+	if err := s.DB.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+		return nil, nil, err
+	}
+	return user, &book, nil
+}
+
+// ListCopies returns the copies of the book in the order they were added
+func (s *copyService) ListCopies(ctx context.Context, userID string, bookID string) ([]models.BookCopy, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := []models.BookCopy{}
+	if err := s.DB.Where("book_id = ? AND user_id = ?", book.ID, user.ID).Order("id asc").Find(&copies).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch copies: %v", err)
+	}
+	if err := attachCopyLoans(s.DB, copies); err != nil {
+		return nil, err
+	}
+	return copies, nil
+}
+
+// AddCopy records another physical copy of the book
+func (s *copyService) AddCopy(ctx context.Context, userID string, bookID string, bookCopy models.BookCopy) (*models.BookCopy, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if book.Format == models.FormatEbook || book.Format == models.FormatAudiobook {
+		return nil, newValidationError("only physical books can have copies")
+	}
+	if bookCopy, err = validateCopy(bookCopy); err != nil {
+		return nil, err
+	}
+
+	bookCopy.ID = 0
+	bookCopy.BookID = book.ID
+	bookCopy.UserID = user.ID
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkBarcodeFree(tx, user.ID, bookCopy); err != nil {
+			return err
+		}
+		if err := tx.Create(&bookCopy).Error; err != nil {
+			return fmt.Errorf("failed to create copy: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+// UpdateCopy replaces the location, condition and details of a copy
+func (s *copyService) UpdateCopy(ctx context.Context, userID string, bookID string, copyID string, bookCopy models.BookCopy) (*models.BookCopy, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if bookCopy, err = validateCopy(bookCopy); err != nil {
+		return nil, err
+	}
+
+	var existing models.BookCopy
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND book_id = ? AND user_id = ?", copyID, book.ID, user.ID).First(&existing).Error; err != nil {
+			return err
+		}
+		bookCopy.ID = existing.ID
+		if err := checkBarcodeFree(tx, user.ID, bookCopy); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&existing).Updates(map[string]interface{}{
+			"room":            bookCopy.Room,
+			"shelf":           bookCopy.Shelf,
+			"position":        bookCopy.Position,
+			"condition":       bookCopy.Condition,
+			"condition_notes": bookCopy.ConditionNotes,
+			"barcode":         bookCopy.Barcode,
+			"acquired_at":     bookCopy.AcquiredAt,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update copy: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	copies := []models.BookCopy{existing}
+	if err := attachCopyLoans(s.DB, copies); err != nil {
+		return nil, err
+	}
+	return &copies[0], nil
+}
+
+// DeleteCopy removes a copy that has left the library. Copies that are lent out
+// have to be returned first.
+func (s *copyService) DeleteCopy(ctx context.Context, userID string, bookID string, copyID string) error {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return err
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var bookCopy models.BookCopy
+		if err := tx.Where("id = ? AND book_id = ? AND user_id = ?", copyID, book.ID, user.ID).First(&bookCopy).Error; err != nil {
+			return err
+		}
+
+		var open int64
+		if err := tx.Model(&models.Loan{}).Where("copy_id = ? AND returned_at IS NULL", bookCopy.ID).Count(&open).Error; err != nil {
+			return fmt.Errorf("failed to check current loans: %v", err)
+		}
+		if open > 0 {
+			return ErrBookOnLoan
+		}
+
+		// Past loans stay in the book's history without the copy
+		if err := tx.Model(&models.Loan{}).Where("copy_id = ?", bookCopy.ID).Update("copy_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to detach loans: %v", err)
+		}
+		if err := tx.Delete(&bookCopy).Error; err != nil {
+			return fmt.Errorf("failed to delete copy: %v", err)
+		}
+		return nil
+	})
+}
+
+// FindCopies answers "where is this book" and "what is on this shelf" across
+// the user's library, returning the matching copies along with their books
+func (s *copyService) FindCopies(ctx context.Context, userID string, query CopyQuery) ([]models.BookCopy, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := s.DB.Preload("Book").
+		Joins("JOIN books ON books.id = book_copies.book_id AND books.deleted_at IS NULL").
+		Where("book_copies.user_id = ?", user.ID)
+	if search := strings.TrimSpace(query.Search); search != "" {
+		pattern := "%" + strings.ToLower(search) + "%"
+		isbn := NormalizeISBN(search)
+		tx = tx.Where("LOWER(books.title) LIKE ? OR LOWER(books.author) LIKE ? OR (? <> '' AND (books.isbn = ? OR books.isbn13 = ? OR books.isbn10 = ?))",
+			pattern, pattern, isbn, isbn, isbn, isbn)
+	}
+	if room := strings.TrimSpace(query.Room); room != "" {
+		tx = tx.Where("LOWER(book_copies.room) = ?", strings.ToLower(room))
+	}
+	if shelf := strings.TrimSpace(query.Shelf); shelf != "" {
+		tx = tx.Where("LOWER(book_copies.shelf) = ?", strings.ToLower(shelf))
+	}
+
+	copies := []models.BookCopy{}
+	if err := tx.Order("LOWER(book_copies.room) asc, LOWER(book_copies.shelf) asc, book_copies.position asc, LOWER(books.title) asc, book_copies.id asc").
+		Find(&copies).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch copies: %v", err)
+	}
+	if err := attachCopyLoans(s.DB, copies); err != nil {
+		return nil, err
+	}
+	return copies, nil
+}
+
+// validateCopy trims the copy's details and checks the condition grade
+func validateCopy(bookCopy models.BookCopy) (models.BookCopy, error) {
+	bookCopy.Room = strings.TrimSpace(bookCopy.Room)
+	bookCopy.Shelf = strings.TrimSpace(bookCopy.Shelf)
+	bookCopy.Position = strings.TrimSpace(bookCopy.Position)
+	bookCopy.Barcode = strings.TrimSpace(bookCopy.Barcode)
+	bookCopy.ConditionNotes = strings.TrimSpace(bookCopy.ConditionNotes)
+	bookCopy.Condition = strings.ToLower(strings.TrimSpace(bookCopy.Condition))
+
+	for name, value := range map[string]string{"room": bookCopy.Room, "shelf": bookCopy.Shelf, "position": bookCopy.Position, "barcode": bookCopy.Barcode} {
+		if len(value) > models.MaxCopyFieldLength {
+			return bookCopy, newValidationError("%s cannot be longer than %d characters", name, models.MaxCopyFieldLength)
+		}
+	}
+	if bookCopy.Condition != "" && !models.IsValidCondition(bookCopy.Condition) {
+		return bookCopy, newValidationError("invalid condition: %s, expected one of %s", bookCopy.Condition, strings.Join(models.Conditions, ", "))
+	}
+	if bookCopy.AcquiredAt != nil && bookCopy.AcquiredAt.After(time.Now().Add(24*time.Hour)) {
+		return bookCopy, newValidationError("acquired date cannot be in the future")
+	}
+	return bookCopy, nil
+}
+
+// checkBarcodeFree makes sure no other copy in the user's library has the barcode
+func checkBarcodeFree(tx *gorm.DB, userID uint, bookCopy models.BookCopy) error {
+	if bookCopy.Barcode == "" {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.BookCopy{}).
+		Where("user_id = ? AND barcode = ? AND id <> ?", userID, bookCopy.Barcode, bookCopy.ID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check barcode: %v", err)
+	}
+	if count > 0 {
+		return newValidationError("barcode %s is already used by another copy", bookCopy.Barcode)
+	}
+	return nil
+}
+
+// attachCopyLoans sets OnLoan on each copy that has an unreturned loan
+func attachCopyLoans(db *gorm.DB, copies []models.BookCopy) error {
+	if len(copies) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(copies))
+	for i, bookCopy := range copies {
+		ids[i] = bookCopy.ID
+	}
+
+	var lent []uint
+	if err := db.Model(&models.Loan{}).
+		Where("copy_id IN ? AND returned_at IS NULL", ids).
+		Pluck("copy_id", &lent).Error; err != nil {
+		return fmt.Errorf("failed to fetch loans: %v", err)
+	}
+
+	onLoan := make(map[uint]bool, len(lent))
+	for _, id := range lent {
+		onLoan[id] = true
+	}
+	for i := range copies {
+		copies[i].OnLoan = onLoan[copies[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookCopies(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewCopyService(db)
+	loans := NewLoanService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	dune := models.Book{Title: "Dune", Author: "Frank Herbert", ISBN: "9780441172719", UserID: user.ID}
+	assert.NoError(t, db.Create(&dune).Error)
+	emma := models.Book{Title: "Emma", Author: "Jane Austen", UserID: user.ID}
+	assert.NoError(t, db.Create(&emma).Error)
+	ebook := models.Book{Title: "Piranesi", Author: "Susanna Clarke", Format: models.FormatEbook, UserID: user.ID}
+	assert.NoError(t, db.Create(&ebook).Error)
+	duneID := fmt.Sprintf("%d", dune.ID)
+	emmaID := fmt.Sprintf("%d", emma.ID)
+
+	_, err := service.AddCopy(ctx, user.Auth0ID, fmt.Sprintf("%d", ebook.ID), models.BookCopy{Room: "Office"})
+	assert.True(t, IsValidationError(err))
+	_, err = service.AddCopy(ctx, user.Auth0ID, duneID, models.BookCopy{Room: "Office", Condition: "mint"})
+	assert.True(t, IsValidationError(err))
+
+	first, err := service.AddCopy(ctx, user.Auth0ID, duneID, models.BookCopy{Room: " Office ", Shelf: "B3", Position: "12", Condition: "Very_Good", Barcode: "LIB-001"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Office / B3 / 12", first.Location())
+	assert.Equal(t, models.ConditionVeryGood, first.Condition)
+	second, err := service.AddCopy(ctx, user.Auth0ID, duneID, models.BookCopy{Room: "Kitchen", Condition: models.ConditionPoor, ConditionNotes: "Coffee stain on the cover"})
+	assert.NoError(t, err)
+	_, err = service.AddCopy(ctx, user.Auth0ID, emmaID, models.BookCopy{Room: "office", Shelf: "b3", Barcode: "LIB-001"})
+	assert.True(t, IsValidationError(err))
+	_, err = service.AddCopy(ctx, user.Auth0ID, emmaID, models.BookCopy{Room: "office", Shelf: "b3", Position: "1"})
+	assert.NoError(t, err)
+
+	// Where is Dune, and what is on shelf B3?
+	found, err := service.FindCopies(ctx, user.Auth0ID, CopyQuery{Search: "dune"})
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, "Kitchen", found[0].Room)
+	assert.Equal(t, "Dune", found[0].Book.Title)
+	found, err = service.FindCopies(ctx, user.Auth0ID, CopyQuery{Search: "978-0-441-17271-9"})
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	found, err = service.FindCopies(ctx, user.Auth0ID, CopyQuery{Room: "OFFICE", Shelf: "B3"})
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, "Emma", found[0].Book.Title)
+
+	// Books with several copies lend and return one copy at a time
+	_, err = loans.LendBook(ctx, user.Auth0ID, duneID, models.Loan{Borrower: "Sam"})
+	assert.True(t, IsValidationError(err))
+	_, err = loans.LendBook(ctx, user.Auth0ID, emmaID, models.Loan{Borrower: "Sam", CopyID: &first.ID})
+	assert.True(t, IsValidationError(err))
+	_, err = loans.LendBook(ctx, user.Auth0ID, duneID, models.Loan{Borrower: "Sam", CopyID: &first.ID})
+	assert.NoError(t, err)
+	_, err = loans.LendBook(ctx, user.Auth0ID, duneID, models.Loan{Borrower: "Alex", CopyID: &first.ID})
+	assert.ErrorIs(t, err, ErrBookOnLoan)
+	_, err = loans.LendBook(ctx, user.Auth0ID, duneID, models.Loan{Borrower: "Alex", CopyID: &second.ID})
+	assert.NoError(t, err)
+
+	// Emma has one copy, which is lent when none is named
+	loan, err := loans.LendBook(ctx, user.Auth0ID, emmaID, models.Loan{Borrower: "Alex"})
+	assert.NoError(t, err)
+	assert.NotNil(t, loan.CopyID)
+
+	book, err := NewBookService(db).GetBookByID(ctx, user.Auth0ID, duneID)
+	assert.NoError(t, err)
+	assert.True(t, book.OnLoan)
+	assert.Len(t, book.Copies, 2)
+	assert.True(t, book.Copies[0].OnLoan)
+
+	assert.ErrorIs(t, service.DeleteCopy(ctx, user.Auth0ID, duneID, fmt.Sprintf("%d", second.ID)), ErrBookOnLoan)
+
+	_, err = loans.ReturnBook(ctx, user.Auth0ID, duneID, nil, time.Time{})
+	assert.True(t, IsValidationError(err))
+	returned, err := loans.ReturnBook(ctx, user.Auth0ID, duneID, &second.ID, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alex", returned.Borrower)
+
+	copies, err := service.ListCopies(ctx, user.Auth0ID, duneID)
+	assert.NoError(t, err)
+	assert.True(t, copies[0].OnLoan)
+	assert.False(t, copies[1].OnLoan)
+
+	updated, err := service.UpdateCopy(ctx, user.Auth0ID, duneID, fmt.Sprintf("%d", second.ID), models.BookCopy{Room: "Office", Shelf: "A1", Condition: models.ConditionGood})
+	assert.NoError(t, err)
+	assert.Equal(t, "Office / A1", updated.Location())
+	assert.Empty(t, updated.ConditionNotes)
+
+	assert.NoError(t, service.DeleteCopy(ctx, user.Auth0ID, duneID, fmt.Sprintf("%d", second.ID)))
+	history, err := loans.ListLoans(ctx, user.Auth0ID, duneID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+}
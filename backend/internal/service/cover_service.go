@@ -0,0 +1,423 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxCoverBytes is the largest cover image that can be uploaded or proxied
+	MaxCoverBytes = 10 << 20
+
+	// maxCoverPixels guards against images that are small files but huge once decoded
+	maxCoverPixels = 40_000_000
+
+	coverJPEGQuality = 85
+)
+
+// CoverSize is a standard width covers are resized to, keeping their aspect ratio
+type CoverSize struct {
+	Name  string
+	Width int
+}
+
+// CoverSizes are the sizes every cover is stored in, smallest first
+var CoverSizes = []CoverSize{
+	{Name: "small", Width: 100},
+	{Name: "medium", Width: 250},
+	{Name: "large", Width: 500},
+}
+
+// DefaultCoverSize is the size a book's CoverImage points at after an upload
+const DefaultCoverSize = "medium"
+
+// ErrCoverFetchFailed is returned when a remote cover can't be downloaded
+var ErrCoverFetchFailed = errors.New("failed to fetch cover")
+
+// coverID matches the ids of uploaded covers, which are derived from their contents
+var coverID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// coverPath is the path uploaded covers are served from
+const coverPath = "/api/covers/"
+
+type CoverService interface {
+	UploadCover(ctx context.Context, userID string, bookID string, data []byte) (*models.Book, error)
+	RemoveCover(ctx context.Context, userID string, bookID string) (*models.Book, error)
+	GetCover(ctx context.Context, coverID string, size string) (io.ReadCloser, error)
+	ProxyCover(ctx context.Context, remoteURL string, size string) (io.ReadCloser, error)
+}
+
+type coverService struct {
+	DB     *gorm.DB
+	Store  BlobStore
+	Client *http.Client
+}
+
+func NewCoverService(db *gorm.DB, store BlobStore) CoverService {
+	return &coverService{
+		DB:     db,
+		Store:  store,
+		Client: newPublicHTTPClient(),
+	}
+}
+
+// findUserBook loads a non-deleted book owned by the user
+func (s *coverService) findUserBook(auth0ID string, bookID string) (*models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, auth0ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var book models.Book
+	if err := s.DB.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// UploadCover stores the image in every cover size and points the book's
+// CoverImage at it. Covers are keyed by their contents, so the same image
+// uploaded for several books is only stored once.
+func (s *coverService) UploadCover(ctx context.Context, userID string, bookID string, data []byte) (*models.Book, error) {
+	book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnails, err := makeCoverThumbnails(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:16])
+	for size, thumbnail := range thumbnails {
+		if err := s.Store.Put(ctx, coverKey(id, size), thumbnail); err != nil {
+			return nil, err
+		}
+	}
+
+	book.CoverImage = coverURL(id, DefaultCoverSize)
+	if err := s.DB.Model(book).Update("cover_image", book.CoverImage).Error; err != nil {
+		return nil, fmt.Errorf("failed to update cover: %v", err)
+	}
+	return book, nil
+}
+
+// RemoveCover clears the book's cover. Uploaded images are left for the orphan
+// cleanup, as other books may share them.
+func (s *coverService) RemoveCover(ctx context.Context, userID string, bookID string) (*models.Book, error) {
+	book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	book.CoverImage = ""
+	if err := s.DB.Model(book).Update("cover_image", "").Error; err != nil {
+		return nil, fmt.Errorf("failed to remove cover: %v", err)
+	}
+	return book, nil
+}
+
+// GetCover opens an uploaded cover in the given size
+func (s *coverService) GetCover(ctx context.Context, id string, size string) (io.ReadCloser, error) {
+	if !coverID.MatchString(id) || !isCoverSize(size) {
+		return nil, ErrBlobNotFound
+	}
+	return s.Store.Get(ctx, coverKey(id, size))
+}
+
+// ProxyCover serves a remote cover image in the given size, downloading and
+// resizing it the first time it is asked for
+func (s *coverService) ProxyCover(ctx context.Context, remoteURL string, size string) (io.ReadCloser, error) {
+	if size == "" {
+		size = DefaultCoverSize
+	}
+	if !isCoverSize(size) {
+		return nil, newValidationError("size must be one of %s", strings.Join(coverSizeNames(), ", "))
+	}
+	parsed, err := url.Parse(remoteURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, newValidationError("url must be an http or https address")
+	}
+
+	key := proxyKey(remoteURL, size)
+	cached, err := s.Store.Get(ctx, key)
+	if !errors.Is(err, ErrBlobNotFound) {
+		return cached, err
+	}
+
+	data, err := s.fetchCover(ctx, remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	thumbnails, err := makeCoverThumbnails(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCoverFetchFailed, err)
+	}
+	for name, thumbnail := range thumbnails {
+		if err := s.Store.Put(ctx, proxyKey(remoteURL, name), thumbnail); err != nil {
+			return nil, err
+		}
+	}
+	return io.NopCloser(bytes.NewReader(thumbnails[size])), nil
+}
+
+// fetchCover downloads a remote image, refusing anything that isn't an image or is too large
+func (s *coverService) fetchCover(ctx context.Context, remoteURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, remoteURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCoverFetchFailed, err)
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCoverFetchFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unexpected status code: %d", ErrCoverFetchFailed, resp.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); !strings.HasPrefix(mediaType, "image/") {
+		return nil, fmt.Errorf("%w: not an image: %s", ErrCoverFetchFailed, mediaType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxCoverBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCoverFetchFailed, err)
+	}
+	if len(data) > MaxCoverBytes {
+		return nil, fmt.Errorf("%w: image is larger than %d bytes", ErrCoverFetchFailed, MaxCoverBytes)
+	}
+	return data, nil
+}
+
+// DeleteOrphanedCovers removes uploaded covers no book points at any more,
+// including recently deleted books that can still be restored, and cached
+// copies of remote covers no book or wishlist item uses. It returns how many
+// blobs were removed.
+func DeleteOrphanedCovers(ctx context.Context, db *gorm.DB, store BlobStore) (int, error) {
+	var covers []string
+	if err := db.Unscoped().Model(&models.Book{}).
+		Where("cover_image <> ''").
+		Distinct().Pluck("cover_image", &covers).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch book covers: %v", err)
+	}
+	var wished []string
+	if err := db.Model(&models.WishlistItem{}).
+		Where("cover_image <> ''").
+		Distinct().Pluck("cover_image", &wished).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch wishlist covers: %v", err)
+	}
+
+	keep := map[string]bool{}
+	for _, cover := range append(covers, wished...) {
+		if id := coverIDFromURL(cover); id != "" {
+			keep["covers/"+id] = true
+		} else {
+			keep["proxy/"+hashURL(cover)] = true
+		}
+	}
+
+	removed := 0
+	for _, prefix := range []string{"covers/", "proxy/"} {
+		keys, err := store.List(ctx, prefix)
+		if err != nil {
+			return removed, err
+		}
+		for _, key := range keys {
+			// Keys are <prefix><id>/<size>.jpg
+			if keep[path.Dir(key)] {
+				continue
+			}
+			if err := store.Delete(ctx, key); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// makeCoverThumbnails decodes an uploaded image and encodes it as a JPEG in each
+// cover size
+func makeCoverThumbnails(data []byte) (map[string][]byte, error) {
+	if len(data) == 0 {
+		return nil, newValidationError("cover image is empty")
+	}
+	if len(data) > MaxCoverBytes {
+		return nil, newValidationError("cover image cannot be larger than %d MB", MaxCoverBytes>>20)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, newValidationError("cover must be a JPEG, PNG or GIF image")
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxCoverPixels {
+		return nil, newValidationError("cover image dimensions are too large")
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, newValidationError("cover image could not be read: %v", err)
+	}
+	flat := flattenImage(src)
+
+	thumbnails := make(map[string][]byte, len(CoverSizes))
+	for _, size := range CoverSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resizeImage(flat, size.Width), &jpeg.Options{Quality: coverJPEGQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode cover: %v", err)
+		}
+		thumbnails[size.Name] = buf.Bytes()
+	}
+	return thumbnails, nil
+}
+
+// flattenImage draws the image onto a white background, as JPEGs have no transparency
+func flattenImage(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, bounds.Min, draw.Over)
+	return flat
+}
+
+// resizeImage scales the image down to the width, averaging the source pixels
+// that fall under each output pixel. Images narrower than the width are kept
+// at their own size rather than enlarged.
+func resizeImage(src *image.RGBA, width int) *image.RGBA {
+	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
+	if srcW <= width {
+		return src
+	}
+	height := max(int(math.Round(float64(srcH)*float64(width)/float64(srcW))), 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcH / height
+		y1 := max((y+1)*srcH/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := x * srcW / width
+			x1 := max((x+1)*srcW/width, x0+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(src.Pix[i+c])
+					}
+					i += 4
+				}
+			}
+
+			n := (y1 - y0) * (x1 - x0)
+			j := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[j+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// newPublicHTTPClient returns a client for fetching remote covers that refuses
+// to connect to loopback, private and link-local addresses, so the proxy can't
+// be used to reach internal services
+func newPublicHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+				return fmt.Errorf("refusing to connect to %s", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout:   15 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+}
+
+// coverURL is where an uploaded cover is served from. BACKEND_URL makes it
+// absolute for frontends served from another origin.
+func coverURL(id string, size string) string {
+	return strings.TrimRight(os.Getenv("BACKEND_URL"), "/") + coverPath + id + "/" + size
+}
+
+// coverIDFromURL returns the id of an uploaded cover from its URL, or "" for other URLs
+func coverIDFromURL(cover string) string {
+	i := strings.Index(cover, coverPath)
+	if i < 0 {
+		return ""
+	}
+	id, _, _ := strings.Cut(cover[i+len(coverPath):], "/")
+	if !coverID.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+func coverKey(id string, size string) string {
+	return "covers/" + id + "/" + size + ".jpg"
+}
+
+func proxyKey(remoteURL string, size string) string {
+	return "proxy/" + hashURL(remoteURL) + "/" + size + ".jpg"
+}
+
+func hashURL(remoteURL string) string {
+	sum := sha256.Sum256([]byte(remoteURL))
+	return hex.EncodeToString(sum[:16])
+}
+
+func isCoverSize(name string) bool {
+	for _, size := range CoverSizes {
+		if size.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func coverSizeNames() []string {
+	names := make([]string, len(CoverSizes))
+	for i, size := range CoverSizes {
+		names[i] = size.Name
+	}
+	return names
+}
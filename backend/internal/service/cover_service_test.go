@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCoverPNG(t *testing.T, width int, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func decodeCover(t *testing.T, cover io.ReadCloser) image.Image {
+	defer cover.Close()
+	img, err := jpeg.Decode(cover)
+	assert.NoError(t, err)
+	return img
+}
+
+func TestUploadCover(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewLocalBlobStore(t.TempDir())
+	service := NewCoverService(db, store)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	book := models.Book{Title: "Dune", Author: "Frank Herbert", CoverImage: "https://example.com/dune.jpg", UserID: user.ID}
+	assert.NoError(t, db.Create(&book).Error)
+	bookID := fmt.Sprintf("%d", book.ID)
+
+	_, err := service.UploadCover(ctx, user.Auth0ID, bookID, []byte("not an image"))
+	assert.True(t, IsValidationError(err))
+
+	updated, err := service.UploadCover(ctx, user.Auth0ID, bookID, testCoverPNG(t, 400, 600))
+	assert.NoError(t, err)
+	id := coverIDFromURL(updated.CoverImage)
+	assert.NotEmpty(t, id)
+	assert.True(t, strings.HasSuffix(updated.CoverImage, "/api/covers/"+id+"/medium"))
+
+	// Each size keeps the aspect ratio, and smaller images aren't enlarged
+	for size, width := range map[string]int{"small": 100, "medium": 250, "large": 400} {
+		cover, err := service.GetCover(ctx, id, size)
+		assert.NoError(t, err)
+		bounds := decodeCover(t, cover).Bounds()
+		assert.Equal(t, width, bounds.Dx(), size)
+		assert.Equal(t, width*3/2, bounds.Dy(), size)
+	}
+
+	_, err = service.GetCover(ctx, id, "huge")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+	_, err = service.GetCover(ctx, "../../etc", "small")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	removed, err := service.RemoveCover(ctx, user.Auth0ID, bookID)
+	assert.NoError(t, err)
+	assert.Empty(t, removed.CoverImage)
+}
+
+func TestProxyCover(t *testing.T) {
+	db := setupTestDB(t)
+	cover := testCoverPNG(t, 300, 450)
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path == "/page.html" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(cover)
+	}))
+	defer server.Close()
+
+	service := &coverService{DB: db, Store: NewLocalBlobStore(t.TempDir()), Client: server.Client()}
+	ctx := context.Background()
+
+	proxied, err := service.ProxyCover(ctx, server.URL+"/cover.png", "small")
+	assert.NoError(t, err)
+	assert.Equal(t, 100, decodeCover(t, proxied).Bounds().Dx())
+
+	// Later requests for any size come from the cache
+	proxied, err = service.ProxyCover(ctx, server.URL+"/cover.png", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 250, decodeCover(t, proxied).Bounds().Dx())
+	assert.Equal(t, 1, hits)
+
+	_, err = service.ProxyCover(ctx, server.URL+"/page.html", "small")
+	assert.ErrorIs(t, err, ErrCoverFetchFailed)
+
+	_, err = service.ProxyCover(ctx, "file:///etc/passwd", "small")
+	assert.True(t, IsValidationError(err))
+
+	// The default client won't reach addresses on the local network
+	service.Client = newPublicHTTPClient()
+	_, err = service.ProxyCover(ctx, server.URL+"/other.png", "small")
+	assert.ErrorIs(t, err, ErrCoverFetchFailed)
+}
+
+func TestDeleteOrphanedCovers(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewLocalBlobStore(t.TempDir())
+	service := NewCoverService(db, store)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	kept := models.Book{Title: "Dune", Author: "Frank Herbert", UserID: user.ID}
+	assert.NoError(t, db.Create(&kept).Error)
+	purged := models.Book{Title: "Emma", Author: "Jane Austen", UserID: user.ID}
+	assert.NoError(t, db.Create(&purged).Error)
+
+	_, err := service.UploadCover(ctx, user.Auth0ID, fmt.Sprintf("%d", kept.ID), testCoverPNG(t, 120, 180))
+	assert.NoError(t, err)
+	_, err = service.UploadCover(ctx, user.Auth0ID, fmt.Sprintf("%d", purged.ID), testCoverPNG(t, 130, 180))
+	assert.NoError(t, err)
+	assert.NoError(t, store.Put(ctx, proxyKey("https://example.com/gone.jpg", "small"), []byte("cached")))
+
+	// Soft deleted books can still be restored, so their covers stay
+	assert.NoError(t, db.Delete(&purged).Error)
+	removed, err := DeleteOrphanedCovers(ctx, db, store)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	assert.NoError(t, db.Unscoped().Delete(&purged).Error)
+	removed, err = DeleteOrphanedCovers(ctx, db, store)
+	assert.NoError(t, err)
+	assert.Equal(t, len(CoverSizes), removed)
+
+	keys, err := store.List(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, keys, len(CoverSizes))
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	store := NewLocalBlobStore(t.TempDir())
+	ctx := context.Background()
+
+	assert.NoError(t, store.Put(ctx, "covers/a/small.jpg", []byte("a")))
+	assert.NoError(t, store.Put(ctx, "proxy/b/small.jpg", []byte("b")))
+
+	blob, err := store.Get(ctx, "covers/a/small.jpg")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(blob)
+	blob.Close()
+	assert.Equal(t, "a", string(data))
+
+	keys, err := store.List(ctx, "covers/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"covers/a/small.jpg"}, keys)
+
+	for _, key := range []string{"../escape", "/etc/passwd", "covers/../../escape", ""} {
+		assert.Error(t, store.Put(ctx, key, []byte("x")), key)
+	}
+
+	assert.NoError(t, store.Delete(ctx, "covers/a/small.jpg"))
+	assert.NoError(t, store.Delete(ctx, "covers/a/small.jpg"))
+	_, err = store.Get(ctx, "covers/a/small.jpg")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+}
@@ -0,0 +1,481 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrDuplicateCustomField is returned when the user already has a custom field with the same name
+var ErrDuplicateCustomField = errors.New("a custom field with this name already exists")
+
+// customFieldKey matches the keys custom field values are stored under. Keys
+// are used as JSON paths in queries, so nothing else may be let through.
+var customFieldKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+type CustomFieldService interface {
+	ListCustomFields(ctx context.Context, userID string) ([]models.CustomField, error)
+	CreateCustomField(ctx context.Context, userID string, field models.CustomField) (*models.CustomField, error)
+	UpdateCustomField(ctx context.Context, userID string, fieldID string, field models.CustomField) (*models.CustomField, error)
+	DeleteCustomField(ctx context.Context, userID string, fieldID string) error
+}
+
+type customFieldService struct {
+	DB *gorm.DB
+}
+
+func NewCustomFieldService(db *gorm.DB) CustomFieldService {
+	return &customFieldService{
+		DB: db,
+	}
+}
+
+// ListCustomFields returns the user's custom fields by name
+func (s *customFieldService) ListCustomFields(ctx context.Context, userID string) ([]models.CustomField, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	return findCustomFields(s.DB, user.ID)
+}
+
+// CreateCustomField defines a new field. Its key is taken from the name.
+func (s *customFieldService) CreateCustomField(ctx context.Context, userID string, field models.CustomField) (*models.CustomField, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	field.ID = 0
+	field.UserID = user.ID
+	field.Type = strings.ToLower(strings.TrimSpace(field.Type))
+	if !models.IsValidCustomFieldType(field.Type) {
+		return nil, newValidationError("invalid custom field type: %s, expected one of %s", field.Type, strings.Join(models.CustomFieldTypes, ", "))
+	}
+	if err := validateCustomField(&field); err != nil {
+		return nil, err
+	}
+	if field.Key, err = customFieldKeyFor(field.Name); err != nil {
+		return nil, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.CustomField{}).Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to count custom fields: %v", err)
+		}
+		if count >= models.MaxCustomFields {
+			return newValidationError("you can't have more than %d custom fields", models.MaxCustomFields)
+		}
+		if err := checkCustomFieldFree(tx, user.ID, field); err != nil {
+			return err
+		}
+		if err := tx.Create(&field).Error; err != nil {
+			return fmt.Errorf("failed to create custom field: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+// UpdateCustomField renames a field or changes the options of an enum field. The
+// key stays the same so existing values are kept, and the type can't be changed.
+func (s *customFieldService) UpdateCustomField(ctx context.Context, userID string, fieldID string, field models.CustomField) (*models.CustomField, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing models.CustomField
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", fieldID, user.ID).First(&existing).Error; err != nil {
+			return err
+		}
+		if fieldType := strings.ToLower(strings.TrimSpace(field.Type)); fieldType != "" && fieldType != existing.Type {
+			return newValidationError("the type of a custom field can't be changed")
+		}
+
+		field.ID = existing.ID
+		field.Key = existing.Key
+		field.Type = existing.Type
+		if err := validateCustomField(&field); err != nil {
+			return err
+		}
+		if err := checkCustomFieldFree(tx, user.ID, field); err != nil {
+			return err
+		}
+		if err := checkRemovedOptionsUnused(tx, user.ID, existing, field.Options); err != nil {
+			return err
+		}
+
+		existing.Name = field.Name
+		existing.Options = field.Options
+		if err := tx.Save(&existing).Error; err != nil {
+			return fmt.Errorf("failed to update custom field: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// DeleteCustomField removes a field along with its values on every book
+func (s *customFieldService) DeleteCustomField(ctx context.Context, userID string, fieldID string) error {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var field models.CustomField
+		if err := tx.Where("id = ? AND user_id = ?", fieldID, user.ID).First(&field).Error; err != nil {
+			return err
+		}
+		if err := checkCustomFieldUnfiltered(tx, user.ID, field); err != nil {
+			return err
+		}
+
+		var books []models.Book
+		if err := tx.Unscoped().
+			Select("id", "custom_fields").
+			Where("user_id = ? AND "+customFieldText(field.Key)+" IS NOT NULL", user.ID).
+			Find(&books).Error; err != nil {
+			return fmt.Errorf("failed to fetch books: %v", err)
+		}
+		for _, book := range books {
+			delete(book.CustomFields, field.Key)
+			if err := tx.Unscoped().Model(&book).UpdateColumn("custom_fields", book.CustomFields).Error; err != nil {
+				return fmt.Errorf("failed to remove custom field values: %v", err)
+			}
+		}
+
+		if err := tx.Delete(&field).Error; err != nil {
+			return fmt.Errorf("failed to delete custom field: %v", err)
+		}
+		return nil
+	})
+}
+
+func findCustomFields(db *gorm.DB, userID uint) ([]models.CustomField, error) {
+	fields := []models.CustomField{}
+	if err := db.Where("user_id = ?", userID).Order("LOWER(name) asc, id asc").Find(&fields).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch custom fields: %v", err)
+	}
+	return fields, nil
+}
+
+// validateCustomField trims the name and options and checks them against the field's type
+func validateCustomField(field *models.CustomField) error {
+	field.Name = strings.TrimSpace(field.Name)
+	if field.Name == "" {
+		return newValidationError("custom field name is required")
+	}
+	if len(field.Name) > models.MaxCustomFieldNameLength {
+		return newValidationError("custom field name cannot be longer than %d characters", models.MaxCustomFieldNameLength)
+	}
+
+	if field.Type != models.CustomFieldEnum {
+		if len(field.Options) > 0 {
+			return newValidationError("only enum fields have options")
+		}
+		field.Options = models.StringArray{}
+		return nil
+	}
+
+	options := models.StringArray{}
+	seen := map[string]bool{}
+	for _, option := range field.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return newValidationError("enum options cannot be empty")
+		}
+		if len(option) > models.MaxCustomFieldNameLength {
+			return newValidationError("enum options cannot be longer than %d characters", models.MaxCustomFieldNameLength)
+		}
+		if seen[strings.ToLower(option)] {
+			return newValidationError("duplicate enum option: %s", option)
+		}
+		seen[strings.ToLower(option)] = true
+		options = append(options, option)
+	}
+	if len(options) == 0 {
+		return newValidationError("enum fields need at least one option")
+	}
+	field.Options = options
+	return nil
+}
+
+// customFieldKeyFor turns a field name into its key, e.g. "Book club pick" into "book_club_pick"
+func customFieldKeyFor(name string) (string, error) {
+	var key strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if underscore && key.Len() > 0 {
+				key.WriteByte('_')
+			}
+			key.WriteRune(r)
+			underscore = false
+		} else {
+			underscore = true
+		}
+	}
+
+	k := key.String()
+	if k != "" && (k[0] < 'a' || k[0] > 'z') {
+		k = "field_" + k
+	}
+	if len(k) > 63 {
+		k = strings.TrimRight(k[:63], "_")
+	}
+	if !customFieldKey.MatchString(k) {
+		return "", newValidationError("custom field name must contain a letter or digit")
+	}
+	return k, nil
+}
+
+// checkCustomFieldFree makes sure no other field of the user has the name, ignoring
+// case, or the key
+func checkCustomFieldFree(tx *gorm.DB, userID uint, field models.CustomField) error {
+	var count int64
+	if err := tx.Model(&models.CustomField{}).
+		Where("user_id = ? AND id <> ? AND (LOWER(name) = ? OR key = ?)", userID, field.ID, strings.ToLower(field.Name), field.Key).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check custom field name: %v", err)
+	}
+	if count > 0 {
+		return ErrDuplicateCustomField
+	}
+	return nil
+}
+
+// checkRemovedOptionsUnused refuses to drop enum options that books still have
+func checkRemovedOptionsUnused(tx *gorm.DB, userID uint, field models.CustomField, options models.StringArray) error {
+	kept := map[string]bool{}
+	for _, option := range options {
+		kept[strings.ToLower(option)] = true
+	}
+	for _, option := range field.Options {
+		if kept[strings.ToLower(option)] {
+			continue
+		}
+		var count int64
+		if err := tx.Model(&models.Book{}).Unscoped().
+			Where("user_id = ? AND "+customFieldText(field.Key)+" = ?", userID, option).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check enum options: %v", err)
+		}
+		if count > 0 {
+			return newValidationError("option %q is still used by %d books", option, count)
+		}
+	}
+	return nil
+}
+
+// checkCustomFieldUnfiltered refuses to delete a field that smart shelves filter on
+func checkCustomFieldUnfiltered(tx *gorm.DB, userID uint, field models.CustomField) error {
+	var shelves []models.SmartShelf
+	if err := tx.Where("user_id = ?", userID).Order("id asc").Find(&shelves).Error; err != nil {
+		return fmt.Errorf("failed to check smart shelves: %v", err)
+	}
+	for _, shelf := range shelves {
+		for _, condition := range shelf.Filter.Conditions {
+			if condition.Field == customFieldPrefix+field.Key {
+				return newValidationError("%s is still used by smart shelf %q", field.Name, shelf.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCustomFieldValues checks a book's values against the user's field
+// definitions and returns them cleaned up. Null and empty values are dropped.
+func validateCustomFieldValues(db *gorm.DB, userID uint, values models.CustomFieldValues) (models.CustomFieldValues, error) {
+	cleaned := models.CustomFieldValues{}
+	if len(values) == 0 {
+		return cleaned, nil
+	}
+
+	fields, err := findCustomFields(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]models.CustomField, len(fields))
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+
+	for key, value := range values {
+		field, ok := byKey[key]
+		if !ok {
+			return nil, newValidationError("unknown custom field: %s", key)
+		}
+		if value == nil {
+			continue
+		}
+
+		switch field.Type {
+		case models.CustomFieldText:
+			text, ok := value.(string)
+			if !ok {
+				return nil, newValidationError("%s must be text", field.Name)
+			}
+			text = strings.TrimSpace(text)
+			if len(text) > models.MaxCustomFieldTextLength {
+				return nil, newValidationError("%s cannot be longer than %d characters", field.Name, models.MaxCustomFieldTextLength)
+			}
+			if text != "" {
+				cleaned[key] = text
+			}
+		case models.CustomFieldNumber:
+			number, ok := value.(float64)
+			if !ok {
+				return nil, newValidationError("%s must be a number", field.Name)
+			}
+			cleaned[key] = number
+		case models.CustomFieldDate:
+			text, ok := value.(string)
+			if !ok {
+				return nil, newValidationError("%s must be a date", field.Name)
+			}
+			if text = strings.TrimSpace(text); text == "" {
+				continue
+			}
+			date, err := parseCustomFieldDate(text)
+			if err != nil {
+				return nil, newValidationError("%s must be a date in YYYY-MM-DD format", field.Name)
+			}
+			cleaned[key] = date
+		case models.CustomFieldBool:
+			flag, ok := value.(bool)
+			if !ok {
+				return nil, newValidationError("%s must be true or false", field.Name)
+			}
+			cleaned[key] = flag
+		case models.CustomFieldEnum:
+			text, ok := value.(string)
+			if !ok {
+				return nil, newValidationError("%s must be one of %s", field.Name, strings.Join(field.Options, ", "))
+			}
+			if text = strings.TrimSpace(text); text == "" {
+				continue
+			}
+			option, ok := enumOption(field, text)
+			if !ok {
+				return nil, newValidationError("%s must be one of %s", field.Name, strings.Join(field.Options, ", "))
+			}
+			cleaned[key] = option
+		}
+	}
+	return cleaned, nil
+}
+
+// parseCustomFieldDate accepts a date or a full timestamp and returns the date
+func parseCustomFieldDate(value string) (string, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date.Format(time.DateOnly), nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", err
+	}
+	return date.Format(time.DateOnly), nil
+}
+
+// enumOption finds the option matching value, ignoring case
+func enumOption(field models.CustomField, value string) (string, bool) {
+	for _, option := range field.Options {
+		if strings.EqualFold(option, value) {
+			return option, true
+		}
+	}
+	return "", false
+}
+
+// customFieldText is the SQL expression for a book's value of the field as
+// text, which is NULL when the book has no value. Postgres and SQLite both
+// read JSON with ->>, and the key is safe to inline as it matches customFieldKey.
+func customFieldText(key string) string {
+	return fmt.Sprintf("custom_fields ->> '%s'", key)
+}
+
+// customFieldJSON is the SQL expression for a book's value of the field as JSON
+// text. Unlike customFieldText it reads booleans as true and false on both databases.
+func customFieldJSON(key string) string {
+	return fmt.Sprintf("CAST(custom_fields -> '%s' AS TEXT)", key)
+}
+
+// applyCustomFieldFilters adds the custom field filters of a collection query to
+// tx, checking each against the user's field definitions
+func applyCustomFieldFilters(db *gorm.DB, tx *gorm.DB, userID uint, filters []CustomFieldFilter) (*gorm.DB, error) {
+	if len(filters) == 0 {
+		return tx, nil
+	}
+
+	fields, err := findCustomFields(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]models.CustomField, len(fields))
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+
+	for _, filter := range filters {
+		field, ok := byKey[filter.Key]
+		if !ok {
+			return nil, newValidationError("unknown custom field: %s", filter.Key)
+		}
+		ranged := filter.Op == CustomFieldMin || filter.Op == CustomFieldMax
+		text := customFieldText(field.Key)
+
+		switch field.Type {
+		case models.CustomFieldText, models.CustomFieldEnum:
+			if ranged {
+				return nil, newValidationError("%s can only be filtered by value", field.Key)
+			}
+			if field.Type == models.CustomFieldEnum {
+				if _, ok := enumOption(field, filter.Value); !ok {
+					return nil, newValidationError("%s must be one of %s", field.Key, strings.Join(field.Options, ", "))
+				}
+			}
+			tx = tx.Where("LOWER("+text+") = ?", strings.ToLower(filter.Value))
+		case models.CustomFieldNumber:
+			number, err := strconv.ParseFloat(filter.Value, 64)
+			if err != nil {
+				return nil, newValidationError("invalid %s: %s", field.Key, filter.Value)
+			}
+			tx = tx.Where("CAST("+text+" AS NUMERIC) "+filter.comparison()+" ?", number)
+		case models.CustomFieldDate:
+			date, err := parseCustomFieldDate(filter.Value)
+			if err != nil {
+				return nil, newValidationError("invalid %s: %s, expected YYYY-MM-DD", field.Key, filter.Value)
+			}
+			tx = tx.Where(text+" "+filter.comparison()+" ?", date)
+		case models.CustomFieldBool:
+			flag, err := strconv.ParseBool(filter.Value)
+			if ranged || err != nil {
+				return nil, newValidationError("%s can only be filtered by true or false", field.Key)
+			}
+			// Books without a value count as false
+			if flag {
+				tx = tx.Where(customFieldJSON(field.Key) + " = 'true'")
+			} else {
+				tx = tx.Where(text + " IS NULL OR " + customFieldJSON(field.Key) + " = 'false'")
+			}
+		}
+	}
+	return tx, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomFieldDefinitions(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewCustomFieldService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	_, err := service.CreateCustomField(ctx, user.Auth0ID, models.CustomField{Name: "Mood", Type: "color"})
+	assert.True(t, IsValidationError(err))
+	_, err = service.CreateCustomField(ctx, user.Auth0ID, models.CustomField{Name: "Mood", Type: "enum"})
+	assert.True(t, IsValidationError(err))
+	_, err = service.CreateCustomField(ctx, user.Auth0ID, models.CustomField{Name: "Signed", Type: "bool", Options: models.StringArray{"yes"}})
+	assert.True(t, IsValidationError(err))
+	_, err = service.CreateCustomField(ctx, user.Auth0ID, models.CustomField{Name: " -- ", Type: "text"})
+	assert.True(t, IsValidationError(err))
+
+	club, err := service.CreateCustomField(ctx, user.Auth0ID, models.CustomField{Name: " Book Club Pick ", Type: "Enum", Options: models.StringArray{"Spring", " Fall "}})
+	assert.NoError(t, err)
+	assert.Equal(t, "book_club_pick", club.Key)
+	assert.Equal(t, models.StringArray{"Spring", "Fall"}, club.Options)
+
+	_, err = service.CreateCustomField(ctx, user.Auth0ID, models.CustomField{Name: "book club pick", Type: "text"})
+	assert.ErrorIs(t, err, ErrDuplicateCustomField)
+	_, err = service.CreateCustomField(ctx, user.Auth0ID, models.CustomField{Name: "Book-club pick", Type: "text"})
+	assert.ErrorIs(t, err, ErrDuplicateCustomField)
+
+	numbered, err := service.CreateCustomField(ctx, user.Auth0ID, models.CustomField{Name: "2nd copy", Type: "bool"})
+	assert.NoError(t, err)
+	assert.Equal(t, "field_2nd_copy", numbered.Key)
+
+	// Renaming keeps the key, and the type stays fixed
+	renamed, err := service.UpdateCustomField(ctx, user.Auth0ID, fmt.Sprintf("%d", club.ID), models.CustomField{Name: "Club read", Options: models.StringArray{"Spring", "Fall", "Winter"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Club read", renamed.Name)
+	assert.Equal(t, "book_club_pick", renamed.Key)
+	_, err = service.UpdateCustomField(ctx, user.Auth0ID, fmt.Sprintf("%d", club.ID), models.CustomField{Name: "Club read", Type: "text"})
+	assert.True(t, IsValidationError(err))
+
+	fields, err := service.ListCustomFields(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Len(t, fields, 2)
+	assert.Equal(t, "2nd copy", fields[0].Name)
+}
+
+func TestCustomFieldValues(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewCustomFieldService(db)
+	books := NewBookService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	for _, field := range []models.CustomField{
+		{Name: "Signed copy", Type: models.CustomFieldBool},
+		{Name: "Book club", Type: models.CustomFieldEnum, Options: models.StringArray{"Spring", "Fall"}},
+		{Name: "Copies", Type: models.CustomFieldNumber},
+		{Name: "Meeting", Type: models.CustomFieldDate},
+		{Name: "Location", Type: models.CustomFieldText},
+	} {
+		_, err := service.CreateCustomField(ctx, user.Auth0ID, field)
+		assert.NoError(t, err)
+	}
+
+	err := books.AddBook(ctx, user.Auth0ID, models.Book{Title: "Emma", Author: "Jane Austen", CustomFields: models.CustomFieldValues{"mood": "light"}})
+	assert.True(t, IsValidationError(err))
+	err = books.AddBook(ctx, user.Auth0ID, models.Book{Title: "Emma", Author: "Jane Austen", CustomFields: models.CustomFieldValues{"copies": "two"}})
+	assert.True(t, IsValidationError(err))
+	err = books.AddBook(ctx, user.Auth0ID, models.Book{Title: "Emma", Author: "Jane Austen", CustomFields: models.CustomFieldValues{"book_club": "Summer"}})
+	assert.True(t, IsValidationError(err))
+	err = books.AddBook(ctx, user.Auth0ID, models.Book{Title: "Emma", Author: "Jane Austen", CustomFields: models.CustomFieldValues{"meeting": "next week"}})
+	assert.True(t, IsValidationError(err))
+
+	assert.NoError(t, books.AddBook(ctx, user.Auth0ID, models.Book{Title: "Dune", Author: "Frank Herbert", CustomFields: models.CustomFieldValues{
+		"signed_copy": true, "book_club": "fall", "copies": float64(2), "meeting": "2024-10-03T19:00:00Z", "location": " Hall shelf ",
+	}}))
+	assert.NoError(t, books.AddBook(ctx, user.Auth0ID, models.Book{Title: "Emma", Author: "Jane Austen", CustomFields: models.CustomFieldValues{
+		"signed_copy": false, "book_club": "Spring", "copies": float64(1), "meeting": "2024-04-11", "location": nil,
+	}}))
+	assert.NoError(t, books.AddBook(ctx, user.Auth0ID, models.Book{Title: "Piranesi", Author: "Susanna Clarke"}))
+
+	page, err := books.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Sort: "title"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.CustomFieldValues{
+		"signed_copy": true, "book_club": "Fall", "copies": float64(2), "meeting": "2024-10-03", "location": "Hall shelf",
+	}, page.Books[0].CustomFields)
+	assert.NotContains(t, page.Books[1].CustomFields, "location")
+	assert.Empty(t, page.Books[2].CustomFields)
+
+	titles := func(filters ...CustomFieldFilter) []string {
+		page, err := books.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Sort: "title", CustomFields: filters})
+		assert.NoError(t, err)
+		names := []string{}
+		for _, book := range page.Books {
+			names = append(names, book.Title)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Dune"}, titles(CustomFieldFilter{Key: "signed_copy", Op: CustomFieldEquals, Value: "true"}))
+	assert.Equal(t, []string{"Emma", "Piranesi"}, titles(CustomFieldFilter{Key: "signed_copy", Op: CustomFieldEquals, Value: "false"}))
+	assert.Equal(t, []string{"Emma"}, titles(CustomFieldFilter{Key: "book_club", Op: CustomFieldEquals, Value: "SPRING"}))
+	assert.Equal(t, []string{"Dune"}, titles(CustomFieldFilter{Key: "copies", Op: CustomFieldMin, Value: "1.5"}))
+	assert.Equal(t, []string{"Emma"}, titles(CustomFieldFilter{Key: "meeting", Op: CustomFieldMax, Value: "2024-06-30"}))
+	assert.Equal(t, []string{"Dune"}, titles(
+		CustomFieldFilter{Key: "location", Op: CustomFieldEquals, Value: "hall shelf"},
+		CustomFieldFilter{Key: "copies", Op: CustomFieldEquals, Value: "2"},
+	))
+
+	_, err = books.QueryUserBooks(ctx, user.Auth0ID, BookQuery{CustomFields: []CustomFieldFilter{{Key: "mood", Op: CustomFieldEquals, Value: "x"}}})
+	assert.True(t, IsValidationError(err))
+	_, err = books.QueryUserBooks(ctx, user.Auth0ID, BookQuery{CustomFields: []CustomFieldFilter{{Key: "location", Op: CustomFieldMin, Value: "a"}}})
+	assert.True(t, IsValidationError(err))
+	assert.Error(t, BookQuery{CustomFields: []CustomFieldFilter{{Key: "copies') OR 1=1 --", Op: CustomFieldEquals}}}.Validate())
+
+	var buf bytes.Buffer
+	assert.NoError(t, NewExportService(db).Export(ctx, user.Auth0ID, "csv", false, &buf))
+	lines := strings.Split(buf.String(), "\n")
+	assert.True(t, strings.HasSuffix(lines[0], "custom:Book club,custom:Copies,custom:Location,custom:Meeting,custom:Signed copy"))
+	assert.Contains(t, buf.String(), "Fall,2,Hall shelf,2024-10-03,true")
+
+	// Options in use can't be dropped, and deleting a field clears its values
+	fields, err := service.ListCustomFields(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	clubID := fmt.Sprintf("%d", fields[0].ID)
+	_, err = service.UpdateCustomField(ctx, user.Auth0ID, clubID, models.CustomField{Name: "Book club", Options: models.StringArray{"Spring"}})
+	assert.True(t, IsValidationError(err))
+
+	assert.NoError(t, service.DeleteCustomField(ctx, user.Auth0ID, clubID))
+	page, err = books.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Sort: "title"})
+	assert.NoError(t, err)
+	assert.NotContains(t, page.Books[0].CustomFields, "book_club")
+	assert.Equal(t, float64(2), page.Books[0].CustomFields["copies"])
+
+	// Batch patches only change the values they name
+	result, err := NewBatchService(db).ApplyBatch(ctx, user.Auth0ID, BatchAllOrNothing, []BatchOperation{
+		{Op: BatchPatch, BookID: page.Books[0].ID, Patch: &BatchBookPatch{CustomFields: models.CustomFieldValues{"copies": float64(3), "location": nil}}},
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.Applied)
+	var dune models.Book
+	assert.NoError(t, db.First(&dune, page.Books[0].ID).Error)
+	assert.Equal(t, models.CustomFieldValues{"signed_copy": true, "copies": float64(3), "meeting": "2024-10-03"}, dune.CustomFields)
+}
@@ -0,0 +1,544 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// minDuplicateScore is the lowest similarity reported as a possible duplicate
+const minDuplicateScore = 0.8
+
+// Reasons a pair of books is reported as a possible duplicate
+const (
+	DuplicateSameISBN      = "same_isbn"
+	DuplicateSameTitle     = "same_title"
+	DuplicateSameMainTitle = "same_main_title"
+	DuplicateSimilarTitle  = "similar_title"
+)
+
+// PossibleDuplicate is a pair of the user's books that look like the same book
+type PossibleDuplicate struct {
+	Book      models.Book `json:"book"`
+	Duplicate models.Book `json:"duplicate"`
+	Score     float64     `json:"score"`
+	Reason    string      `json:"reason"`
+}
+
+type DuplicateService interface {
+	FindDuplicate(ctx context.Context, userID string, book models.Book) (*models.Book, error)
+	PossibleDuplicates(ctx context.Context, userID string) ([]PossibleDuplicate, error)
+	MergeBooks(ctx context.Context, userID string, bookID string, duplicateID string) (*models.Book, error)
+}
+
+type duplicateService struct {
+	DB *gorm.DB
+}
+
+func NewDuplicateService(db *gorm.DB) DuplicateService {
+	return &duplicateService{
+		DB: db,
+	}
+}
+
+// FindDuplicate looks for a book in the user's collection that is the same as
+// book, either by ISBN or by title and author once case, spacing and punctuation
+// are ignored. Recently deleted books are included so they can be restored
+// instead of duplicated, but a book still in the collection is preferred.
+func (s *duplicateService) FindDuplicate(ctx context.Context, userID string, book models.Book) (*models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Narrow the candidates down in the database before comparing normalized values
+	isbn := ISBN13(book.ISBN)
+	candidates := s.DB.Unscoped().
+		Select("id", "title", "author", "isbn", "deleted_at").
+		Where("user_id = ?", user.ID)
+	conditions := s.DB.Where("LOWER(title) LIKE ? ESCAPE '\\'", "%"+likeEscaper.Replace(strings.ToLower(titleSearchTerm(book.Title)))+"%")
+	if isbn != "" {
+		conditions = conditions.Or("isbn IN ?", isbnForms(isbn))
+	}
+
+	var books []models.Book
+	if err := candidates.Where(conditions).Order("deleted_at IS NOT NULL, id asc").Find(&books).Error; err != nil {
+		return nil, fmt.Errorf("failed to check existing books: %v", err)
+	}
+
+	title := NormalizeTitle(book.Title)
+	authors := authorKeys(book)
+
+	var match *models.Book
+	for i := range books {
+		candidate := &books[i]
+		if isbn != "" && ISBN13(candidate.ISBN) == isbn {
+			match = candidate
+			break
+		}
+		if match == nil && NormalizeTitle(candidate.Title) == title && sharesAuthor(authors, authorKeys(*candidate)) {
+			match = candidate
+		}
+	}
+	if match == nil {
+		return nil, nil
+	}
+
+	var found models.Book
+	if err := s.DB.Unscoped().First(&found, match.ID).Error; err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+// PossibleDuplicates returns pairs of books in the collection that are likely the
+// same book, best matches first. Only books sharing an ISBN or an author are
+// compared, so large collections stay cheap to check.
+func (s *duplicateService) PossibleDuplicates(ctx context.Context, userID string) ([]PossibleDuplicate, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var books []models.Book
+	if err := s.DB.Where("user_id = ?", user.ID).Order("id asc").Find(&books).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch books: %v", err)
+	}
+
+	groups := map[string][]int{}
+	for i, book := range books {
+		if isbn := ISBN13(book.ISBN); isbn != "" {
+			groups["isbn:"+isbn] = append(groups["isbn:"+isbn], i)
+		}
+		for author := range authorKeys(book) {
+			groups["author:"+author] = append(groups["author:"+author], i)
+		}
+	}
+
+	seen := map[[2]int]bool{}
+	duplicates := []PossibleDuplicate{}
+	for _, group := range groups {
+		for a := 0; a < len(group); a++ {
+			for b := a + 1; b < len(group); b++ {
+				pair := [2]int{group[a], group[b]}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+
+				score, reason := duplicateScore(books[pair[0]], books[pair[1]])
+				if score >= minDuplicateScore {
+					duplicates = append(duplicates, PossibleDuplicate{
+						Book:      books[pair[0]],
+						Duplicate: books[pair[1]],
+						Score:     score,
+						Reason:    reason,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Score != duplicates[j].Score {
+			return duplicates[i].Score > duplicates[j].Score
+		}
+		return duplicates[i].Book.ID < duplicates[j].Book.ID
+	})
+	return duplicates, nil
+}
+
+// duplicateScore rates how likely two books are the same, from 0 to 1
+func duplicateScore(a models.Book, b models.Book) (float64, string) {
+	if isbn := ISBN13(a.ISBN); isbn != "" && isbn == ISBN13(b.ISBN) {
+		return 1, DuplicateSameISBN
+	}
+	if !sharesAuthor(authorKeys(a), authorKeys(b)) {
+		return 0, ""
+	}
+
+	titleA, titleB := NormalizeTitle(a.Title), NormalizeTitle(b.Title)
+	if titleA == titleB {
+		return 0.95, DuplicateSameTitle
+	}
+	// Editions often only differ in their subtitle, like "Dune: Deluxe Edition"
+	if mainA, mainB := NormalizeTitle(mainTitle(a.Title)), NormalizeTitle(mainTitle(b.Title)); mainA != "" && mainA == mainB {
+		return 0.9, DuplicateSameMainTitle
+	}
+	return similarity(titleA, titleB), DuplicateSimilarTitle
+}
+
+// MergeBooks folds the duplicate into the book: its reading history, sessions,
+// notes, highlights, reviews, loans, shelves and author credits move over, details the
+// book is missing are copied from the duplicate, and the duplicate is then
+// removed for good.
+func (s *duplicateService) MergeBooks(ctx context.Context, userID string, bookID string, duplicateID string) (*models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if bookID == duplicateID {
+		return nil, newValidationError("a book cannot be merged with itself")
+	}
+
+	var book models.Book
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var duplicate models.Book
+		if err := tx.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id = ? AND user_id = ?", duplicateID, user.ID).First(&duplicate).Error; err != nil {
+			return err
+		}
+
+		// Every read of either book is counted again once they are merged
+		finished, err := bookFinishDates(tx, &book)
+		if err != nil {
+			return err
+		}
+		duplicateFinished, err := bookFinishDates(tx, &duplicate)
+		if err != nil {
+			return err
+		}
+		finished = append(finished, duplicateFinished...)
+
+		if err := mergeReadingHistory(tx, &book, duplicate); err != nil {
+			return err
+		}
+		mergeBookDetails(&book, duplicate)
+		if err := tx.Omit(clause.Associations).Save(&book).Error; err != nil {
+			return fmt.Errorf("failed to save merged book: %v", err)
+		}
+
+		if err := tx.Model(&models.ReadingSession{}).Where("book_id = ?", duplicate.ID).Update("book_id", book.ID).Error; err != nil {
+			return fmt.Errorf("failed to move reading sessions: %v", err)
+		}
+		if err := tx.Model(&models.Note{}).Where("book_id = ?", duplicate.ID).Update("book_id", book.ID).Error; err != nil {
+			return fmt.Errorf("failed to move notes: %v", err)
+		}
+		if err := tx.Model(&models.Highlight{}).Where("book_id = ?", duplicate.ID).Update("book_id", book.ID).Error; err != nil {
+			return fmt.Errorf("failed to move highlights: %v", err)
+		}
+		if err := tx.Model(&models.Review{}).Where("book_id = ?", duplicate.ID).Update("book_id", book.ID).Error; err != nil {
+			return fmt.Errorf("failed to move reviews: %v", err)
+		}
+		if err := tx.Model(&models.Loan{}).Where("book_id = ?", duplicate.ID).Update("book_id", book.ID).Error; err != nil {
+			return fmt.Errorf("failed to move loans: %v", err)
+		}
+		if err := tx.Model(&models.BookCopy{}).Where("book_id = ?", duplicate.ID).Update("book_id", book.ID).Error; err != nil {
+			return fmt.Errorf("failed to move copies: %v", err)
+		}
+
+		var shelfIDs []uint
+		if err := tx.Model(&models.BookShelf{}).Where("book_id = ?", duplicate.ID).Pluck("shelf_id", &shelfIDs).Error; err != nil {
+			return fmt.Errorf("failed to fetch shelves: %v", err)
+		}
+		for _, shelfID := range shelfIDs {
+			if err := addBooksToShelf(tx, shelfID, []uint{book.ID}); err != nil {
+				return err
+			}
+		}
+
+		var credits []models.BookAuthor
+		if err := tx.Preload("Author").Where("book_id = ?", duplicate.ID).Order("position asc").Find(&credits).Error; err != nil {
+			return fmt.Errorf("failed to fetch author credits: %v", err)
+		}
+		if err := addMissingCredits(tx, book.ID, credits); err != nil {
+			return err
+		}
+
+		if err := tx.Where("book_id = ?", duplicate.ID).Delete(&models.BookShelf{}).Error; err != nil {
+			return fmt.Errorf("failed to remove duplicate from shelves: %v", err)
+		}
+		if err := tx.Where("book_id = ?", duplicate.ID).Delete(&models.BookAuthor{}).Error; err != nil {
+			return fmt.Errorf("failed to remove duplicate credits: %v", err)
+		}
+		if err := tx.Unscoped().Delete(&duplicate).Error; err != nil {
+			return fmt.Errorf("failed to delete duplicate: %v", err)
+		}
+		if err := tx.Where("book_id = ?", duplicate.ID).Delete(&models.BookSearch{}).Error; err != nil {
+			return fmt.Errorf("failed to remove duplicate from search: %v", err)
+		}
+		// The kept book now has the duplicate's notes and highlights too
+		if err := indexBook(tx, book.ID); err != nil {
+			return err
+		}
+		return NewGoalService(tx).RecomputeGoalHistory(ctx, userID, append(finished, finishedOn(book.FinishedAt)...))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.Preload("ReadThroughs", func(db *gorm.DB) *gorm.DB {
+		return db.Order("finished_at asc, id asc")
+	}).First(&book, book.ID).Error; err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// mergeReadingHistory combines the reads of both books. When the book hasn't been
+// started the duplicate's current read takes its place, otherwise a finished
+// duplicate read is kept as a read-through. Reads finishing on the same day as
+// one the book already has are the same read logged twice and are dropped.
+func mergeReadingHistory(tx *gorm.DB, book *models.Book, duplicate models.Book) error {
+	var reads []models.ReadThrough
+	if err := tx.Where("book_id IN ?", []uint{book.ID, duplicate.ID}).Order("finished_at asc, id asc").Find(&reads).Error; err != nil {
+		return fmt.Errorf("failed to fetch read-throughs: %v", err)
+	}
+	finished := map[string]bool{}
+	if book.FinishedAt != nil {
+		finished[book.FinishedAt.Format(time.DateOnly)] = true
+	}
+	for _, read := range reads {
+		if read.BookID == book.ID && read.FinishedAt != nil {
+			finished[read.FinishedAt.Format(time.DateOnly)] = true
+		}
+	}
+
+	for _, read := range reads {
+		if read.BookID != duplicate.ID {
+			continue
+		}
+		if read.FinishedAt != nil && finished[read.FinishedAt.Format(time.DateOnly)] {
+			if err := tx.Delete(&read).Error; err != nil {
+				return fmt.Errorf("failed to drop repeated read-through: %v", err)
+			}
+			continue
+		}
+		if read.FinishedAt != nil {
+			finished[read.FinishedAt.Format(time.DateOnly)] = true
+		}
+		if err := tx.Model(&read).Update("book_id", book.ID).Error; err != nil {
+			return fmt.Errorf("failed to move read-through: %v", err)
+		}
+	}
+
+	notStarted := book.StartedAt == nil && book.FinishedAt == nil && book.Status == models.StatusWantToRead
+	switch {
+	case notStarted:
+		book.Status = duplicate.Status
+		book.StartedAt = duplicate.StartedAt
+		book.FinishedAt = duplicate.FinishedAt
+		book.StoppedAt = duplicate.StoppedAt
+		book.StoppedPage = duplicate.StoppedPage
+	case duplicate.FinishedAt != nil && !finished[duplicate.FinishedAt.Format(time.DateOnly)]:
+		read := models.ReadThrough{
+			BookID:     book.ID,
+			UserID:     book.UserID,
+			StartedAt:  duplicate.StartedAt,
+			FinishedAt: duplicate.FinishedAt,
+			Rating:     duplicate.Rating,
+		}
+		if err := tx.Create(&read).Error; err != nil {
+			return fmt.Errorf("failed to archive duplicate read: %v", err)
+		}
+	}
+	return nil
+}
+
+// mergeBookDetails fills in details the book is missing from the duplicate
+func mergeBookDetails(book *models.Book, duplicate models.Book) {
+	if book.ISBN == "" {
+		book.ISBN = duplicate.ISBN
+	}
+	if book.CoverImage == "" {
+		book.CoverImage = duplicate.CoverImage
+	}
+	if book.Rating == 0 {
+		book.Rating = duplicate.Rating
+	}
+	if book.PageCount == 0 {
+		book.PageCount = duplicate.PageCount
+	}
+	if book.Genre == "" {
+		book.Genre = duplicate.Genre
+	}
+	if book.SeriesID == nil {
+		book.SeriesID = duplicate.SeriesID
+		book.SeriesPosition = duplicate.SeriesPosition
+	}
+	for key, value := range duplicate.CustomFields {
+		if _, ok := book.CustomFields[key]; !ok {
+			if book.CustomFields == nil {
+				book.CustomFields = models.CustomFieldValues{}
+			}
+			book.CustomFields[key] = value
+		}
+	}
+}
+
+// addMissingCredits adds the credits the book doesn't already have, after its own
+func addMissingCredits(tx *gorm.DB, bookID uint, credits []models.BookAuthor) error {
+	var existing []models.BookAuthor
+	if err := tx.Where("book_id = ?", bookID).Order("position asc").Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to fetch author credits: %v", err)
+	}
+	has := map[string]bool{}
+	position := 0
+	for _, credit := range existing {
+		has[fmt.Sprintf("%d:%s", credit.AuthorID, credit.Role)] = true
+		position = credit.Position + 1
+	}
+
+	for _, credit := range credits {
+		if has[fmt.Sprintf("%d:%s", credit.AuthorID, credit.Role)] {
+			continue
+		}
+		row := models.BookAuthor{BookID: bookID, AuthorID: credit.AuthorID, Role: credit.Role, Position: position}
+		if err := tx.Omit("Author").Create(&row).Error; err != nil {
+			return fmt.Errorf("failed to add author credit: %v", err)
+		}
+		position++
+	}
+	return nil
+}
+
+// NormalizeTitle lower-cases a title and drops punctuation and extra spaces, so
+// "The Hobbit " and "the hobbit" compare equal
+func NormalizeTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '\'' || r == '’':
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// mainTitle drops a subtitle or parenthesized edition from a title
+func mainTitle(title string) string {
+	if i := strings.IndexAny(title, ":(["); i > 0 {
+		return title[:i]
+	}
+	return title
+}
+
+// ISBN13 returns the ISBN-13 form of an ISBN-10 or ISBN-13, so both editions of
+// the number compare equal. Anything else returns "".
+func ISBN13(isbn string) string {
+	isbn = NormalizeISBN(isbn)
+	if !isISBN(isbn) {
+		return ""
+	}
+	if len(isbn) == 13 {
+		return isbn
+	}
+
+	digits := "978" + isbn[:9]
+	sum := 0
+	for i, c := range digits {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(c-'0') * weight
+	}
+	return fmt.Sprintf("%s%d", digits, (10-sum%10)%10)
+}
+
+// ISBN10 returns the ISBN-10 form of an ISBN-10 or ISBN-13. Only ISBN-13s
+// starting with 978 have one, anything else returns "".
+func ISBN10(isbn string) string {
+	isbn13 := ISBN13(isbn)
+	if !strings.HasPrefix(isbn13, "978") {
+		return ""
+	}
+
+	digits := isbn13[3:12]
+	sum := 0
+	for i, c := range digits {
+		sum += int(c-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return digits + "X"
+	}
+	return fmt.Sprintf("%s%d", digits, check)
+}
+
+// isbnForms returns the ISBN-13 and, for 978 numbers, the ISBN-10 it was made from
+func isbnForms(isbn13 string) []string {
+	forms := []string{isbn13}
+	if isbn10 := ISBN10(isbn13); isbn10 != "" {
+		forms = append(forms, isbn10)
+	}
+	return forms
+}
+
+// titleSearchTerm picks the longest word of a title to look up candidates with
+func titleSearchTerm(title string) string {
+	longest := ""
+	for _, word := range strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) > len([]rune(longest)) {
+			longest = word
+		}
+	}
+	return longest
+}
+
+// authorKeys returns the normalized names of a book's authors
+func authorKeys(book models.Book) map[string]bool {
+	keys := map[string]bool{}
+	for _, name := range ParseAuthorNames(book.Author) {
+		keys[NormalizeAuthorName(name)] = true
+	}
+	for _, credit := range book.Authors {
+		if credit.Role == models.RoleAuthor {
+			keys[NormalizeAuthorName(credit.Author.Name)] = true
+		}
+	}
+	return keys
+}
+
+func sharesAuthor(a map[string]bool, b map[string]bool) bool {
+	for key := range a {
+		if b[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// similarity is one minus the edit distance between two strings, relative to the longer one
+func similarity(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return 1 - float64(previous[len(rb)])/float64(longest)
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestISBN13(t *testing.T) {
+	assert.Equal(t, "9780441172719", ISBN13("0441172717"))
+	assert.Equal(t, "9780441172719", ISBN13("978-0-441-17271-9"))
+	assert.Equal(t, "", ISBN13("sg-1234"))
+	assert.Equal(t, []string{"9780441172719", "0441172717"}, isbnForms("9780441172719"))
+}
+
+func TestFindDuplicate(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewDuplicateService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	deleted := models.Book{Title: "The Hobbit", Author: "J.R.R. Tolkien", UserID: user.ID}
+	live := models.Book{Title: "The Hobbit", Author: "J. R. R. Tolkien", UserID: user.ID}
+	dune := models.Book{Title: "Dune", Author: "Frank Herbert", ISBN: "0441172717", UserID: user.ID}
+	assert.NoError(t, db.Create(&deleted).Error)
+	assert.NoError(t, db.Delete(&deleted).Error)
+	assert.NoError(t, db.Create(&live).Error)
+	assert.NoError(t, db.Create(&dune).Error)
+
+	found, err := service.FindDuplicate(ctx, user.Auth0ID, models.Book{Title: "the hobbit ", Author: "Tolkien, J.R.R."})
+	assert.NoError(t, err)
+	assert.Equal(t, live.ID, found.ID)
+
+	found, err = service.FindDuplicate(ctx, user.Auth0ID, models.Book{Title: "The Hobbit", Author: "Someone Else"})
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	found, err = service.FindDuplicate(ctx, user.Auth0ID, models.Book{Title: "Dune (Deluxe Edition)", Author: "Herbert", ISBN: "9780441172719"})
+	assert.NoError(t, err)
+	assert.Equal(t, dune.ID, found.ID)
+
+	assert.NoError(t, db.Delete(&live).Error)
+	found, err = service.FindDuplicate(ctx, user.Auth0ID, models.Book{Title: "The Hobbit", Author: "J.R.R. Tolkien"})
+	assert.NoError(t, err)
+	assert.True(t, found.DeletedAt.Valid)
+}
+
+func TestPossibleDuplicates(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewDuplicateService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	books := []models.Book{
+		{Title: "Dune", Author: "Frank Herbert", UserID: user.ID},
+		{Title: "Dune: Deluxe Edition", Author: "Frank Herbert", UserID: user.ID},
+		{Title: "The Fellowship of the Ring", Author: "J.R.R. Tolkien", UserID: user.ID},
+		{Title: "The Felowship of the Ring", Author: "Tolkien, J. R. R.", UserID: user.ID},
+		{Title: "The Two Towers", Author: "J.R.R. Tolkien", UserID: user.ID},
+		{Title: "Dune", Author: "Someone Else", UserID: user.ID},
+	}
+	assert.NoError(t, db.Create(&books).Error)
+
+	duplicates, err := service.PossibleDuplicates(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Len(t, duplicates, 2)
+	assert.Equal(t, DuplicateSimilarTitle, duplicates[0].Reason)
+	assert.Equal(t, books[2].ID, duplicates[0].Book.ID)
+	assert.Equal(t, DuplicateSameMainTitle, duplicates[1].Reason)
+	assert.Equal(t, books[0].ID, duplicates[1].Book.ID)
+}
+
+func TestMergeBooks(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewDuplicateService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	firstRead := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+	secondRead := time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC)
+	book := models.Book{Title: "Dune", Author: "Frank Herbert", UserID: user.ID, FinishedAt: &firstRead}
+	duplicate := models.Book{Title: "Dune", Author: "Frank Herbert", ISBN: "9780441172719", PageCount: 412, UserID: user.ID, FinishedAt: &secondRead, Rating: 5}
+	assert.NoError(t, db.Create(&book).Error)
+	assert.NoError(t, db.Create(&duplicate).Error)
+
+	// A read-through of the same read logged on both books is only kept once
+	assert.NoError(t, db.Create(&models.ReadThrough{BookID: duplicate.ID, UserID: user.ID, FinishedAt: &firstRead}).Error)
+	page := uint(50)
+	assert.NoError(t, db.Create(&models.ReadingSession{BookID: duplicate.ID, UserID: user.ID, Date: secondRead, EndPage: &page}).Error)
+	shelf, err := NewShelfService(db).CreateShelf(ctx, user.Auth0ID, "Sci-fi")
+	assert.NoError(t, err)
+	assert.NoError(t, addBooksToShelf(db, shelf.ID, []uint{duplicate.ID}))
+
+	bookID, duplicateID := fmt.Sprintf("%d", book.ID), fmt.Sprintf("%d", duplicate.ID)
+	_, err = service.MergeBooks(ctx, user.Auth0ID, bookID, bookID)
+	assert.True(t, IsValidationError(err))
+
+	merged, err := service.MergeBooks(ctx, user.Auth0ID, bookID, duplicateID)
+	assert.NoError(t, err)
+	assert.Equal(t, "9780441172719", merged.ISBN)
+	assert.Equal(t, uint(412), merged.PageCount)
+	assert.Equal(t, firstRead, *merged.FinishedAt)
+	assert.Len(t, merged.ReadThroughs, 1)
+	assert.Equal(t, secondRead, *merged.ReadThroughs[0].FinishedAt)
+	assert.Equal(t, 5.0, merged.ReadThroughs[0].Rating)
+
+	var sessions []models.ReadingSession
+	assert.NoError(t, db.Where("book_id = ?", book.ID).Find(&sessions).Error)
+	assert.Len(t, sessions, 1)
+
+	var shelved int64
+	db.Model(&models.BookShelf{}).Where("book_id = ?", book.ID).Count(&shelved)
+	assert.Equal(t, int64(1), shelved)
+
+	var remaining int64
+	db.Unscoped().Model(&models.Book{}).Where("id = ?", duplicate.ID).Count(&remaining)
+	assert.Equal(t, int64(0), remaining)
+
+	// The finished reads are still counted once each
+	count, err := NewBookService(db).CountFinishedBooks(ctx, user.Auth0ID, firstRead.AddDate(0, 0, -1), secondRead.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
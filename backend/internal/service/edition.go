@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// languageCode matches ISO 639-1 and 639-2 codes, optionally with a region such as en-GB
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]{2})?$`)
+
+// publishDateLayouts are the precisions a publish date can be given in
+var publishDateLayouts = []string{"2006-01-02", "2006-01", "2006"}
+
+// normalizeEdition tidies and validates the edition details of a book, filling
+// in the ISBN-10 and ISBN-13 forms of its ISBN
+func normalizeEdition(book *models.Book) error {
+	book.Format = strings.ToLower(strings.TrimSpace(book.Format))
+	if !models.IsValidFormat(book.Format) {
+		return newValidationError("invalid format: %s", book.Format)
+	}
+
+	if err := fillISBNs(book); err != nil {
+		return err
+	}
+
+	book.Publisher = strings.TrimSpace(book.Publisher)
+	book.Narrator = strings.TrimSpace(book.Narrator)
+
+	book.PublishDate = strings.TrimSpace(book.PublishDate)
+	if book.PublishDate != "" && !isPublishDate(book.PublishDate) {
+		return newValidationError("publish date must be YYYY, YYYY-MM or YYYY-MM-DD")
+	}
+
+	book.Language = strings.ToLower(strings.TrimSpace(book.Language))
+	if book.Language != "" && !languageCode.MatchString(book.Language) {
+		return newValidationError("language must be an ISO 639 code such as en or fre")
+	}
+	return nil
+}
+
+// fillISBNs makes ISBN, ISBN10 and ISBN13 consistent. An ISBN given in any of
+// them is used for the others, with ISBN taking precedence.
+func fillISBNs(book *models.Book) error {
+	book.ISBN = NormalizeISBN(book.ISBN)
+	book.ISBN10 = NormalizeISBN(book.ISBN10)
+	book.ISBN13 = NormalizeISBN(book.ISBN13)
+
+	if book.ISBN10 != "" && (len(book.ISBN10) != 10 || !isISBN(book.ISBN10)) {
+		return newValidationError("invalid ISBN-10: %s", book.ISBN10)
+	}
+	if book.ISBN13 != "" && (len(book.ISBN13) != 13 || !isISBN(book.ISBN13)) {
+		return newValidationError("invalid ISBN-13: %s", book.ISBN13)
+	}
+
+	if book.ISBN == "" {
+		book.ISBN = book.ISBN13
+	}
+	if book.ISBN == "" {
+		book.ISBN = book.ISBN10
+	}
+	if isbn13 := ISBN13(book.ISBN); isbn13 != "" {
+		book.ISBN13 = isbn13
+		book.ISBN10 = ISBN10(isbn13)
+	}
+	return nil
+}
+
+func isPublishDate(value string) bool {
+	for _, layout := range publishDateLayouts {
+		if len(value) != len(layout) {
+			continue
+		}
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// BackfillBookISBNs fills in the ISBN-10 and ISBN-13 of books saved before
+// both forms were stored
+func BackfillBookISBNs(db *gorm.DB) error {
+	var books []models.Book
+	result := db.Unscoped().Select("id", "isbn").
+		Where("isbn <> '' AND (isbn13 IS NULL OR isbn13 = '')").
+		FindInBatches(&books, 200, func(tx *gorm.DB, _ int) error {
+			for _, book := range books {
+				isbn13 := ISBN13(book.ISBN)
+				if isbn13 == "" {
+					continue
+				}
+				if err := db.Unscoped().Model(&models.Book{}).Where("id = ?", book.ID).
+					UpdateColumns(map[string]interface{}{"isbn13": isbn13, "isbn10": ISBN10(isbn13)}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return result.Error
+	}
+	return nil
+}
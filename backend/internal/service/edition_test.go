@@ -0,0 +1,95 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeEdition(t *testing.T) {
+	t.Run("Fills both ISBN forms", func(t *testing.T) {
+		book := models.Book{ISBN: "0-618-26030-7", Format: " Paperback ", Language: "EN-gb", PublishDate: "2002-09"}
+		assert.NoError(t, normalizeEdition(&book))
+		assert.Equal(t, "0618260307", book.ISBN)
+		assert.Equal(t, "0618260307", book.ISBN10)
+		assert.Equal(t, "9780618260300", book.ISBN13)
+		assert.Equal(t, models.FormatPaperback, book.Format)
+		assert.Equal(t, "en-gb", book.Language)
+	})
+
+	t.Run("Uses an explicit ISBN-13 when no ISBN is given", func(t *testing.T) {
+		book := models.Book{ISBN13: "978-0-618-26030-0"}
+		assert.NoError(t, normalizeEdition(&book))
+		assert.Equal(t, "9780618260300", book.ISBN)
+		assert.Equal(t, "0618260307", book.ISBN10)
+	})
+
+	invalid := map[string]models.Book{
+		"Unknown Format":      {Format: "scroll"},
+		"Bad ISBN-10":         {ISBN10: "06182603AB"},
+		"ISBN-13 As ISBN-10":  {ISBN10: "9780618260300"},
+		"Unparseable Date":    {PublishDate: "Sept 2002"},
+		"Impossible Date":     {PublishDate: "2002-13"},
+		"Language Not A Code": {Language: "English"},
+	}
+	for name, book := range invalid {
+		t.Run(name, func(t *testing.T) {
+			assert.True(t, IsValidationError(normalizeEdition(&book)))
+		})
+	}
+}
+
+func TestReadingSpeed(t *testing.T) {
+	page := func(v uint) *uint { return &v }
+	percent := func(v float64) *float64 { return &v }
+
+	t.Run("Pages", func(t *testing.T) {
+		book := &models.Book{PageCount: 300}
+		speed := readingSpeed(book, []models.ReadingSession{
+			{EndPage: page(30), Minutes: 30},
+			// Picks up from the previous session's end
+			{EndPage: page(90), Minutes: 60},
+			// No time recorded, so it only moves the position
+			{StartPage: page(90), EndPage: page(120)},
+		})
+		assert.Equal(t, models.UnitPages, speed.Unit)
+		assert.Equal(t, uint(90), speed.Minutes)
+		assert.Equal(t, 60.0, *speed.PerHour)
+		assert.Equal(t, 180.0, *speed.Remaining)
+		assert.Equal(t, uint(180), *speed.MinutesRemaining)
+	})
+
+	t.Run("Audiobook In Minutes", func(t *testing.T) {
+		book := &models.Book{Format: models.FormatAudiobook, DurationMinutes: 600}
+		speed := readingSpeed(book, []models.ReadingSession{
+			{StartMinute: page(0), EndMinute: page(90), Minutes: 60},
+			// Percent progress is converted using the duration
+			{StartPercent: percent(15), EndPercent: percent(30), Minutes: 60},
+		})
+		assert.Equal(t, models.UnitMinutes, speed.Unit)
+		assert.Equal(t, 90.0, *speed.PerHour)
+		assert.Equal(t, 420.0, *speed.Remaining)
+		assert.Equal(t, uint(280), *speed.MinutesRemaining)
+	})
+
+	t.Run("Ebook In Percent", func(t *testing.T) {
+		book := &models.Book{Format: models.FormatEbook}
+		speed := readingSpeed(book, []models.ReadingSession{
+			{EndPercent: percent(10), Minutes: 30},
+			// Pages can't be converted without a page count
+			{EndPage: page(50), Minutes: 30},
+		})
+		assert.Equal(t, models.UnitPercent, speed.Unit)
+		assert.Equal(t, 20.0, *speed.PerHour)
+		assert.Equal(t, 90.0, *speed.Remaining)
+		assert.Equal(t, uint(270), *speed.MinutesRemaining)
+	})
+
+	t.Run("No Timed Sessions", func(t *testing.T) {
+		speed := readingSpeed(&models.Book{}, []models.ReadingSession{{EndPage: page(40)}})
+		assert.Nil(t, speed.PerHour)
+		assert.Nil(t, speed.Remaining)
+		assert.Nil(t, speed.MinutesRemaining)
+	})
+}
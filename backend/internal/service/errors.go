@@ -0,0 +1,26 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ValidationError is returned when a request is well-formed but its values are not
+// acceptable, so controllers can answer 400 instead of 500
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func newValidationError(format string, args ...interface{}) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// IsValidationError reports whether err (or anything it wraps) is a ValidationError
+func IsValidationError(err error) bool {
+	var validationErr *ValidationError
+	return errors.As(err, &validationErr)
+}
@@ -0,0 +1,584 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize is how many rows are loaded at a time while streaming an export
+const exportBatchSize = 200
+
+// ExportFormat describes how an export format is served
+type ExportFormat struct {
+	ContentType string
+	Extension   string
+}
+
+// exportFormats lists the supported export formats. The json export is a full
+// backup, while csv and goodreads only hold the books.
+var exportFormats = map[string]ExportFormat{
+	"json":      {ContentType: "application/json", Extension: "json"},
+	"csv":       {ContentType: "text/csv", Extension: "csv"},
+	"goodreads": {ContentType: "text/csv", Extension: "csv"},
+}
+
+// GetExportFormat returns the details of an export format, or a validation error
+// if the format isn't supported
+func GetExportFormat(format string) (ExportFormat, error) {
+	exportFormat, ok := exportFormats[format]
+	if !ok {
+		return ExportFormat{}, newValidationError("unsupported export format: %s, expected one of: csv, json, goodreads", format)
+	}
+	return exportFormat, nil
+}
+
+// goodreadsExportShelves maps reading statuses onto Goodreads exclusive shelves
+var goodreadsExportShelves = map[string]string{
+	models.StatusFinished:     "read",
+	models.StatusReading:      "currently-reading",
+	models.StatusWantToRead:   "to-read",
+	models.StatusDidNotFinish: "did-not-finish",
+	models.StatusPaused:       "paused",
+}
+
+type ExportService interface {
+	Export(ctx context.Context, userID string, format string, includeDeleted bool, w io.Writer) error
+}
+
+type exportService struct {
+	DB *gorm.DB
+}
+
+func NewExportService(db *gorm.DB) ExportService {
+	return &exportService{
+		DB: db,
+	}
+}
+
+// Export streams the user's collection to w in the given format. Books are read
+// in batches so large collections are never held in memory at once. Soft deleted
+// books are only included when includeDeleted is set.
+func (s *exportService) Export(ctx context.Context, userID string, format string, includeDeleted bool, w io.Writer) error {
+	if _, err := GetExportFormat(format); err != nil {
+		return err
+	}
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+	switch format {
+	case "json":
+		err = s.exportJSON(user, includeDeleted, out)
+	case "csv":
+		err = s.exportCSV(user, includeDeleted, out)
+	case "goodreads":
+		err = s.exportGoodreads(user, includeDeleted, out)
+	}
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// eachBook calls fn with the user's books in batches, oldest first
+func (s *exportService) eachBook(user *models.User, includeDeleted bool, fn func(books []models.Book) error) error {
+	db := s.DB
+	if includeDeleted {
+		db = db.Unscoped()
+	}
+
+	var books []models.Book
+	result := db.Preload("ReadThroughs", func(db *gorm.DB) *gorm.DB {
+		return db.Order("finished_at asc, id asc")
+	}).
+		Preload("Shelves", func(db *gorm.DB) *gorm.DB {
+			return db.Order("LOWER(name) asc")
+		}).
+		Preload("Series").
+		Preload("Authors", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position asc")
+		}).
+		Preload("Authors.Author").
+		Where("user_id = ?", user.ID).
+		FindInBatches(&books, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			return fn(books)
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to export books: %v", result.Error)
+	}
+	return nil
+}
+
+func (s *exportService) exportJSON(user *models.User, includeDeleted bool, w *bufio.Writer) error {
+	scale := user.RatingScale()
+	fmt.Fprintf(w, `{"exported_at":%q,"reading_goal":%d,"rating_scale":{"max":%g,"step":%g},"books":[`,
+		time.Now().UTC().Format(time.RFC3339), user.ReadingGoal, scale.Max, scale.Step)
+
+	books := newJSONArrayWriter(w)
+	err := s.eachBook(user, includeDeleted, func(batch []models.Book) error {
+		for _, book := range batch {
+			if err := books.write(book); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sessions, notes, highlights, reviews, loans and copies of books that aren't exported are left out too
+	w.WriteString(`],"reading_sessions":[`)
+	if err := exportJSONArray[models.ReadingSession](w, s.bookRecords(user, includeDeleted), "reading sessions"); err != nil {
+		return err
+	}
+	w.WriteString(`],"notes":[`)
+	if err := exportJSONArray[models.Note](w, s.bookRecords(user, includeDeleted), "notes"); err != nil {
+		return err
+	}
+	w.WriteString(`],"highlights":[`)
+	if err := exportJSONArray[models.Highlight](w, s.bookRecords(user, includeDeleted), "highlights"); err != nil {
+		return err
+	}
+	w.WriteString(`],"reviews":[`)
+	if err := exportJSONArray[models.Review](w, s.bookRecords(user, includeDeleted), "reviews"); err != nil {
+		return err
+	}
+	w.WriteString(`],"loans":[`)
+	if err := exportJSONArray[models.Loan](w, s.bookRecords(user, includeDeleted), "loans"); err != nil {
+		return err
+	}
+	w.WriteString(`],"copies":[`)
+	if err := exportJSONArray[models.BookCopy](w, s.bookRecords(user, includeDeleted), "copies"); err != nil {
+		return err
+	}
+
+	var histories []models.GoalHistory
+	if err := s.DB.Where("auth0_id = ?", user.Auth0ID).Order("start_date asc, id asc").Find(&histories).Error; err != nil {
+		return fmt.Errorf("failed to export goal history: %v", err)
+	}
+	var series []models.Series
+	if err := s.DB.Where("user_id = ?", user.ID).Order("id asc").Find(&series).Error; err != nil {
+		return fmt.Errorf("failed to export series: %v", err)
+	}
+	w.WriteString(`],"series":`)
+	if err := writeJSON(w, series); err != nil {
+		return err
+	}
+
+	var wishlist []models.WishlistItem
+	if err := s.DB.Where("user_id = ?", user.ID).Order("id asc").Find(&wishlist).Error; err != nil {
+		return fmt.Errorf("failed to export wishlist: %v", err)
+	}
+	w.WriteString(`,"wishlist":`)
+	if err := writeJSON(w, wishlist); err != nil {
+		return err
+	}
+
+	fields, err := findCustomFields(s.DB, user.ID)
+	if err != nil {
+		return err
+	}
+	w.WriteString(`,"custom_fields":`)
+	if err := writeJSON(w, fields); err != nil {
+		return err
+	}
+
+	var smartShelves []models.SmartShelf
+	if err := s.DB.Where("user_id = ?", user.ID).Order("id asc").Find(&smartShelves).Error; err != nil {
+		return fmt.Errorf("failed to export smart shelves: %v", err)
+	}
+	w.WriteString(`,"smart_shelves":`)
+	if err := writeJSON(w, smartShelves); err != nil {
+		return err
+	}
+
+	w.WriteString(`,"goal_history":`)
+	if err := writeJSON(w, histories); err != nil {
+		return err
+	}
+
+	var settings []models.StreakSettings
+	if err := s.DB.Where("auth0_id = ?", user.Auth0ID).Limit(1).Find(&settings).Error; err != nil {
+		return fmt.Errorf("failed to export streak settings: %v", err)
+	}
+	w.WriteString(`,"streak_settings":`)
+	if len(settings) > 0 {
+		if err := writeJSON(w, settings[0]); err != nil {
+			return err
+		}
+	} else {
+		w.WriteString("null")
+	}
+
+	_, err = w.WriteString("}\n")
+	return err
+}
+
+// bookRecords selects the user's rows of a table that belongs to books, leaving
+// out those of deleted books unless they are exported too
+func (s *exportService) bookRecords(user *models.User, includeDeleted bool) *gorm.DB {
+	query := s.DB.Where("user_id = ?", user.ID)
+	if !includeDeleted {
+		query = query.Where("book_id IN (?)", s.DB.Model(&models.Book{}).Select("id").Where("user_id = ?", user.ID))
+	}
+	return query.Order("id asc")
+}
+
+// exportJSONArray streams the rows of the query as the elements of a JSON array
+func exportJSONArray[T any](w *bufio.Writer, query *gorm.DB, what string) error {
+	array := newJSONArrayWriter(w)
+	var batch []T
+	result := query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, row := range batch {
+			if err := array.write(row); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to export %s: %v", what, result.Error)
+	}
+	return nil
+}
+
+// jsonArrayWriter writes the elements of a JSON array one at a time
+type jsonArrayWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+func newJSONArrayWriter(w *bufio.Writer) *jsonArrayWriter {
+	return &jsonArrayWriter{w: w}
+}
+
+func (a *jsonArrayWriter) write(v interface{}) error {
+	if a.count > 0 {
+		a.w.WriteByte(',')
+	}
+	a.count++
+	return writeJSON(a.w, v)
+}
+
+func writeJSON(w *bufio.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode export: %v", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+var exportCSVHeader = []string{
+	"id", "title", "author", "credits", "isbn", "genre", "shelves", "series", "series_position", "rating", "page_count", "status",
+	"format", "isbn10", "isbn13", "publisher", "publish_date", "language", "duration_minutes", "narrator",
+	"purchase_price", "purchase_currency", "purchased_at", "purchase_store",
+	"started_at", "finished_at", "stopped_at", "stopped_page", "read_count",
+	"created_at", "updated_at", "deleted_at",
+}
+
+func (s *exportService) exportCSV(user *models.User, includeDeleted bool, w *bufio.Writer) error {
+	// Each custom field gets a column after the fixed ones
+	fields, err := findCustomFields(s.DB, user.ID)
+	if err != nil {
+		return err
+	}
+	header := append([]string{}, exportCSVHeader...)
+	for _, field := range fields {
+		header = append(header, "custom:"+field.Name)
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	err = s.eachBook(user, includeDeleted, func(batch []models.Book) error {
+		for _, book := range batch {
+			record := []string{
+				strconv.FormatUint(uint64(book.ID), 10),
+				book.Title,
+				book.Author,
+				formatCredits(book.Authors),
+				book.ISBN,
+				book.Genre,
+				strings.Join(shelfNames(book), ", "),
+				seriesName(book),
+				formatExportFloatPtr(book.SeriesPosition),
+				formatExportFloat(book.Rating),
+				formatExportUint(book.PageCount),
+				book.Status,
+				book.Format,
+				book.ISBN10,
+				book.ISBN13,
+				book.Publisher,
+				book.PublishDate,
+				book.Language,
+				formatExportUint(book.DurationMinutes),
+				book.Narrator,
+				formatExportFloatPtr(book.PurchasePrice),
+				book.PurchaseCurrency,
+				formatExportTime(book.PurchasedAt, time.RFC3339),
+				book.PurchaseStore,
+				formatExportTime(book.StartedAt, time.RFC3339),
+				formatExportTime(book.FinishedAt, time.RFC3339),
+				formatExportTime(book.StoppedAt, time.RFC3339),
+				formatExportUintPtr(book.StoppedPage),
+				strconv.Itoa(readCount(book)),
+				book.CreatedAt.UTC().Format(time.RFC3339),
+				book.UpdatedAt.UTC().Format(time.RFC3339),
+				"",
+			}
+			if book.DeletedAt.Valid {
+				record[len(record)-1] = book.DeletedAt.Time.UTC().Format(time.RFC3339)
+			}
+			for _, field := range fields {
+				record = append(record, formatCustomFieldValue(book.CustomFields[field.Key]))
+			}
+			if err := out.Write(record); err != nil {
+				return err
+			}
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return err
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// goodreadsExportHeader matches the columns of a Goodreads library export, so the
+// file can be imported into Goodreads or any app that reads its format
+var goodreadsExportHeader = []string{
+	"Book Id", "Title", "Author", "Author l-f", "Additional Authors", "ISBN", "ISBN13",
+	"My Rating", "Average Rating", "Publisher", "Binding", "Number of Pages",
+	"Year Published", "Original Publication Year", "Date Read", "Date Added",
+	"Bookshelves", "Bookshelves with positions", "Exclusive Shelf", "My Review",
+	"Spoiler", "Private Notes", "Read Count", "Owned Copies",
+}
+
+// goodreadsBindings are the Goodreads names for each book format
+var goodreadsBindings = map[string]string{
+	models.FormatHardcover: "Hardcover",
+	models.FormatPaperback: "Paperback",
+	models.FormatEbook:     "Kindle Edition",
+	models.FormatAudiobook: "Audible Audio",
+}
+
+// goodreadsRatingScale is the scale of the My Rating column
+var goodreadsRatingScale = models.RatingScale{Max: 5, Step: 1}
+
+func (s *exportService) exportGoodreads(user *models.User, includeDeleted bool, w *bufio.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(goodreadsExportHeader); err != nil {
+		return err
+	}
+
+	err := s.eachBook(user, includeDeleted, func(batch []models.Book) error {
+		for _, book := range batch {
+			shelf, ok := goodreadsExportShelves[book.Status]
+			if !ok {
+				shelf = "to-read"
+			}
+
+			// Goodreads wraps ISBNs in ="..." so spreadsheets keep them as text
+			var isbn10, isbn13 string
+			if book.ISBN10 != "" {
+				isbn10 = fmt.Sprintf(`="%s"`, book.ISBN10)
+			}
+			if book.ISBN13 != "" {
+				isbn13 = fmt.Sprintf(`="%s"`, book.ISBN13)
+			}
+			switch {
+			case isbn10 == "" && len(book.ISBN) == 10:
+				isbn10 = fmt.Sprintf(`="%s"`, book.ISBN)
+			case isbn13 == "" && len(book.ISBN) == 13:
+				isbn13 = fmt.Sprintf(`="%s"`, book.ISBN)
+			}
+
+			// Goodreads only has whole star ratings out of five
+			rating := int(goodreadsRatingScale.Convert(book.Rating, user.RatingScale()))
+
+			// The exclusive shelf is listed first among the book's shelves
+			shelves := append([]string{shelf}, shelfNames(book)...)
+			positions := make([]string, len(shelves))
+			for i, name := range shelves {
+				positions[i] = fmt.Sprintf("%s (#%d)", name, i+1)
+			}
+
+			// Goodreads has a single main author, with everyone else as additional authors
+			author, additional := book.Author, []string{}
+			for i, credit := range book.Authors {
+				if i == 0 {
+					author = credit.Author.Name
+					continue
+				}
+				additional = append(additional, credit.Author.Name)
+			}
+
+			record := []string{
+				"",
+				book.Title,
+				author,
+				authorLastFirst(author),
+				strings.Join(additional, ", "),
+				isbn10,
+				isbn13,
+				strconv.Itoa(rating),
+				"",
+				book.Publisher,
+				goodreadsBindings[book.Format],
+				formatExportUint(book.PageCount),
+				publishYear(book.PublishDate),
+				"",
+				formatExportTime(book.FinishedAt, "2006/01/02"),
+				book.CreatedAt.Format("2006/01/02"),
+				strings.Join(shelves, ", "),
+				strings.Join(positions, ", "),
+				shelf,
+				"",
+				"",
+				"",
+				strconv.Itoa(readCount(book)),
+				"0",
+			}
+			if err := out.Write(record); err != nil {
+				return err
+			}
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return err
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// readCount counts the finished reads of a book, including its current read
+func readCount(book models.Book) int {
+	count := 0
+	if book.FinishedAt != nil {
+		count++
+	}
+	for _, read := range book.ReadThroughs {
+		if read.FinishedAt != nil {
+			count++
+		}
+	}
+	return count
+}
+
+func shelfNames(book models.Book) []string {
+	names := make([]string, len(book.Shelves))
+	for i, shelf := range book.Shelves {
+		names[i] = shelf.Name
+	}
+	return names
+}
+
+// formatCredits lists every credited person with their role, e.g. "Jay Rubin (translator)"
+func formatCredits(credits []models.BookAuthor) string {
+	names := make([]string, len(credits))
+	for i, credit := range credits {
+		names[i] = fmt.Sprintf("%s (%s)", credit.Author.Name, credit.Role)
+	}
+	return strings.Join(names, "; ")
+}
+
+// authorLastFirst turns "Frank Herbert" into "Herbert, Frank" for Goodreads' Author l-f column
+func authorLastFirst(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return name
+	}
+	return parts[len(parts)-1] + ", " + strings.Join(parts[:len(parts)-1], " ")
+}
+
+// publishYear is the year of a YYYY, YYYY-MM or YYYY-MM-DD publish date
+func publishYear(date string) string {
+	if len(date) < 4 {
+		return ""
+	}
+	return date[:4]
+}
+
+func seriesName(book models.Book) string {
+	if book.Series == nil {
+		return ""
+	}
+	return book.Series.Name
+}
+
+// formatCustomFieldValue writes a custom field value the way it was entered
+func formatCustomFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func formatExportTime(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(layout)
+}
+
+func formatExportFloat(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatExportFloatPtr(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+func formatExportUint(n uint) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+func formatExportUintPtr(n *uint) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*n), 10)
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportJSON(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewExportService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	finished := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	kept := models.Book{Title: "Kept", Author: "Author", UserID: user.ID, FinishedAt: &finished}
+	deleted := models.Book{Title: "Deleted", Author: "Author", UserID: user.ID}
+	assert.NoError(t, db.Create(&kept).Error)
+	assert.NoError(t, db.Create(&deleted).Error)
+	assert.NoError(t, db.Delete(&deleted).Error)
+
+	page := uint(10)
+	assert.NoError(t, db.Create(&models.ReadingSession{BookID: kept.ID, UserID: user.ID, Date: finished, EndPage: &page}).Error)
+	assert.NoError(t, db.Create(&models.ReadingSession{BookID: deleted.ID, UserID: user.ID, Date: finished, EndPage: &page}).Error)
+	assert.NoError(t, db.Create(&models.Note{BookID: kept.ID, UserID: user.ID, Text: "Loved the ending"}).Error)
+	assert.NoError(t, db.Create(&models.Highlight{BookID: deleted.ID, UserID: user.ID, Text: "A quoted line", Page: &page}).Error)
+	assert.NoError(t, db.Create(&models.GoalHistory{Auth0ID: user.Auth0ID, Interval: "yearly", Target: 1, Achieved: 1}).Error)
+	assert.NoError(t, db.Create(&models.StreakSettings{Auth0ID: user.Auth0ID, GoalInterval: "yearly", ExcludedDays: models.IntArray{0}}).Error)
+
+	var export struct {
+		Books           []models.Book           `json:"books"`
+		ReadingSessions []models.ReadingSession `json:"reading_sessions"`
+		Notes           []models.Note           `json:"notes"`
+		Highlights      []models.Highlight      `json:"highlights"`
+		GoalHistory     []models.GoalHistory    `json:"goal_history"`
+		StreakSettings  *models.StreakSettings  `json:"streak_settings"`
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, service.Export(ctx, user.Auth0ID, "json", false, &buf))
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &export))
+	assert.Len(t, export.Books, 1)
+	assert.Equal(t, "Kept", export.Books[0].Title)
+	assert.Len(t, export.ReadingSessions, 1)
+	assert.Len(t, export.Notes, 1)
+	assert.Equal(t, "Loved the ending", export.Notes[0].Text)
+	assert.Empty(t, export.Highlights)
+	assert.Len(t, export.GoalHistory, 1)
+	assert.NotNil(t, export.StreakSettings)
+	assert.Equal(t, models.IntArray{0}, export.StreakSettings.ExcludedDays)
+
+	buf.Reset()
+	assert.NoError(t, service.Export(ctx, user.Auth0ID, "json", true, &buf))
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &export))
+	assert.Len(t, export.Books, 2)
+	assert.Len(t, export.ReadingSessions, 2)
+	assert.Len(t, export.Highlights, 1)
+
+	err := service.Export(ctx, user.Auth0ID, "xml", false, &buf)
+	assert.True(t, IsValidationError(err))
+}
+
+func TestExportCSVStreamsInBatches(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewExportService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	books := make([]models.Book, exportBatchSize+5)
+	for i := range books {
+		books[i] = models.Book{Title: "Book", Author: "Author", UserID: user.ID}
+	}
+	assert.NoError(t, db.Create(&books).Error)
+
+	var buf bytes.Buffer
+	assert.NoError(t, service.Export(ctx, user.Auth0ID, "csv", false, &buf))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, exportCSVHeader, records[0])
+	assert.Len(t, records, len(books)+1)
+}
+
+func TestExportGoodreadsRoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewExportService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	finished := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	books := []models.Book{
+		{Title: "Dune", Author: "Frank Herbert", ISBN: "9780441172719", Rating: 4.6, PageCount: 412, UserID: user.ID, FinishedAt: &finished},
+		{Title: "Emma", Author: "Jane Austen", UserID: user.ID, Status: models.StatusDidNotFinish},
+	}
+	assert.NoError(t, db.Create(&books).Error)
+
+	var buf bytes.Buffer
+	assert.NoError(t, service.Export(ctx, user.Auth0ID, "goodreads", false, &buf))
+
+	importer, ok := DefaultImporterRegistry().Detect(buf.Bytes())
+	assert.True(t, ok)
+	assert.Equal(t, "goodreads", importer.Format())
+
+	rows, err := importer.Parse(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, "9780441172719", rows[0].Book.ISBN)
+	assert.Equal(t, 5.0, rows[0].Book.Rating)
+	assert.Equal(t, uint(412), rows[0].Book.PageCount)
+	assert.Equal(t, models.StatusFinished, rows[0].Book.Status)
+	assert.Equal(t, finished, *rows[0].Book.FinishedAt)
+	assert.Equal(t, models.StatusDidNotFinish, rows[1].Book.Status)
+
+	// Ratings on a larger scale are brought down to five stars
+	_, err = NewBookService(db).UpdateRatingScale(ctx, user.Auth0ID, models.RatingScale{Max: 10, Step: 1})
+	assert.NoError(t, err)
+	buf.Reset()
+	assert.NoError(t, service.Export(ctx, user.Auth0ID, "goodreads", false, &buf))
+	rows, err = importer.Parse(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, rows[0].Book.Rating)
+}
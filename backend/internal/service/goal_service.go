@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// DefaultGoalInterval is used for users who never chose a goal interval
+const DefaultGoalInterval = "yearly"
+
+// What a rebuild does with each goal history row
+const (
+	GoalHistoryCreated = "created"
+	GoalHistoryUpdated = "updated"
+	GoalHistoryRemoved = "removed"
+)
+
+// GoalHistoryChange is a single difference between the stored goal history and
+// the one rebuilt from the user's books. Before is missing for created rows and
+// After for removed ones.
+type GoalHistoryChange struct {
+	Action string              `json:"action"`
+	Before *models.GoalHistory `json:"before,omitempty"`
+	After  *models.GoalHistory `json:"after,omitempty"`
+}
+
+// GoalHistoryRebuild describes the outcome of a rebuild. In a dry run the
+// changes are only reported and nothing is saved.
+type GoalHistoryRebuild struct {
+	DryRun    bool                `json:"dry_run"`
+	Interval  string              `json:"interval"`
+	Target    int                 `json:"target"`
+	Created   int                 `json:"created"`
+	Updated   int                 `json:"updated"`
+	Removed   int                 `json:"removed"`
+	Unchanged int                 `json:"unchanged"`
+	Changes   []GoalHistoryChange `json:"changes"`
+}
+
+func (r *GoalHistoryRebuild) add(action string, before *models.GoalHistory, after *models.GoalHistory) {
+	switch action {
+	case GoalHistoryCreated:
+		r.Created++
+	case GoalHistoryUpdated:
+		r.Updated++
+	case GoalHistoryRemoved:
+		r.Removed++
+	}
+	r.Changes = append(r.Changes, GoalHistoryChange{Action: action, Before: before, After: after})
+}
+
+type GoalService interface {
+	RecomputeGoalHistory(ctx context.Context, userID string, dates []time.Time) error
+	RebuildGoalHistory(ctx context.Context, userID string, dryRun bool) (*GoalHistoryRebuild, error)
+}
+
+type goalService struct {
+	DB *gorm.DB
+}
+
+func NewGoalService(db *gorm.DB) GoalService {
+	return &goalService{
+		DB: db,
+	}
+}
+
+// RecomputeGoalHistory brings the user's goal history for the intervals
+// containing the dates back in line with their books
+func (s *goalService) RecomputeGoalHistory(ctx context.Context, userID string, dates []time.Time) error {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		return recomputeGoalIntervals(tx, user, dates)
+	})
+}
+
+// RebuildGoalHistory replaces the user's goal history with one row for every
+// period they finished a read in, using their current goal and interval. Rows
+// of other intervals, duplicates and periods without finished reads are removed.
+func (s *goalService) RebuildGoalHistory(ctx context.Context, userID string, dryRun bool) (*GoalHistoryRebuild, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rebuild *GoalHistoryRebuild
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		interval, err := goalInterval(tx, user.Auth0ID)
+		if err != nil {
+			return err
+		}
+		rebuild = &GoalHistoryRebuild{DryRun: dryRun, Interval: interval, Target: user.ReadingGoal, Changes: []GoalHistoryChange{}}
+
+		finished, err := finishDates(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		achieved := map[time.Time]int{}
+		starts := []time.Time{}
+		for _, date := range finished {
+			start := goalIntervalStart(date, interval)
+			if achieved[start] == 0 {
+				starts = append(starts, start)
+			}
+			achieved[start]++
+		}
+		sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+		var existing []models.GoalHistory
+		if err := tx.Where("auth0_id = ?", user.Auth0ID).Order("start_date asc, id asc").Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to fetch goal history: %v", err)
+		}
+
+		// Each period keeps the first stored row that falls in it, if there is one
+		matched := map[uint]bool{}
+		rebuilt := make([]models.GoalHistory, 0, len(starts))
+		for _, start := range starts {
+			end := NextGoalIntervalStart(start, interval)
+			history := models.GoalHistory{
+				Auth0ID:      user.Auth0ID,
+				Interval:     interval,
+				Target:       user.ReadingGoal,
+				Achieved:     achieved[start],
+				StartDate:    start,
+				EndDate:      goalIntervalEnd(start, interval),
+				WasCompleted: user.ReadingGoal > 0 && achieved[start] >= user.ReadingGoal,
+			}
+			rebuilt = append(rebuilt, history)
+
+			var before *models.GoalHistory
+			for i := range existing {
+				row := &existing[i]
+				if !matched[row.ID] && row.Interval == interval && !row.StartDate.Before(start) && row.StartDate.Before(end) {
+					matched[row.ID] = true
+					before = row
+					break
+				}
+			}
+			switch {
+			case before == nil:
+				rebuild.add(GoalHistoryCreated, nil, &rebuilt[len(rebuilt)-1])
+			case sameGoalHistory(*before, history):
+				rebuild.Unchanged++
+			default:
+				rebuild.add(GoalHistoryUpdated, before, &rebuilt[len(rebuilt)-1])
+			}
+		}
+		for i := range existing {
+			if !matched[existing[i].ID] {
+				rebuild.add(GoalHistoryRemoved, &existing[i], nil)
+			}
+		}
+
+		if dryRun {
+			return nil
+		}
+		if err := tx.Where("auth0_id = ?", user.Auth0ID).Delete(&models.GoalHistory{}).Error; err != nil {
+			return fmt.Errorf("failed to clear goal history: %v", err)
+		}
+		if len(rebuilt) > 0 {
+			if err := tx.Create(&rebuilt).Error; err != nil {
+				return fmt.Errorf("failed to record goal history: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rebuild, nil
+}
+
+// sameGoalHistory reports whether a stored row already says what the rebuilt one does
+func sameGoalHistory(stored models.GoalHistory, rebuilt models.GoalHistory) bool {
+	return stored.Interval == rebuilt.Interval &&
+		stored.Target == rebuilt.Target &&
+		stored.Achieved == rebuilt.Achieved &&
+		stored.WasCompleted == rebuilt.WasCompleted &&
+		stored.StartDate.Equal(rebuilt.StartDate) &&
+		stored.EndDate.Equal(rebuilt.EndDate)
+}
+
+// recomputeGoalIntervals rewrites the goal history of every interval that one of
+// the finish dates falls in, counting the reads the user finished in it against
+// their current goal. Each interval keeps a single row, and loses it once
+// nothing finished in it is left.
+func recomputeGoalIntervals(tx *gorm.DB, user *models.User, dates []time.Time) error {
+	if len(dates) == 0 {
+		return nil
+	}
+
+	interval, err := goalInterval(tx, user.Auth0ID)
+	if err != nil {
+		return err
+	}
+
+	seen := map[time.Time]bool{}
+	for _, date := range dates {
+		start := goalIntervalStart(date, interval)
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+
+		end := NextGoalIntervalStart(start, interval)
+		achieved, err := countFinishedReads(tx, user.ID, start, end)
+		if err != nil {
+			return err
+		}
+
+		// Interval is a keyword in Postgres, so it is matched through a map which gets quoted
+		if err := tx.Where(map[string]interface{}{"auth0_id": user.Auth0ID, "interval": interval}).
+			Where("start_date >= ? AND start_date < ?", start, end).
+			Delete(&models.GoalHistory{}).Error; err != nil {
+			return fmt.Errorf("failed to clear goal history: %v", err)
+		}
+		if achieved == 0 {
+			continue
+		}
+
+		history := models.GoalHistory{
+			Auth0ID:      user.Auth0ID,
+			Interval:     interval,
+			Target:       user.ReadingGoal,
+			Achieved:     int(achieved),
+			StartDate:    start,
+			EndDate:      goalIntervalEnd(start, interval),
+			WasCompleted: user.ReadingGoal > 0 && int(achieved) >= user.ReadingGoal,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("failed to record goal history: %v", err)
+		}
+	}
+	return nil
+}
+
+// bookFinishDates returns the dates every read of the book was finished on, the
+// current read's and those of its earlier read-throughs
+func bookFinishDates(tx *gorm.DB, book *models.Book) ([]time.Time, error) {
+	var reads []models.ReadThrough
+	if err := tx.Select("finished_at").Where("book_id = ? AND finished_at IS NOT NULL", book.ID).Find(&reads).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch read-throughs: %v", err)
+	}
+
+	dates := finishedOn(book.FinishedAt)
+	for _, read := range reads {
+		dates = append(dates, finishedOn(read.FinishedAt)...)
+	}
+	return dates, nil
+}
+
+// finishedOn returns the finish dates that are set, so callers can pass the
+// finish dates of a book from before and after a change as they are
+func finishedOn(dates ...*time.Time) []time.Time {
+	finished := []time.Time{}
+	for _, date := range dates {
+		if date != nil {
+			finished = append(finished, *date)
+		}
+	}
+	return finished
+}
+
+// finishDates returns the finish date of every read of the user's non-deleted
+// books, re-reads included
+func finishDates(tx *gorm.DB, userID uint) ([]time.Time, error) {
+	var books []time.Time
+	if err := tx.Model(&models.Book{}).
+		Where("user_id = ? AND finished_at IS NOT NULL", userID).
+		Pluck("finished_at", &books).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch finished books: %v", err)
+	}
+
+	var rereads []time.Time
+	if err := tx.Model(&models.ReadThrough{}).
+		Joins("JOIN books ON books.id = read_throughs.book_id AND books.deleted_at IS NULL").
+		Where("read_throughs.user_id = ? AND read_throughs.finished_at IS NOT NULL", userID).
+		Pluck("read_throughs.finished_at", &rereads).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch finished read-throughs: %v", err)
+	}
+
+	return append(books, rereads...), nil
+}
+
+// goalInterval returns the interval the user measures their reading goal over
+func goalInterval(tx *gorm.DB, auth0ID string) (string, error) {
+	var settings []models.StreakSettings
+	if err := tx.Where("auth0_id = ?", auth0ID).Limit(1).Find(&settings).Error; err != nil {
+		return "", fmt.Errorf("failed to fetch streak settings: %v", err)
+	}
+	if len(settings) == 0 || settings[0].GoalInterval == "" {
+		return DefaultGoalInterval, nil
+	}
+	return settings[0].GoalInterval, nil
+}
+
+// countFinishedReads counts the reads of non-deleted books the user finished
+// from start up to but not including end, with every completed read-through of
+// a re-read book counting as another finished book
+func countFinishedReads(tx *gorm.DB, userID uint, start time.Time, end time.Time) (int64, error) {
+	var books int64
+	if err := tx.Model(&models.Book{}).
+		Where("user_id = ? AND finished_at IS NOT NULL AND finished_at >= ? AND finished_at < ?", userID, start, end).
+		Count(&books).Error; err != nil {
+		return 0, fmt.Errorf("failed to count finished books: %v", err)
+	}
+
+	var rereads int64
+	if err := tx.Model(&models.ReadThrough{}).
+		Joins("JOIN books ON books.id = read_throughs.book_id AND books.deleted_at IS NULL").
+		Where("read_throughs.user_id = ? AND read_throughs.finished_at IS NOT NULL AND read_throughs.finished_at >= ? AND read_throughs.finished_at < ?", userID, start, end).
+		Count(&rereads).Error; err != nil {
+		return 0, fmt.Errorf("failed to count finished read-throughs: %v", err)
+	}
+
+	return books + rereads, nil
+}
+
+// goalIntervalStart returns the start of the interval containing date, in UTC.
+// Weeks start on Sunday, and unknown intervals are treated as yearly.
+func goalIntervalStart(date time.Time, interval string) time.Time {
+	date = date.UTC()
+	switch interval {
+	case "daily":
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	case "weekly":
+		day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -int(day.Weekday()))
+	case "monthly":
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(date.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// goalIntervalEnd returns the last day of the interval starting at start
+func goalIntervalEnd(start time.Time, interval string) time.Time {
+	return NextGoalIntervalStart(start, interval).AddDate(0, 0, -1)
+}
+
+// NextGoalIntervalStart returns the start of the interval after the one starting at start
+func NextGoalIntervalStart(start time.Time, interval string) time.Time {
+	switch interval {
+	case "daily":
+		return start.AddDate(0, 0, 1)
+	case "weekly":
+		return start.AddDate(0, 0, 7)
+	case "monthly":
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(1, 0, 0)
+	}
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// goalRows returns the user's goal history in interval order
+func goalRows(t *testing.T, service BookService, auth0ID string) []models.GoalHistory {
+	var histories []models.GoalHistory
+	assert.NoError(t, service.GetDB().Where("auth0_id = ?", auth0ID).Order("start_date asc").Find(&histories).Error)
+	return histories
+}
+
+func TestGoalHistoryFollowsBooks(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBookService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+	assert.NoError(t, service.UpdateReadingGoal(ctx, user.Auth0ID, 2))
+
+	march := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	lastYear := time.Date(2023, 11, 5, 12, 0, 0, 0, time.UTC)
+
+	// Unfinished books leave the goal history alone
+	assert.NoError(t, service.AddBook(ctx, user.Auth0ID, models.Book{Title: "Emma", Author: "Jane Austen"}))
+	assert.Empty(t, goalRows(t, service, user.Auth0ID))
+
+	// Finishing books fills in a single row for the year
+	assert.NoError(t, service.AddBook(ctx, user.Auth0ID, models.Book{Title: "Dune", Author: "Frank Herbert", FinishedAt: &march}))
+	histories := goalRows(t, service, user.Auth0ID)
+	if assert.Len(t, histories, 1) {
+		assert.Equal(t, DefaultGoalInterval, histories[0].Interval)
+		assert.Equal(t, 1, histories[0].Achieved)
+		assert.Equal(t, 2, histories[0].Target)
+		assert.False(t, histories[0].WasCompleted)
+		assert.True(t, histories[0].StartDate.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+		assert.True(t, histories[0].EndDate.Equal(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)))
+	}
+	assert.NoError(t, service.AddBook(ctx, user.Auth0ID, models.Book{Title: "Piranesi", Author: "Susanna Clarke", FinishedAt: &june}))
+	histories = goalRows(t, service, user.Auth0ID)
+	if assert.Len(t, histories, 1) {
+		assert.Equal(t, 2, histories[0].Achieved)
+		assert.True(t, histories[0].WasCompleted)
+	}
+
+	// Moving a finish date into another year corrects both years
+	var piranesi models.Book
+	assert.NoError(t, db.Where("title = ?", "Piranesi").First(&piranesi).Error)
+	piranesiID := fmt.Sprintf("%d", piranesi.ID)
+	finishedAt := &lastYear
+	assert.NoError(t, service.UpdateBook(ctx, user.Auth0ID, piranesiID, BookPatch{FinishedAt: &finishedAt}))
+	histories = goalRows(t, service, user.Auth0ID)
+	if assert.Len(t, histories, 2) {
+		assert.Equal(t, 2023, histories[0].StartDate.Year())
+		assert.Equal(t, 1, histories[0].Achieved)
+		assert.Equal(t, 1, histories[1].Achieved)
+		assert.False(t, histories[1].WasCompleted)
+	}
+
+	// Un-finishing a book drops the year it no longer counts towards
+	finishedAt = nil
+	assert.NoError(t, service.UpdateBook(ctx, user.Auth0ID, piranesiID, BookPatch{FinishedAt: &finishedAt}))
+	histories = goalRows(t, service, user.Auth0ID)
+	if assert.Len(t, histories, 1) {
+		assert.Equal(t, 2024, histories[0].StartDate.Year())
+	}
+
+	// Deleted books stop counting until they are restored
+	var dune models.Book
+	assert.NoError(t, db.Where("title = ?", "Dune").First(&dune).Error)
+	assert.NoError(t, service.DeleteBook(ctx, user.Auth0ID, dune.ID))
+	assert.Empty(t, goalRows(t, service, user.Auth0ID))
+	assert.NoError(t, service.RestoreBook(ctx, fmt.Sprintf("%d", dune.ID)))
+	assert.Len(t, goalRows(t, service, user.Auth0ID), 1)
+
+	// Batch edits and deleting a re-read are recomputed too
+	reread := models.ReadThrough{BookID: dune.ID, UserID: user.ID, FinishedAt: &june}
+	assert.NoError(t, db.Create(&reread).Error)
+	assert.NoError(t, NewGoalService(db).RecomputeGoalHistory(ctx, user.Auth0ID, []time.Time{june}))
+	histories = goalRows(t, service, user.Auth0ID)
+	if assert.Len(t, histories, 1) {
+		assert.Equal(t, 2, histories[0].Achieved)
+	}
+	assert.NoError(t, NewReadThroughService(db).DeleteReadThrough(ctx, user.Auth0ID, fmt.Sprintf("%d", dune.ID), fmt.Sprintf("%d", reread.ID)))
+	histories = goalRows(t, service, user.Auth0ID)
+	if assert.Len(t, histories, 1) {
+		assert.Equal(t, 1, histories[0].Achieved)
+	}
+
+	status := models.StatusWantToRead
+	result, err := NewBatchService(db).ApplyBatch(ctx, user.Auth0ID, BatchAllOrNothing, []BatchOperation{
+		{Op: BatchPatch, BookID: dune.ID, Patch: &BatchBookPatch{Status: &status}},
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.Applied)
+	assert.Empty(t, goalRows(t, service, user.Auth0ID))
+}
+
+func TestGoalHistoryIntervals(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBookService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+	assert.NoError(t, db.Create(&models.StreakSettings{Auth0ID: user.Auth0ID, GoalInterval: "monthly"}).Error)
+
+	first := time.Date(2024, 2, 3, 9, 0, 0, 0, time.UTC)
+	second := time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC)
+	third := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, finished := range []time.Time{first, second, third} {
+		assert.NoError(t, service.AddBook(ctx, user.Auth0ID, models.Book{Title: fmt.Sprintf("Book %d", i), Author: "Author", FinishedAt: &finished}))
+	}
+
+	histories := goalRows(t, service, user.Auth0ID)
+	if assert.Len(t, histories, 2) {
+		assert.Equal(t, "monthly", histories[0].Interval)
+		assert.Equal(t, 2, histories[0].Achieved)
+		assert.Equal(t, 1, histories[1].Achieved)
+		// Without a reading goal there is nothing to complete
+		assert.False(t, histories[0].WasCompleted)
+	}
+
+	assert.True(t, goalIntervalStart(time.Date(2024, 6, 12, 15, 0, 0, 0, time.UTC), "weekly").Equal(time.Date(2024, 6, 9, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, NextGoalIntervalStart(time.Date(2024, 6, 9, 0, 0, 0, 0, time.UTC), "weekly").Equal(time.Date(2024, 6, 16, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, goalIntervalStart(time.Date(2024, 6, 12, 15, 0, 0, 0, time.UTC), "daily").Equal(time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestRebuildGoalHistory(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBookService(db)
+	goals := NewGoalService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	march := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, service.AddBook(ctx, user.Auth0ID, models.Book{Title: "Dune", Author: "Frank Herbert", FinishedAt: &march}))
+	assert.NoError(t, service.AddBook(ctx, user.Auth0ID, models.Book{Title: "Emma", Author: "Jane Austen", FinishedAt: &june}))
+
+	// Rows left behind by the old controller: a duplicate for the year, a stale
+	// goal and a weekly row from when the user tracked weeks
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stale := []models.GoalHistory{
+		{Auth0ID: user.Auth0ID, Interval: "yearly", Target: 10, Achieved: 1, StartDate: start, EndDate: start.AddDate(1, 0, 0)},
+		{Auth0ID: user.Auth0ID, Interval: "weekly", Target: 1, Achieved: 1, StartDate: march, EndDate: march.AddDate(0, 0, 7)},
+	}
+	assert.NoError(t, db.Create(&stale).Error)
+	assert.NoError(t, service.UpdateReadingGoal(ctx, user.Auth0ID, 2))
+
+	// A dry run reports the differences without touching the rows
+	rebuild, err := goals.RebuildGoalHistory(ctx, user.Auth0ID, true)
+	assert.NoError(t, err)
+	assert.True(t, rebuild.DryRun)
+	assert.Equal(t, DefaultGoalInterval, rebuild.Interval)
+	assert.Equal(t, 1, rebuild.Updated)
+	assert.Equal(t, 2, rebuild.Removed)
+	assert.Zero(t, rebuild.Created)
+	assert.Len(t, rebuild.Changes, 3)
+	assert.Len(t, goalRows(t, service, user.Auth0ID), 3)
+
+	rebuild, err = goals.RebuildGoalHistory(ctx, user.Auth0ID, false)
+	assert.NoError(t, err)
+	assert.False(t, rebuild.DryRun)
+	histories := goalRows(t, service, user.Auth0ID)
+	if assert.Len(t, histories, 1) {
+		assert.Equal(t, "yearly", histories[0].Interval)
+		assert.Equal(t, 2, histories[0].Target)
+		assert.Equal(t, 2, histories[0].Achieved)
+		assert.True(t, histories[0].WasCompleted)
+		assert.True(t, start.Equal(histories[0].StartDate))
+	}
+
+	// Rebuilding again has nothing left to change
+	rebuild, err = goals.RebuildGoalHistory(ctx, user.Auth0ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rebuild.Unchanged)
+	assert.Empty(t, rebuild.Changes)
+
+	// Switching to monthly goals splits the history into one row per month
+	assert.NoError(t, db.Create(&models.StreakSettings{Auth0ID: user.Auth0ID, GoalInterval: "monthly"}).Error)
+	rebuild, err = goals.RebuildGoalHistory(ctx, user.Auth0ID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rebuild.Created)
+	assert.Equal(t, 1, rebuild.Removed)
+	histories = goalRows(t, service, user.Auth0ID)
+	if assert.Len(t, histories, 2) {
+		assert.Equal(t, "monthly", histories[0].Interval)
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), histories[0].StartDate.UTC())
+		assert.Equal(t, time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), histories[1].EndDate.UTC())
+	}
+}
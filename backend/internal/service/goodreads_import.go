@@ -0,0 +1,194 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+)
+
+// goodreadsShelves maps Goodreads exclusive shelves onto reading statuses. Custom
+// exclusive shelves that aren't listed here are treated as to-read.
+var goodreadsShelves = map[string]string{
+	"read":              models.StatusFinished,
+	"currently-reading": models.StatusReading,
+	"to-read":           models.StatusWantToRead,
+	"did-not-finish":    models.StatusDidNotFinish,
+	"dnf":               models.StatusDidNotFinish,
+	"abandoned":         models.StatusDidNotFinish,
+	"paused":            models.StatusPaused,
+	"on-hold":           models.StatusPaused,
+}
+
+// csvTable is a parsed CSV file with case-insensitive access to columns by header name
+type csvTable struct {
+	columns map[string]int
+	records [][]string
+}
+
+func readCSVTable(r io.Reader, comma rune) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, newValidationError("failed to read header: %v", err)
+	}
+
+	table := &csvTable{columns: make(map[string]int, len(header))}
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		table.columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	table.records, err = reader.ReadAll()
+	if err != nil {
+		return nil, newValidationError("failed to read file: %v", err)
+	}
+	return table, nil
+}
+
+func (t *csvTable) has(column string) bool {
+	_, ok := t.columns[strings.ToLower(column)]
+	return ok
+}
+
+// get returns the trimmed value of a column, or "" when the column or field is missing
+func (t *csvTable) get(record []string, column string) string {
+	i, ok := t.columns[strings.ToLower(column)]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// parseImportDate accepts the date layouts used by the supported export formats
+func parseImportDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{"2006/01/02", "2006-01-02", "2006/1/2", time.RFC3339, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date: %s", value)
+}
+
+func parseImportUint(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", value)
+	}
+	return uint(n), nil
+}
+
+func parseImportFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", value)
+	}
+	return f, nil
+}
+
+// cleanGoodreadsISBN removes the ="..." wrapping Goodreads uses to stop spreadsheets mangling ISBNs
+func cleanGoodreadsISBN(value string) string {
+	value = strings.TrimPrefix(value, "=")
+	return NormalizeISBN(strings.Trim(value, `"`))
+}
+
+// goodreadsImporter reads a Goodreads library export
+type goodreadsImporter struct{}
+
+func (goodreadsImporter) Format() string {
+	return "goodreads"
+}
+
+func (goodreadsImporter) Detect(sample []byte) bool {
+	return hasColumns(headerColumns(sample, ","), "Title", "Author", "My Rating", "Exclusive Shelf")
+}
+
+func (goodreadsImporter) Parse(r io.Reader) ([]ImportRow, error) {
+	table, err := readCSVTable(r, ',')
+	if err != nil {
+		return nil, err
+	}
+	if !table.has("Title") || !table.has("Author") {
+		return nil, newValidationError("not a Goodreads export: Title and Author columns are required")
+	}
+
+	rows := make([]ImportRow, 0, len(table.records))
+	for i, record := range table.records {
+		row := ImportRow{Line: i + 2}
+		row.Book, row.Err = goodreadsRecordToBook(table, record)
+
+		// Non-exclusive shelves are plain tags
+		exclusive := strings.ToLower(table.get(record, "Exclusive Shelf"))
+		for _, shelf := range splitImportList(table.get(record, "Bookshelves"), ",") {
+			if strings.ToLower(shelf) != exclusive {
+				row.Tags = append(row.Tags, shelf)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func goodreadsRecordToBook(table *csvTable, record []string) (models.Book, error) {
+	book := models.Book{
+		Title:  table.get(record, "Title"),
+		Author: table.get(record, "Author"),
+		ISBN:   cleanGoodreadsISBN(table.get(record, "ISBN13")),
+	}
+	if book.ISBN == "" {
+		book.ISBN = cleanGoodreadsISBN(table.get(record, "ISBN"))
+	}
+	book.Publisher = table.get(record, "Publisher")
+	book.Format = importFormat(table.get(record, "Binding"))
+	book.PublishDate = table.get(record, "Year Published")
+	if additional := splitImportList(table.get(record, "Additional Authors"), ","); len(additional) > 0 {
+		for _, name := range append([]string{book.Author}, additional...) {
+			book.Authors = append(book.Authors, models.BookAuthor{Role: models.RoleAuthor, Author: models.Author{Name: name}})
+		}
+	}
+
+	var err error
+	if book.Rating, err = parseImportFloat(table.get(record, "My Rating")); err != nil {
+		return book, fmt.Errorf("My Rating: %v", err)
+	}
+	if book.PageCount, err = parseImportUint(table.get(record, "Number of Pages")); err != nil {
+		return book, fmt.Errorf("Number of Pages: %v", err)
+	}
+	if book.FinishedAt, err = parseImportDate(table.get(record, "Date Read")); err != nil {
+		return book, fmt.Errorf("Date Read: %v", err)
+	}
+	added, err := parseImportDate(table.get(record, "Date Added"))
+	if err != nil {
+		return book, fmt.Errorf("Date Added: %v", err)
+	}
+	if added != nil {
+		book.CreatedAt = *added
+	}
+
+	// Goodreads has no start dates, so the status comes from the shelf alone
+	book.Status = models.StatusWantToRead
+	if status, ok := goodreadsShelves[strings.ToLower(table.get(record, "Exclusive Shelf"))]; ok {
+		book.Status = status
+	}
+	if book.Status != models.StatusFinished {
+		book.FinishedAt = nil
+	}
+	return book, nil
+}
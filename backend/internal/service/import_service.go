@@ -0,0 +1,230 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	ImportCreated  = "created"
+	ImportSkipped  = "skipped_duplicate"
+	ImportRestored = "restored"
+	ImportFailed   = "failed"
+)
+
+// ImportRow is a single book read from an import file. Rows that could not be
+// parsed carry the reason in Err and are reported as failed.
+type ImportRow struct {
+	Line int
+	Book models.Book
+	Tags []string
+	Err  error
+}
+
+// ImportRowResult is what happened to one row of an import
+type ImportRowResult struct {
+	Line    int      `json:"line"`
+	Title   string   `json:"title"`
+	Author  string   `json:"author"`
+	Outcome string   `json:"outcome"`
+	Reason  string   `json:"reason,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// ImportReport summarizes an import
+type ImportReport struct {
+	Format   string            `json:"format"`
+	DryRun   bool              `json:"dry_run"`
+	Created  int               `json:"created"`
+	Skipped  int               `json:"skipped"`
+	Restored int               `json:"restored"`
+	Failed   int               `json:"failed"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+func (r *ImportReport) add(row ImportRow, outcome string, reason string) {
+	r.Rows = append(r.Rows, ImportRowResult{
+		Line:    row.Line,
+		Title:   row.Book.Title,
+		Author:  row.Book.Author,
+		Outcome: outcome,
+		Reason:  reason,
+		Tags:    row.Tags,
+	})
+	switch outcome {
+	case ImportCreated:
+		r.Created++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportRestored:
+		r.Restored++
+	case ImportFailed:
+		r.Failed++
+	}
+}
+
+// errDryRun rolls back the import transaction once a dry run has been reported
+var errDryRun = errors.New("dry run")
+
+type ImportService interface {
+	Import(ctx context.Context, userID string, format string, r io.Reader, dryRun bool) (*ImportReport, error)
+}
+
+type importService struct {
+	DB        *gorm.DB
+	Importers *ImporterRegistry
+}
+
+func NewImportService(db *gorm.DB) ImportService {
+	return &importService{
+		DB:        db,
+		Importers: DefaultImporterRegistry(),
+	}
+}
+
+// Import parses r with the importer for format, or detects the format from the
+// file's header when format is empty. A dry run reports what would change
+// without writing anything.
+func (s *importService) Import(ctx context.Context, userID string, format string, r io.Reader, dryRun bool) (*ImportReport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, newValidationError("failed to read import file: %v", err)
+	}
+
+	var importer Importer
+	var ok bool
+	if format == "" {
+		importer, ok = s.Importers.Detect(data)
+		if !ok {
+			return nil, newValidationError("unrecognized import format, expected one of: %s", strings.Join(s.Importers.Formats(), ", "))
+		}
+	} else {
+		importer, ok = s.Importers.Get(format)
+		if !ok {
+			return nil, newValidationError("unsupported import format: %s", format)
+		}
+	}
+
+	rows, err := importer.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return s.importRows(ctx, userID, importer.Format(), rows, dryRun)
+}
+
+// importRatingScale is the scale ratings are given in by the supported import formats
+var importRatingScale = models.RatingScale{Max: 5, Step: 0.25}
+
+// importRows saves the parsed rows in a single transaction. Rows that fail
+// validation are reported and skipped, while a database error rolls back the
+// whole import so it can simply be retried.
+func (s *importService) importRows(ctx context.Context, userID string, format string, rows []ImportRow, dryRun bool) (*ImportReport, error) {
+	report := &ImportReport{Format: format, DryRun: dryRun, Rows: []ImportRowResult{}}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		books := NewBookService(tx)
+		duplicates := NewDuplicateService(tx)
+		user, err := findUserByAuth0ID(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		// Goal history is recomputed once for all the imported books
+		finished := []time.Time{}
+		for _, row := range rows {
+			if row.Err != nil {
+				report.add(row, ImportFailed, row.Err.Error())
+				continue
+			}
+			if row.Book.Title == "" || row.Book.Author == "" {
+				report.add(row, ImportFailed, "title and author are required")
+				continue
+			}
+			if err := normalizeEdition(&row.Book); err != nil {
+				report.add(row, ImportFailed, err.Error())
+				continue
+			}
+
+			existing, err := duplicates.FindDuplicate(ctx, userID, row.Book)
+			if err != nil {
+				return fmt.Errorf("line %d: failed to check existing books: %v", row.Line, err)
+			}
+
+			if existing != nil {
+				if existing.DeletedAt.Valid {
+					if err := books.RestoreBook(ctx, fmt.Sprintf("%d", existing.ID)); err != nil {
+						return fmt.Errorf("line %d: %v", row.Line, err)
+					}
+					if err := shelveImportedBook(tx, user.ID, existing.ID, row.Tags); err != nil {
+						return fmt.Errorf("line %d: %v", row.Line, err)
+					}
+					report.add(row, ImportRestored, "")
+					continue
+				}
+				report.add(row, ImportSkipped, "already in collection")
+				continue
+			}
+
+			// Every supported export rates out of 5, so ratings are moved onto the user's own scale
+			book := row.Book
+			book.Rating = user.RatingScale().Convert(book.Rating, importRatingScale)
+			if err := createBook(tx, user, &book); err != nil {
+				if IsValidationError(err) {
+					report.add(row, ImportFailed, err.Error())
+					continue
+				}
+				return fmt.Errorf("line %d: %v", row.Line, err)
+			}
+			finished = append(finished, finishedOn(book.FinishedAt)...)
+			if err := shelveImportedBook(tx, user.ID, book.ID, row.Tags); err != nil {
+				return fmt.Errorf("line %d: %v", row.Line, err)
+			}
+			report.add(row, ImportCreated, "")
+		}
+		if err := NewGoalService(tx).RecomputeGoalHistory(ctx, userID, finished); err != nil {
+			return err
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// shelveImportedBook puts an imported book on the shelves named by its tags,
+// creating the shelves as needed. Tags that aren't valid shelf names are ignored.
+func shelveImportedBook(tx *gorm.DB, userID uint, bookID uint, tags []string) error {
+	names := []string{}
+	for _, tag := range tags {
+		if name, err := validateShelfName(tag); err == nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	shelves, err := findOrCreateShelves(tx, userID, names)
+	if err != nil {
+		return err
+	}
+	for _, shelf := range shelves {
+		if err := addBooksToShelf(tx, shelf.ID, []uint{bookID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
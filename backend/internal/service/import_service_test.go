@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const goodreadsExport = `Book Id,Title,Author,Author l-f,Additional Authors,ISBN,ISBN13,My Rating,Average Rating,Publisher,Binding,Number of Pages,Year Published,Original Publication Year,Date Read,Date Added,Bookshelves,Bookshelves with positions,Exclusive Shelf,My Review,Spoiler,Private Notes,Read Count,Owned Copies
+5907,The Hobbit,J.R.R. Tolkien,"Tolkien, J.R.R.",,"=""0618260307""","=""9780618260300""",5,4.28,Houghton Mifflin,Paperback,366,2002,1937,2024/03/10,2024/01/02,,,read,,,,1,0
+234225,Dune,Frank Herbert,"Herbert, Frank",,"=""""","=""""",0,4.25,Ace,Paperback,,2005,1965,,2024/02/01,,,to-read,,,,0,0
+1,Existing Book,Someone,,,,,0,,,,,,,,,,,currently-reading,,,,0,0
+2,Deleted Book,Someone,,,,,0,,,,,,,,,,,read,,,,0,0
+3,Bad Pages,Someone,,,,,0,,,,lots,,,,,,,to-read,,,,0,0
+`
+
+func TestImportGoodreads(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewImportService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	assert.NoError(t, db.Create(&models.Book{Title: "Existing Book", Author: "Someone", UserID: user.ID}).Error)
+	deleted := models.Book{Title: "Deleted Book", Author: "Someone", UserID: user.ID}
+	assert.NoError(t, db.Create(&deleted).Error)
+	assert.NoError(t, db.Delete(&deleted).Error)
+
+	report, err := service.Import(ctx, user.Auth0ID, "goodreads", strings.NewReader(goodreadsExport), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 1, report.Restored)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 6, report.Rows[4].Line)
+
+	var hobbit models.Book
+	assert.NoError(t, db.Where("title = ?", "The Hobbit").First(&hobbit).Error)
+	assert.Equal(t, "9780618260300", hobbit.ISBN)
+	assert.Equal(t, "0618260307", hobbit.ISBN10)
+	assert.Equal(t, models.FormatPaperback, hobbit.Format)
+	assert.Equal(t, "Houghton Mifflin", hobbit.Publisher)
+	assert.Equal(t, "2002", hobbit.PublishDate)
+	assert.Equal(t, 5.0, hobbit.Rating)
+	assert.Equal(t, uint(366), hobbit.PageCount)
+	assert.Equal(t, models.StatusFinished, hobbit.Status)
+	assert.Equal(t, 2024, hobbit.FinishedAt.Year())
+	assert.Equal(t, 2024, hobbit.CreatedAt.Year())
+
+	var dune models.Book
+	assert.NoError(t, db.Where("title = ?", "Dune").First(&dune).Error)
+	assert.Equal(t, models.StatusWantToRead, dune.Status)
+
+	// Importing the same file again only finds duplicates
+	report, err = service.Import(ctx, user.Auth0ID, "goodreads", strings.NewReader(goodreadsExport), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Created)
+	assert.Equal(t, 4, report.Skipped)
+
+	// Files without the expected columns are rejected
+	_, err = service.Import(ctx, user.Auth0ID, "goodreads", strings.NewReader("foo,bar\n1,2\n"), false)
+	assert.True(t, IsValidationError(err))
+}
+
+const storyGraphExport = `Title,Authors,Contributors,ISBN/UID,Format,Read Status,Date Added,Last Date Read,Dates Read,Read Count,Moods,Pace,Character- or Plot-Driven?,Strong Character Development?,Loveable Characters?,Diverse Characters?,Flawed Characters?,Star Rating,Review,Content Warnings,Content Warning Description,Tags,Owned?
+Piranesi,Susanna Clarke,,9781635575996,hardcover,read,2024/01/05,2024/02/20,2024/02/01-2024/02/20,1,"mysterious, reflective",slow,Character,Yes,Yes,No,Yes,4.5,,,,"favourites",Yes
+Some Book,Someone Else,,sg-1234,digital,did-not-finish,2024/01/05,,,0,,,,,,,,,,,,,No
+`
+
+const libraryThingTSVExport = "Book Id\tTitle\tPrimary Author\tRating\tPage Count\tEntry Date\tDate Started\tDate Read\tTags\tCollections\tISBN\n" +
+	"101\tThe Name of the Wind\tPatrick Rothfuss\t4.5\t662 p.\t2023-05-01\t2023-06-01\t2023-07-01\tfantasy, kingkiller\tYour library, Favorites\t[0756404746]\n" +
+	"102\tThe Wise Man's Fear\tPatrick Rothfuss\t\t\t2023-05-01\t\t\t\tCurrently reading\t\n"
+
+const libraryThingJSONExport = `{
+	"200": {"books_id": "200", "title": "Gideon the Ninth", "primaryauthor": "Tamsyn Muir", "isbn": {"0": "1250313198"}, "pages": "448", "rating": 4, "tags": ["necromancy"], "collections": ["To read"], "genre": ["Fiction and Literature"]}
+}`
+
+func TestImportDetectsFormats(t *testing.T) {
+	registry := DefaultImporterRegistry()
+
+	tests := []struct {
+		data   string
+		format string
+	}{
+		{goodreadsExport, "goodreads"},
+		{storyGraphExport, "storygraph"},
+		{libraryThingTSVExport, "librarything"},
+		{libraryThingJSONExport, "librarything_json"},
+	}
+	for _, tt := range tests {
+		importer, ok := registry.Detect([]byte(tt.data))
+		assert.True(t, ok)
+		assert.Equal(t, tt.format, importer.Format())
+	}
+
+	_, ok := registry.Detect([]byte("foo,bar\n1,2\n"))
+	assert.False(t, ok)
+}
+
+func TestImportStoryGraphAndLibraryThing(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewImportService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	report, err := service.Import(ctx, user.Auth0ID, "", strings.NewReader(storyGraphExport), false)
+	assert.NoError(t, err)
+	assert.Equal(t, "storygraph", report.Format)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, []string{"mysterious", "reflective", "favourites"}, report.Rows[0].Tags)
+
+	var piranesi models.Book
+	assert.NoError(t, db.Where("title = ?", "Piranesi").First(&piranesi).Error)
+	assert.Equal(t, "9781635575996", piranesi.ISBN)
+	assert.Equal(t, 4.5, piranesi.Rating)
+	assert.Equal(t, models.FormatHardcover, piranesi.Format)
+	assert.Equal(t, models.StatusFinished, piranesi.Status)
+	assert.Equal(t, 1, piranesi.StartedAt.Day())
+	assert.Equal(t, 20, piranesi.FinishedAt.Day())
+
+	var dnf models.Book
+	assert.NoError(t, db.Where("title = ?", "Some Book").First(&dnf).Error)
+	assert.Equal(t, models.StatusDidNotFinish, dnf.Status)
+	assert.Equal(t, models.FormatEbook, dnf.Format)
+	assert.Empty(t, dnf.ISBN)
+
+	report, err = service.Import(ctx, user.Auth0ID, "", strings.NewReader(libraryThingTSVExport), false)
+	assert.NoError(t, err)
+	assert.Equal(t, "librarything", report.Format)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, []string{"fantasy", "kingkiller", "Your library", "Favorites"}, report.Rows[0].Tags)
+
+	var wind models.Book
+	assert.NoError(t, db.Where("title = ?", "The Name of the Wind").First(&wind).Error)
+	assert.Equal(t, uint(662), wind.PageCount)
+	assert.Equal(t, "0756404746", wind.ISBN)
+	assert.Equal(t, models.StatusFinished, wind.Status)
+
+	var fear models.Book
+	assert.NoError(t, db.Where("title = ?", "The Wise Man's Fear").First(&fear).Error)
+	assert.Equal(t, models.StatusReading, fear.Status)
+
+	report, err = service.Import(ctx, user.Auth0ID, "", strings.NewReader(libraryThingJSONExport), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+
+	var gideon models.Book
+	assert.NoError(t, db.Where("title = ?", "Gideon the Ninth").First(&gideon).Error)
+	assert.Equal(t, "1250313198", gideon.ISBN)
+	assert.Equal(t, uint(448), gideon.PageCount)
+	assert.Equal(t, "Fiction and Literature", gideon.Genre)
+	assert.Equal(t, models.StatusWantToRead, gideon.Status)
+}
+
+func TestImportDryRun(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewImportService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	report, err := service.Import(ctx, user.Auth0ID, "goodreads", strings.NewReader(goodreadsExport), true)
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 4, report.Created)
+
+	var count int64
+	db.Model(&models.Book{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+)
+
+// Importer reads one export format into import rows
+type Importer interface {
+	// Format is the name used to select the importer explicitly, e.g. "goodreads"
+	Format() string
+	// Detect reports whether the start of a file looks like this format
+	Detect(sample []byte) bool
+	Parse(r io.Reader) ([]ImportRow, error)
+}
+
+// ImporterRegistry holds the available importers in detection order
+type ImporterRegistry struct {
+	importers []Importer
+}
+
+func NewImporterRegistry(importers ...Importer) *ImporterRegistry {
+	return &ImporterRegistry{importers: importers}
+}
+
+// DefaultImporterRegistry returns a registry with every built-in format
+func DefaultImporterRegistry() *ImporterRegistry {
+	return NewImporterRegistry(
+		goodreadsImporter{},
+		storyGraphImporter{},
+		libraryThingTSVImporter{},
+		libraryThingJSONImporter{},
+	)
+}
+
+// Register adds an importer, replacing any existing importer for the same format
+func (r *ImporterRegistry) Register(importer Importer) {
+	for i, existing := range r.importers {
+		if existing.Format() == importer.Format() {
+			r.importers[i] = importer
+			return
+		}
+	}
+	r.importers = append(r.importers, importer)
+}
+
+// Get returns the importer for a format name
+func (r *ImporterRegistry) Get(format string) (Importer, bool) {
+	for _, importer := range r.importers {
+		if importer.Format() == format {
+			return importer, true
+		}
+	}
+	return nil, false
+}
+
+// Detect returns the first importer that recognizes the sample
+func (r *ImporterRegistry) Detect(sample []byte) (Importer, bool) {
+	for _, importer := range r.importers {
+		if importer.Detect(sample) {
+			return importer, true
+		}
+	}
+	return nil, false
+}
+
+// Formats lists the registered format names
+func (r *ImporterRegistry) Formats() []string {
+	formats := make([]string, len(r.importers))
+	for i, importer := range r.importers {
+		formats[i] = importer.Format()
+	}
+	return formats
+}
+
+// headerColumns splits the first line of a delimited file into lower-cased column names
+func headerColumns(sample []byte, comma string) map[string]bool {
+	line := sample
+	if i := bytes.IndexByte(sample, '\n'); i >= 0 {
+		line = sample[:i]
+	}
+	line = bytes.TrimPrefix(line, []byte("\ufeff"))
+
+	columns := map[string]bool{}
+	for _, column := range strings.Split(string(line), comma) {
+		column = strings.ToLower(strings.Trim(strings.TrimSpace(column), `"`))
+		columns[column] = true
+	}
+	return columns
+}
+
+// hasColumns reports whether all of the given columns are present in the header
+func hasColumns(header map[string]bool, columns ...string) bool {
+	for _, column := range columns {
+		if !header[strings.ToLower(column)] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitImportList splits a delimited list of values, such as tags or authors
+func splitImportList(value string, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// importFormats maps the format and binding names used by other apps to book formats
+var importFormats = map[string]string{
+	"hardcover":             models.FormatHardcover,
+	"hardback":              models.FormatHardcover,
+	"library binding":       models.FormatHardcover,
+	"paperback":             models.FormatPaperback,
+	"mass market paperback": models.FormatPaperback,
+	"trade paperback":       models.FormatPaperback,
+	"digital":               models.FormatEbook,
+	"ebook":                 models.FormatEbook,
+	"kindle edition":        models.FormatEbook,
+	"nook":                  models.FormatEbook,
+	"audio":                 models.FormatAudiobook,
+	"audiobook":             models.FormatAudiobook,
+	"audio cd":              models.FormatAudiobook,
+	"audible audio":         models.FormatAudiobook,
+}
+
+// importFormat returns the book format for an imported format name, or "" when it isn't known
+func importFormat(value string) string {
+	return importFormats[strings.ToLower(strings.TrimSpace(value))]
+}
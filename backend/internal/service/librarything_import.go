@@ -0,0 +1,240 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+)
+
+// libraryThingCollections maps LibraryThing's built-in collections onto reading statuses.
+// Other collections, like "Your library" or "Favorites", are imported as tags.
+var libraryThingCollections = map[string]string{
+	"currently reading": models.StatusReading,
+	"to read":           models.StatusWantToRead,
+	"read but unowned":  models.StatusFinished,
+}
+
+// libraryThingBook is the format independent content of a LibraryThing entry
+type libraryThingBook struct {
+	Title       string
+	Author      string
+	ISBN        string
+	Rating      string
+	Pages       string
+	Genre       string
+	EntryDate   string
+	DateStarted string
+	DateRead    string
+	Tags        []string
+	Collections []string
+}
+
+func (lt libraryThingBook) toRow(line int) ImportRow {
+	row := ImportRow{Line: line}
+	book := models.Book{
+		Title:  lt.Title,
+		Author: lt.Author,
+		Genre:  lt.Genre,
+	}
+	if isbn := NormalizeISBN(strings.Trim(lt.ISBN, "[]")); isISBN(isbn) {
+		book.ISBN = isbn
+	}
+
+	var err error
+	if book.Rating, err = parseImportFloat(lt.Rating); err != nil {
+		row.Err = fmt.Errorf("rating: %v", err)
+	}
+	// Page counts are sometimes given as free text such as "310 p."
+	if pages := strings.Fields(lt.Pages); len(pages) > 0 {
+		if book.PageCount, err = parseImportUint(pages[0]); err != nil {
+			row.Err = fmt.Errorf("pages: %v", err)
+		}
+	}
+	if added, err := parseImportDate(lt.EntryDate); err != nil {
+		row.Err = fmt.Errorf("entry date: %v", err)
+	} else if added != nil {
+		book.CreatedAt = *added
+	}
+	if book.StartedAt, err = parseImportDate(lt.DateStarted); err != nil {
+		row.Err = fmt.Errorf("date started: %v", err)
+	}
+	if book.FinishedAt, err = parseImportDate(lt.DateRead); err != nil {
+		row.Err = fmt.Errorf("date read: %v", err)
+	}
+
+	row.Tags = append(row.Tags, lt.Tags...)
+	for _, collection := range lt.Collections {
+		if status, ok := libraryThingCollections[strings.ToLower(collection)]; ok {
+			book.Status = status
+			continue
+		}
+		row.Tags = append(row.Tags, collection)
+	}
+
+	// Dates are more reliable than collections, which many users never maintain
+	switch {
+	case book.FinishedAt != nil:
+		book.Status = models.StatusFinished
+	case book.Status == "" && book.StartedAt != nil:
+		book.Status = models.StatusReading
+	case book.Status == "":
+		book.Status = models.StatusWantToRead
+	}
+
+	row.Book = book
+	return row
+}
+
+// libraryThingTSVImporter reads LibraryThing's tab-delimited export
+type libraryThingTSVImporter struct{}
+
+func (libraryThingTSVImporter) Format() string {
+	return "librarything"
+}
+
+func (libraryThingTSVImporter) Detect(sample []byte) bool {
+	return hasColumns(headerColumns(sample, "\t"), "Title", "Primary Author")
+}
+
+func (libraryThingTSVImporter) Parse(r io.Reader) ([]ImportRow, error) {
+	table, err := readCSVTable(r, '\t')
+	if err != nil {
+		return nil, err
+	}
+	if !table.has("Title") || !table.has("Primary Author") {
+		return nil, newValidationError("not a LibraryThing export: Title and Primary Author columns are required")
+	}
+
+	rows := make([]ImportRow, 0, len(table.records))
+	for i, record := range table.records {
+		isbn := table.get(record, "ISBN")
+		if isbn == "" {
+			isbn = table.get(record, "ISBNs")
+		}
+		lt := libraryThingBook{
+			Title:       table.get(record, "Title"),
+			Author:      table.get(record, "Primary Author"),
+			ISBN:        strings.Split(isbn, ",")[0],
+			Rating:      table.get(record, "Rating"),
+			Pages:       table.get(record, "Page Count"),
+			EntryDate:   table.get(record, "Entry Date"),
+			DateStarted: table.get(record, "Date Started"),
+			DateRead:    table.get(record, "Date Read"),
+			Tags:        splitImportList(table.get(record, "Tags"), ","),
+			Collections: splitImportList(table.get(record, "Collections"), ","),
+		}
+		rows = append(rows, lt.toRow(i+2))
+	}
+	return rows, nil
+}
+
+// libraryThingJSONImporter reads LibraryThing's JSON export, an object of books keyed by id
+type libraryThingJSONImporter struct{}
+
+func (libraryThingJSONImporter) Format() string {
+	return "librarything_json"
+}
+
+func (libraryThingJSONImporter) Detect(sample []byte) bool {
+	trimmed := bytes.TrimSpace(sample)
+	return bytes.HasPrefix(trimmed, []byte("{")) &&
+		(bytes.Contains(trimmed, []byte(`"books_id"`)) || bytes.Contains(trimmed, []byte(`"primaryauthor"`)))
+}
+
+type libraryThingJSONEntry struct {
+	Title         string          `json:"title"`
+	PrimaryAuthor string          `json:"primaryauthor"`
+	ISBN          json.RawMessage `json:"isbn"`
+	OriginalISBN  string          `json:"originalisbn"`
+	Rating        json.RawMessage `json:"rating"`
+	Pages         json.RawMessage `json:"pages"`
+	Genre         json.RawMessage `json:"genre"`
+	EntryDate     string          `json:"entrydate"`
+	DateStarted   string          `json:"datestarted"`
+	DateRead      string          `json:"dateread"`
+	Tags          []string        `json:"tags"`
+	Collections   []string        `json:"collections"`
+}
+
+func (libraryThingJSONImporter) Parse(r io.Reader) ([]ImportRow, error) {
+	var entries map[string]libraryThingJSONEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, newValidationError("not a LibraryThing JSON export: %v", err)
+	}
+
+	// Keep the import order stable by sorting on the LibraryThing id
+	ids := make([]string, 0, len(entries))
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return ids[i] < ids[j]
+	})
+
+	rows := make([]ImportRow, 0, len(entries))
+	for i, id := range ids {
+		entry := entries[id]
+		lt := libraryThingBook{
+			Title:       entry.Title,
+			Author:      entry.PrimaryAuthor,
+			ISBN:        firstJSONString(entry.ISBN),
+			Rating:      firstJSONString(entry.Rating),
+			Pages:       firstJSONString(entry.Pages),
+			EntryDate:   entry.EntryDate,
+			DateStarted: entry.DateStarted,
+			DateRead:    entry.DateRead,
+			Tags:        entry.Tags,
+			Collections: entry.Collections,
+		}
+		if lt.ISBN == "" {
+			lt.ISBN = entry.OriginalISBN
+		}
+		lt.Genre = firstJSONString(entry.Genre)
+		rows = append(rows, lt.toRow(i+1))
+	}
+	return rows, nil
+}
+
+// firstJSONString reads a value LibraryThing may encode as a string, a number,
+// a list or an object of strings, returning the first string found
+func firstJSONString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String()
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil && len(list) > 0 {
+		return strings.TrimSpace(list[0])
+	}
+	var object map[string]string
+	if err := json.Unmarshal(raw, &object); err == nil {
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if len(keys) > 0 {
+			return strings.TrimSpace(object[keys[0]])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrBookOnLoan is returned when lending a book that hasn't come back yet
+var ErrBookOnLoan = errors.New("this book is already on loan")
+
+type LoanService interface {
+	ListLoans(ctx context.Context, userID string, bookID string) ([]models.Loan, error)
+	LendBook(ctx context.Context, userID string, bookID string, loan models.Loan) (*models.Loan, error)
+	ReturnBook(ctx context.Context, userID string, bookID string, copyID *uint, returnedAt time.Time) (*models.Loan, error)
+	ListLentOut(ctx context.Context, userID string) ([]models.Loan, error)
+	ListOverdue(ctx context.Context, userID string) ([]models.Loan, error)
+}
+
+type loanService struct {
+	DB *gorm.DB
+}
+
+func NewLoanService(db *gorm.DB) LoanService {
+	return &loanService{
+		DB: db,
+	}
+}
+
+// findUserBook loads a non-deleted book owned by the user
+func (s *loanService) findUserBook(auth0ID string, bookID string) (*models.User, *models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, auth0ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var book models.Book
+	if err := s.DB.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+		return nil, nil, err
+	}
+	return user, &book, nil
+}
+
+// ListLoans returns every time the book has been lent, most recent first
+func (s *loanService) ListLoans(ctx context.Context, userID string, bookID string) ([]models.Loan, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	loans := []models.Loan{}
+	if err := s.DB.Where("book_id = ? AND user_id = ?", book.ID, user.ID).
+		Order("lent_at desc, id desc").
+		Find(&loans).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch loans: %v", err)
+	}
+	return loans, nil
+}
+
+// LendBook records the book as lent to someone, today unless a date is given.
+// Ebooks and audiobooks can't be lent, and each copy has one loan out at a time.
+// Books with several copies need the loan to say which copy is lent.
+func (s *loanService) LendBook(ctx context.Context, userID string, bookID string, loan models.Loan) (*models.Loan, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if book.Format == models.FormatEbook || book.Format == models.FormatAudiobook {
+		return nil, newValidationError("only physical books can be lent")
+	}
+	if loan.LentAt.IsZero() {
+		loan.LentAt = time.Now()
+	}
+	if loan, err = validateLoan(loan); err != nil {
+		return nil, err
+	}
+
+	loan.ID = 0
+	loan.BookID = book.ID
+	loan.UserID = user.ID
+	loan.ReturnedAt = nil
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := chooseLoanCopy(tx, &loan); err != nil {
+			return err
+		}
+
+		// Loans from before the book had copies hold every copy
+		open := tx.Model(&models.Loan{}).Where("book_id = ? AND returned_at IS NULL", book.ID)
+		if loan.CopyID != nil {
+			open = open.Where("copy_id = ? OR copy_id IS NULL", *loan.CopyID)
+		}
+		var count int64
+		if err := open.Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check current loans: %v", err)
+		}
+		if count > 0 {
+			return ErrBookOnLoan
+		}
+
+		if err := tx.Create(&loan).Error; err != nil {
+			return fmt.Errorf("failed to create loan: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+// ReturnBook closes the book's current loan, returned today unless a date is
+// given. When several copies are lent out copyID says which one came back.
+func (s *loanService) ReturnBook(ctx context.Context, userID string, bookID string, copyID *uint, returnedAt time.Time) (*models.Loan, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	open := s.DB.Where("book_id = ? AND user_id = ? AND returned_at IS NULL", book.ID, user.ID)
+	if copyID != nil {
+		open = open.Where("copy_id = ?", *copyID)
+	}
+	var loans []models.Loan
+	if err := open.Limit(2).Find(&loans).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch loan: %v", err)
+	}
+	if len(loans) == 0 {
+		return nil, newValidationError("this book is not on loan")
+	}
+	if len(loans) > 1 {
+		return nil, newValidationError("more than one copy of this book is on loan, copy_id is required")
+	}
+	loan := loans[0]
+
+	if returnedAt.IsZero() {
+		returnedAt = time.Now()
+	}
+	if returnedAt.Before(loan.LentAt) {
+		return nil, newValidationError("a book cannot be returned before it was lent")
+	}
+	if returnedAt.After(time.Now().Add(24 * time.Hour)) {
+		return nil, newValidationError("return date cannot be in the future")
+	}
+
+	loan.ReturnedAt = &returnedAt
+	if err := s.DB.Model(&loan).Update("returned_at", returnedAt).Error; err != nil {
+		return nil, fmt.Errorf("failed to return book: %v", err)
+	}
+	return &loan, nil
+}
+
+// ListLentOut returns the user's loans that haven't been returned, soonest due first
+func (s *loanService) ListLentOut(ctx context.Context, userID string) ([]models.Loan, error) {
+	return s.openLoans(userID, nil)
+}
+
+// ListOverdue returns the user's loans that are still out past their due date
+func (s *loanService) ListOverdue(ctx context.Context, userID string) ([]models.Loan, error) {
+	return s.openLoans(userID, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("loans.due_at < ?", time.Now())
+	})
+}
+
+// openLoans loads the user's unreturned loans of books still in the collection,
+// along with the books themselves
+func (s *loanService) openLoans(auth0ID string, scope func(*gorm.DB) *gorm.DB) ([]models.Loan, error) {
+	user, err := findUserByAuth0ID(s.DB, auth0ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := s.DB.Preload("Book").
+		Preload("Copy").
+		Joins("JOIN books ON books.id = loans.book_id AND books.deleted_at IS NULL").
+		Where("loans.user_id = ? AND loans.returned_at IS NULL", user.ID)
+	if scope != nil {
+		tx = scope(tx)
+	}
+
+	loans := []models.Loan{}
+	if err := tx.Order("loans.due_at IS NULL, loans.due_at asc, loans.lent_at asc").
+		Find(&loans).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch loans: %v", err)
+	}
+	return loans, nil
+}
+
+// chooseLoanCopy checks the copy being lent belongs to the book. Books with a
+// single copy lend that copy when none is given.
+func chooseLoanCopy(tx *gorm.DB, loan *models.Loan) error {
+	var copies []models.BookCopy
+	if err := tx.Where("book_id = ?", loan.BookID).Order("id asc").Find(&copies).Error; err != nil {
+		return fmt.Errorf("failed to fetch copies: %v", err)
+	}
+
+	if loan.CopyID == nil {
+		switch len(copies) {
+		case 0:
+			return nil
+		case 1:
+			loan.CopyID = &copies[0].ID
+			return nil
+		default:
+			return newValidationError("this book has %d copies, copy_id is required", len(copies))
+		}
+	}
+	for _, bookCopy := range copies {
+		if bookCopy.ID == *loan.CopyID {
+			return nil
+		}
+	}
+	return newValidationError("copy %d is not a copy of this book", *loan.CopyID)
+}
+
+// validateLoan trims the borrower details and checks the dates make sense
+func validateLoan(loan models.Loan) (models.Loan, error) {
+	loan.Borrower = strings.TrimSpace(loan.Borrower)
+	loan.Contact = strings.TrimSpace(loan.Contact)
+	if loan.Borrower == "" {
+		return loan, newValidationError("borrower is required")
+	}
+	if loan.LentAt.After(time.Now().Add(24 * time.Hour)) {
+		return loan, newValidationError("lent date cannot be in the future")
+	}
+	if loan.DueAt != nil && loan.DueAt.Before(loan.LentAt) {
+		return loan, newValidationError("due date cannot be before the lent date")
+	}
+	return loan, nil
+}
+
+// attachLoans sets OnLoan on each book that has an unreturned loan, and on
+// each of their loaded copies that is lent out
+func attachLoans(db *gorm.DB, books []models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+
+	var open []models.Loan
+	if err := db.Select("book_id", "copy_id").
+		Where("book_id IN ? AND returned_at IS NULL", ids).
+		Find(&open).Error; err != nil {
+		return fmt.Errorf("failed to fetch loans: %v", err)
+	}
+
+	onLoan := make(map[uint]bool, len(open))
+	copyOnLoan := make(map[uint]bool, len(open))
+	for _, loan := range open {
+		onLoan[loan.BookID] = true
+		if loan.CopyID != nil {
+			copyOnLoan[*loan.CopyID] = true
+		}
+	}
+	for i := range books {
+		books[i].OnLoan = onLoan[books[i].ID]
+		for j := range books[i].Copies {
+			books[i].Copies[j].OnLoan = copyOnLoan[books[i].Copies[j].ID]
+		}
+	}
+	return nil
+}
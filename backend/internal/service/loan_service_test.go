@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoanLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewLoanService(db)
+	books := NewBookService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	dune := models.Book{Title: "Dune", Author: "Frank Herbert", UserID: user.ID}
+	assert.NoError(t, db.Create(&dune).Error)
+	hobbit := models.Book{Title: "The Hobbit", Author: "J.R.R. Tolkien", UserID: user.ID}
+	assert.NoError(t, db.Create(&hobbit).Error)
+	ebook := models.Book{Title: "Piranesi", Author: "Susanna Clarke", Format: models.FormatEbook, UserID: user.ID}
+	assert.NoError(t, db.Create(&ebook).Error)
+	duneID := fmt.Sprintf("%d", dune.ID)
+	hobbitID := fmt.Sprintf("%d", hobbit.ID)
+
+	_, err := service.LendBook(ctx, user.Auth0ID, duneID, models.Loan{Borrower: "  "})
+	assert.True(t, IsValidationError(err))
+
+	_, err = service.LendBook(ctx, user.Auth0ID, fmt.Sprintf("%d", ebook.ID), models.Loan{Borrower: "Sam"})
+	assert.True(t, IsValidationError(err))
+
+	lentAt := time.Now().AddDate(0, 0, -20)
+	pastDue := time.Now().AddDate(0, 0, -6)
+	_, err = service.LendBook(ctx, user.Auth0ID, duneID, models.Loan{Borrower: "Sam", LentAt: lentAt, DueAt: &lentAt})
+	assert.NoError(t, err)
+	_, err = service.ReturnBook(ctx, user.Auth0ID, duneID, nil, time.Time{})
+	assert.NoError(t, err)
+
+	loan, err := service.LendBook(ctx, user.Auth0ID, duneID, models.Loan{Borrower: " Alex ", Contact: "alex@example.com", LentAt: lentAt, DueAt: &pastDue})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alex", loan.Borrower)
+	assert.True(t, loan.IsOverdue(time.Now()))
+
+	_, err = service.LendBook(ctx, user.Auth0ID, duneID, models.Loan{Borrower: "Sam"})
+	assert.ErrorIs(t, err, ErrBookOnLoan)
+
+	due := time.Now().AddDate(0, 1, 0)
+	_, err = service.LendBook(ctx, user.Auth0ID, hobbitID, models.Loan{Borrower: "Sam", DueAt: &due})
+	assert.NoError(t, err)
+
+	lentOut, err := service.ListLentOut(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Len(t, lentOut, 2)
+	assert.Equal(t, dune.ID, lentOut[0].BookID)
+	assert.Equal(t, "Dune", lentOut[0].Book.Title)
+
+	overdue, err := service.ListOverdue(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Len(t, overdue, 1)
+	assert.Equal(t, "Alex", overdue[0].Borrower)
+
+	collection, err := books.QueryUserBooks(ctx, user.Auth0ID, BookQuery{})
+	assert.NoError(t, err)
+	onLoan := map[string]bool{}
+	for _, book := range collection.Books {
+		onLoan[book.Title] = book.OnLoan
+	}
+	assert.Equal(t, map[string]bool{"Dune": true, "The Hobbit": true, "Piranesi": false}, onLoan)
+
+	_, err = service.ReturnBook(ctx, user.Auth0ID, duneID, nil, lentAt.AddDate(0, 0, -1))
+	assert.True(t, IsValidationError(err))
+
+	returned, err := service.ReturnBook(ctx, user.Auth0ID, duneID, nil, time.Time{})
+	assert.NoError(t, err)
+	assert.NotNil(t, returned.ReturnedAt)
+
+	_, err = service.ReturnBook(ctx, user.Auth0ID, duneID, nil, time.Time{})
+	assert.True(t, IsValidationError(err))
+
+	history, err := service.ListLoans(ctx, user.Auth0ID, duneID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	overdue, err = service.ListOverdue(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Empty(t, overdue)
+
+	// Loans of deleted books drop out of the listings
+	assert.NoError(t, db.Delete(&hobbit).Error)
+	lentOut, err = service.ListLentOut(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Empty(t, lentOut)
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+)
+
+const (
+	DefaultGoogleBooksURL       = "https://www.googleapis.com/books/v1"
+	DefaultOpenLibraryURL       = "https://openlibrary.org"
+	DefaultOpenLibraryCoversURL = "https://covers.openlibrary.org"
+)
+
+// MetadataQuery is a lookup by ISBN, free text, or both
+type MetadataQuery struct {
+	ISBN  string
+	Query string
+}
+
+// MetadataProvider looks up bibliographic data from a third-party catalogue and
+// normalizes the results into draft books that have not been saved
+type MetadataProvider interface {
+	Name() string
+	Lookup(ctx context.Context, query MetadataQuery) ([]models.Book, error)
+}
+
+// NewMetadataProviders returns the configured providers in lookup order. Base URLs can be
+// overridden with GOOGLE_BOOKS_API_URL and OPEN_LIBRARY_API_URL.
+func NewMetadataProviders() []MetadataProvider {
+	return []MetadataProvider{
+		NewGoogleBooksProvider(envOrDefault("GOOGLE_BOOKS_API_URL", DefaultGoogleBooksURL), os.Getenv("GOOGLE_BOOKS_API_KEY")),
+		NewOpenLibraryProvider(envOrDefault("OPEN_LIBRARY_API_URL", DefaultOpenLibraryURL), envOrDefault("OPEN_LIBRARY_COVERS_URL", DefaultOpenLibraryCoversURL)),
+	}
+}
+
+func envOrDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// NormalizeISBN strips hyphens and spaces from an ISBN
+func NormalizeISBN(isbn string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn)))
+}
+
+// searchTerms builds the provider search string for the query
+func (q MetadataQuery) searchTerms() string {
+	var terms []string
+	if isbn := NormalizeISBN(q.ISBN); isbn != "" {
+		terms = append(terms, "isbn:"+isbn)
+	}
+	if text := strings.TrimSpace(q.Query); text != "" {
+		terms = append(terms, text)
+	}
+	return strings.Join(terms, " ")
+}
+
+func getJSON(ctx context.Context, client *http.Client, endpoint string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// GoogleBooksProvider looks books up through the Google Books volumes API
+type GoogleBooksProvider struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+}
+
+func NewGoogleBooksProvider(baseURL string, apiKey string) *GoogleBooksProvider {
+	return &GoogleBooksProvider{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *GoogleBooksProvider) Name() string {
+	return "google_books"
+}
+
+func (p *GoogleBooksProvider) Lookup(ctx context.Context, query MetadataQuery) ([]models.Book, error) {
+	params := url.Values{}
+	params.Set("q", query.searchTerms())
+	params.Set("maxResults", "10")
+	if p.APIKey != "" {
+		params.Set("key", p.APIKey)
+	}
+
+	var response models.GoogleBookResponse
+	if err := getJSON(ctx, p.Client, p.BaseURL+"/volumes?"+params.Encode(), &response); err != nil {
+		return nil, fmt.Errorf("google books lookup failed: %v", err)
+	}
+
+	// Prefer complete volumes (cover and rating) over sparse ones
+	items := response.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].IsValid() && !items[j].IsValid()
+	})
+
+	books := make([]models.Book, 0, len(items))
+	for _, item := range items {
+		if item.VolumeInfo.Title == "" {
+			continue
+		}
+		books = append(books, googleBookToDraft(item))
+	}
+	return books, nil
+}
+
+func googleBookToDraft(item models.GoogleBook) models.Book {
+	info := item.VolumeInfo
+	book := models.Book{
+		Title:     info.Title,
+		Author:    strings.Join(info.Authors, ", "),
+		PageCount: uint(max(info.PageCount, 0)),
+	}
+	if len(info.Categories) > 0 {
+		book.Genre = info.Categories[0]
+	}
+
+	cover := info.ImageLinks.Thumbnail
+	if cover == "" {
+		cover = info.ImageLinks.SmallThumbnail
+	}
+	book.CoverImage = strings.Replace(cover, "http://", "https://", 1)
+	return book
+}
+
+// BookPrice is what a catalogue sells a book for
+type BookPrice struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	BuyLink  string  `json:"buy_link"`
+}
+
+// PriceProvider looks up the current price of a book, returning nil when no
+// listing for it is for sale
+type PriceProvider interface {
+	LookupPrice(ctx context.Context, query MetadataQuery) (*BookPrice, error)
+}
+
+// LookupPrice returns the price of the first volume matching the query that is for sale
+func (p *GoogleBooksProvider) LookupPrice(ctx context.Context, query MetadataQuery) (*BookPrice, error) {
+	params := url.Values{}
+	params.Set("q", query.searchTerms())
+	params.Set("maxResults", "10")
+	if p.APIKey != "" {
+		params.Set("key", p.APIKey)
+	}
+
+	var response models.GoogleBookResponse
+	if err := getJSON(ctx, p.Client, p.BaseURL+"/volumes?"+params.Encode(), &response); err != nil {
+		return nil, fmt.Errorf("google books price lookup failed: %v", err)
+	}
+
+	for _, item := range response.Items {
+		if price := googleBookPrice(item); price != nil {
+			return price, nil
+		}
+	}
+	return nil, nil
+}
+
+// googleBookPrice reads the retail price of a volume, falling back to its list price
+func googleBookPrice(item models.GoogleBook) *BookPrice {
+	sale := item.SaleInfo
+	if sale.Saleability != "FOR_SALE" {
+		return nil
+	}
+
+	price := &BookPrice{
+		Amount:   sale.RetailPrice.Amount,
+		Currency: sale.RetailPrice.CurrencyCode,
+		BuyLink:  sale.BuyLink,
+	}
+	if price.Amount <= 0 {
+		price.Amount = sale.ListPrice.Amount
+		price.Currency = sale.ListPrice.CurrencyCode
+	}
+	if price.Amount <= 0 || price.Currency == "" {
+		return nil
+	}
+	return price
+}
+
+// OpenLibraryProvider looks books up through the Open Library search API
+type OpenLibraryProvider struct {
+	BaseURL   string
+	CoversURL string
+	Client    *http.Client
+}
+
+func NewOpenLibraryProvider(baseURL string, coversURL string) *OpenLibraryProvider {
+	return &OpenLibraryProvider{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		CoversURL: strings.TrimRight(coversURL, "/"),
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *OpenLibraryProvider) Name() string {
+	return "open_library"
+}
+
+func (p *OpenLibraryProvider) Lookup(ctx context.Context, query MetadataQuery) ([]models.Book, error) {
+	params := url.Values{}
+	params.Set("q", query.searchTerms())
+	params.Set("limit", "10")
+
+	var response models.OpenLibraryResponse
+	if err := getJSON(ctx, p.Client, p.BaseURL+"/search.json?"+params.Encode(), &response); err != nil {
+		return nil, fmt.Errorf("open library lookup failed: %v", err)
+	}
+
+	books := make([]models.Book, 0, len(response.Docs))
+	for _, doc := range response.Docs {
+		if doc.Title == "" {
+			continue
+		}
+		book := models.Book{
+			Title:     doc.Title,
+			Author:    strings.Join(doc.AuthorName, ", "),
+			PageCount: uint(max(doc.NumberOfPagesMedian, 0)),
+		}
+		if doc.CoverI > 0 {
+			book.CoverImage = fmt.Sprintf("%s/b/id/%d-L.jpg", p.CoversURL, doc.CoverI)
+		}
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+// LookupMetadata asks each provider in turn and returns the first non-empty result set.
+// An error is only returned when every provider failed.
+func LookupMetadata(ctx context.Context, providers []MetadataProvider, query MetadataQuery) ([]models.Book, string, error) {
+	var errs []string
+	for _, provider := range providers {
+		books, err := provider.Lookup(ctx, query)
+		if err != nil {
+			fmt.Printf("Metadata lookup with %s failed: %v\n", provider.Name(), err)
+			errs = append(errs, err.Error())
+			continue
+		}
+		if len(books) > 0 {
+			return books, provider.Name(), nil
+		}
+	}
+
+	if len(errs) > 0 && len(errs) == len(providers) {
+		return nil, "", fmt.Errorf("all metadata providers failed: %s", strings.Join(errs, "; "))
+	}
+	return []models.Book{}, "", nil
+}
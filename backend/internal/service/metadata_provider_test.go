@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoogleBooksProviderLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/volumes", r.URL.Path)
+		assert.Equal(t, "isbn:9780547928227", r.URL.Query().Get("q"))
+		assert.Equal(t, "test-key", r.URL.Query().Get("key"))
+		w.Write([]byte(`{
+			"totalItems": 1,
+			"items": [{
+				"volumeInfo": {
+					"title": "The Hobbit",
+					"authors": ["J.R.R. Tolkien"],
+					"pageCount": 300,
+					"categories": ["Fantasy"],
+					"imageLinks": {"thumbnail": "http://books.google.com/cover.jpg"}
+				}
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	provider := NewGoogleBooksProvider(server.URL, "test-key")
+	books, err := provider.Lookup(context.Background(), MetadataQuery{ISBN: "978-0-547-92822-7"})
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, "The Hobbit", books[0].Title)
+	assert.Equal(t, "J.R.R. Tolkien", books[0].Author)
+	assert.Equal(t, uint(300), books[0].PageCount)
+	assert.Equal(t, "Fantasy", books[0].Genre)
+	assert.Equal(t, "https://books.google.com/cover.jpg", books[0].CoverImage)
+}
+
+func TestOpenLibraryProviderLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/search.json", r.URL.Path)
+		assert.Equal(t, "dune", r.URL.Query().Get("q"))
+		w.Write([]byte(`{
+			"numFound": 1,
+			"docs": [{
+				"title": "Dune",
+				"author_name": ["Frank Herbert"],
+				"number_of_pages_median": 604,
+				"cover_i": 42
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenLibraryProvider(server.URL, "https://covers.example.com")
+	books, err := provider.Lookup(context.Background(), MetadataQuery{Query: "dune"})
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, "Frank Herbert", books[0].Author)
+	assert.Equal(t, uint(604), books[0].PageCount)
+	assert.Equal(t, "https://covers.example.com/b/id/42-L.jpg", books[0].CoverImage)
+}
+
+func TestLookupMetadataFallsBack(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"docs": [{"title": "Dune", "author_name": ["Frank Herbert"]}]}`))
+	}))
+	defer working.Close()
+
+	providers := []MetadataProvider{
+		NewGoogleBooksProvider(failing.URL, ""),
+		NewOpenLibraryProvider(working.URL, working.URL),
+	}
+
+	books, source, err := LookupMetadata(context.Background(), providers, MetadataQuery{Query: "dune"})
+	assert.NoError(t, err)
+	assert.Equal(t, "open_library", source)
+	assert.Len(t, books, 1)
+
+	// Every provider failing is an error
+	_, _, err = LookupMetadata(context.Background(), providers[:1], MetadataQuery{Query: "dune"})
+	assert.Error(t, err)
+}
+
+func TestGoogleBooksProviderLookupPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/volumes", r.URL.Path)
+		w.Write([]byte(`{
+			"totalItems": 2,
+			"items": [{
+				"volumeInfo": {"title": "Piranesi"},
+				"saleInfo": {"saleability": "NOT_FOR_SALE"}
+			}, {
+				"volumeInfo": {"title": "Piranesi"},
+				"saleInfo": {
+					"saleability": "FOR_SALE",
+					"listPrice": {"amount": 14.99, "currencyCode": "USD"},
+					"retailPrice": {"amount": 9.99, "currencyCode": "USD"},
+					"buyLink": "https://play.google.com/store/books/details?id=1"
+				}
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	provider := NewGoogleBooksProvider(server.URL, "")
+	price, err := provider.LookupPrice(context.Background(), MetadataQuery{ISBN: "9781635575996"})
+	assert.NoError(t, err)
+	assert.Equal(t, &BookPrice{Amount: 9.99, Currency: "USD", BuyLink: "https://play.google.com/store/books/details?id=1"}, price)
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Kinds of note search results
+const (
+	NoteKindNote      = "note"
+	NoteKindHighlight = "highlight"
+)
+
+// maxNoteSearchResults caps the number of results a note search returns
+const maxNoteSearchResults = 100
+
+// NoteSearchResult is a note or highlight matching a search, with the book it belongs to
+type NoteSearchResult struct {
+	Kind      string    `json:"kind"`
+	ID        uint      `json:"id"`
+	BookID    uint      `json:"book_id"`
+	BookTitle string    `json:"book_title"`
+	Text      string    `json:"text"`
+	Comment   string    `json:"comment,omitempty"`
+	Page      *uint     `json:"page"`
+	Location  string    `json:"location"`
+	Chapter   string    `json:"chapter"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type NoteService interface {
+	ListNotes(ctx context.Context, userID string, bookID string) ([]models.Note, error)
+	AddNote(ctx context.Context, userID string, bookID string, note models.Note) (*models.Note, error)
+	UpdateNote(ctx context.Context, userID string, bookID string, noteID string, note models.Note) (*models.Note, error)
+	DeleteNote(ctx context.Context, userID string, bookID string, noteID string) error
+	ListHighlights(ctx context.Context, userID string, bookID string) ([]models.Highlight, error)
+	AddHighlight(ctx context.Context, userID string, bookID string, highlight models.Highlight) (*models.Highlight, error)
+	UpdateHighlight(ctx context.Context, userID string, bookID string, highlightID string, highlight models.Highlight) (*models.Highlight, error)
+	DeleteHighlight(ctx context.Context, userID string, bookID string, highlightID string) error
+	SearchNotes(ctx context.Context, userID string, query string) ([]NoteSearchResult, error)
+}
+
+type noteService struct {
+	DB *gorm.DB
+}
+
+func NewNoteService(db *gorm.DB) NoteService {
+	return &noteService{
+		DB: db,
+	}
+}
+
+// findUserBook loads a non-deleted book owned by the user
+func (s *noteService) findUserBook(auth0ID string, bookID string) (*models.User, *models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, auth0ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var book models.Book
+	if err := s.DB.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+		return nil, nil, err
+	}
+	return user, &book, nil
+}
+
+// ListNotes returns the book's notes in reading order, with notes without a page last
+func (s *noteService) ListNotes(ctx context.Context, userID string, bookID string) ([]models.Note, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := []models.Note{}
+	if err := s.DB.Where("book_id = ? AND user_id = ?", book.ID, user.ID).
+		Order(noteOrder).
+		Find(&notes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notes: %v", err)
+	}
+	return notes, nil
+}
+
+// AddNote saves a note against the book. The created date may be given to
+// keep the date of notes brought over from elsewhere.
+func (s *noteService) AddNote(ctx context.Context, userID string, bookID string, note models.Note) (*models.Note, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	note.Text, note.Location, note.Chapter, err = validateNote(note.Text, note.Page, note.Location, note.Chapter, book)
+	if err != nil {
+		return nil, err
+	}
+	if note.CreatedAt.After(time.Now().Add(24 * time.Hour)) {
+		return nil, newValidationError("created date cannot be in the future")
+	}
+
+	note.ID = 0
+	note.BookID = book.ID
+	note.UserID = user.ID
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&note).Error; err != nil {
+			return fmt.Errorf("failed to create note: %v", err)
+		}
+		return indexBook(tx, book.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// UpdateNote replaces the text and position of an existing note
+func (s *noteService) UpdateNote(ctx context.Context, userID string, bookID string, noteID string, note models.Note) (*models.Note, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing models.Note
+	if err := s.DB.Where("id = ? AND book_id = ? AND user_id = ?", noteID, book.ID, user.ID).First(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	existing.Text, existing.Location, existing.Chapter, err = validateNote(note.Text, note.Page, note.Location, note.Chapter, book)
+	if err != nil {
+		return nil, err
+	}
+	existing.Page = note.Page
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&existing).Error; err != nil {
+			return fmt.Errorf("failed to update note: %v", err)
+		}
+		return indexBook(tx, book.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// DeleteNote removes a note from the book
+func (s *noteService) DeleteNote(ctx context.Context, userID string, bookID string, noteID string) error {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return err
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND book_id = ? AND user_id = ?", noteID, book.ID, user.ID).Delete(&models.Note{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete note: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return indexBook(tx, book.ID)
+	})
+}
+
+// ListHighlights returns the book's highlights in reading order, with highlights without a page last
+func (s *noteService) ListHighlights(ctx context.Context, userID string, bookID string) ([]models.Highlight, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	highlights := []models.Highlight{}
+	if err := s.DB.Where("book_id = ? AND user_id = ?", book.ID, user.ID).
+		Order(noteOrder).
+		Find(&highlights).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch highlights: %v", err)
+	}
+	return highlights, nil
+}
+
+// AddHighlight saves a quoted passage against the book
+func (s *noteService) AddHighlight(ctx context.Context, userID string, bookID string, highlight models.Highlight) (*models.Highlight, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	highlight.Text, highlight.Location, highlight.Chapter, err = validateNote(highlight.Text, highlight.Page, highlight.Location, highlight.Chapter, book)
+	if err != nil {
+		return nil, err
+	}
+	if highlight.Comment, err = validateNoteComment(highlight.Comment); err != nil {
+		return nil, err
+	}
+	if highlight.CreatedAt.After(time.Now().Add(24 * time.Hour)) {
+		return nil, newValidationError("created date cannot be in the future")
+	}
+
+	highlight.ID = 0
+	highlight.BookID = book.ID
+	highlight.UserID = user.ID
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&highlight).Error; err != nil {
+			return fmt.Errorf("failed to create highlight: %v", err)
+		}
+		return indexBook(tx, book.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &highlight, nil
+}
+
+// UpdateHighlight replaces the passage, comment and position of an existing highlight
+func (s *noteService) UpdateHighlight(ctx context.Context, userID string, bookID string, highlightID string, highlight models.Highlight) (*models.Highlight, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing models.Highlight
+	if err := s.DB.Where("id = ? AND book_id = ? AND user_id = ?", highlightID, book.ID, user.ID).First(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	existing.Text, existing.Location, existing.Chapter, err = validateNote(highlight.Text, highlight.Page, highlight.Location, highlight.Chapter, book)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Comment, err = validateNoteComment(highlight.Comment); err != nil {
+		return nil, err
+	}
+	existing.Page = highlight.Page
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&existing).Error; err != nil {
+			return fmt.Errorf("failed to update highlight: %v", err)
+		}
+		return indexBook(tx, book.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// DeleteHighlight removes a highlight from the book
+func (s *noteService) DeleteHighlight(ctx context.Context, userID string, bookID string, highlightID string) error {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return err
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND book_id = ? AND user_id = ?", highlightID, book.ID, user.ID).Delete(&models.Highlight{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete highlight: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return indexBook(tx, book.ID)
+	})
+}
+
+// SearchNotes finds the notes and highlights on the user's books that contain
+// every word of the query, in their text, comment or chapter. Results are
+// ranked by how often the words occur, then newest first.
+func (s *noteService) SearchNotes(ctx context.Context, userID string, query string) ([]NoteSearchResult, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, newValidationError("a search query is required")
+	}
+
+	var notes []models.Note
+	noteQuery := s.DB.Model(&models.Note{}).
+		Joins("JOIN books ON books.id = notes.book_id AND books.deleted_at IS NULL").
+		Where("notes.user_id = ?", user.ID)
+	for _, term := range terms {
+		pattern := "%" + term + "%"
+		noteQuery = noteQuery.Where("LOWER(notes.text) LIKE ? OR LOWER(notes.chapter) LIKE ?", pattern, pattern)
+	}
+	if err := noteQuery.Order("notes.created_at desc").Limit(maxNoteSearchResults).Find(&notes).Error; err != nil {
+		return nil, fmt.Errorf("failed to search notes: %v", err)
+	}
+
+	var highlights []models.Highlight
+	highlightQuery := s.DB.Model(&models.Highlight{}).
+		Joins("JOIN books ON books.id = highlights.book_id AND books.deleted_at IS NULL").
+		Where("highlights.user_id = ?", user.ID)
+	for _, term := range terms {
+		pattern := "%" + term + "%"
+		highlightQuery = highlightQuery.Where("LOWER(highlights.text) LIKE ? OR LOWER(highlights.comment) LIKE ? OR LOWER(highlights.chapter) LIKE ?", pattern, pattern, pattern)
+	}
+	if err := highlightQuery.Order("highlights.created_at desc").Limit(maxNoteSearchResults).Find(&highlights).Error; err != nil {
+		return nil, fmt.Errorf("failed to search highlights: %v", err)
+	}
+
+	results := make([]NoteSearchResult, 0, len(notes)+len(highlights))
+	for _, note := range notes {
+		results = append(results, NoteSearchResult{
+			Kind: NoteKindNote, ID: note.ID, BookID: note.BookID, Text: note.Text,
+			Page: note.Page, Location: note.Location, Chapter: note.Chapter, CreatedAt: note.CreatedAt,
+		})
+	}
+	for _, highlight := range highlights {
+		results = append(results, NoteSearchResult{
+			Kind: NoteKindHighlight, ID: highlight.ID, BookID: highlight.BookID, Text: highlight.Text, Comment: highlight.Comment,
+			Page: highlight.Page, Location: highlight.Location, Chapter: highlight.Chapter, CreatedAt: highlight.CreatedAt,
+		})
+	}
+
+	scores := make([]int, len(results))
+	for i, result := range results {
+		text := strings.ToLower(result.Text + " " + result.Comment + " " + result.Chapter)
+		for _, term := range terms {
+			scores[i] += strings.Count(text, term)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if scores[i] != scores[j] {
+			return scores[i] > scores[j]
+		}
+		return results[i].CreatedAt.After(results[j].CreatedAt)
+	})
+	if len(results) > maxNoteSearchResults {
+		results = results[:maxNoteSearchResults]
+	}
+
+	if err := attachNoteBookTitles(s.DB, results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// noteOrder sorts notes and highlights by page, with those without a page last
+const noteOrder = "page IS NULL, page asc, created_at asc, id asc"
+
+// validateNote trims the text, location and chapter of a note or highlight and
+// checks the page against the book
+func validateNote(text string, page *uint, location string, chapter string, book *models.Book) (string, string, string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", "", "", newValidationError("text is required")
+	}
+	if len(text) > models.MaxNoteLength {
+		return "", "", "", newValidationError("text cannot be longer than %d characters", models.MaxNoteLength)
+	}
+	if page != nil && book.PageCount > 0 && *page > book.PageCount {
+		return "", "", "", newValidationError("page %d is beyond the book's page count of %d", *page, book.PageCount)
+	}
+	return text, strings.TrimSpace(location), strings.TrimSpace(chapter), nil
+}
+
+func validateNoteComment(comment string) (string, error) {
+	comment = strings.TrimSpace(comment)
+	if len(comment) > models.MaxNoteLength {
+		return "", newValidationError("comment cannot be longer than %d characters", models.MaxNoteLength)
+	}
+	return comment, nil
+}
+
+// attachNoteBookTitles fills in the title of each result's book
+func attachNoteBookTitles(db *gorm.DB, results []NoteSearchResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(results))
+	for i, result := range results {
+		ids[i] = result.BookID
+	}
+
+	var books []models.Book
+	if err := db.Select("id", "title").Where("id IN ?", ids).Find(&books).Error; err != nil {
+		return fmt.Errorf("failed to fetch note books: %v", err)
+	}
+	titles := make(map[uint]string, len(books))
+	for _, book := range books {
+		titles[book.ID] = book.Title
+	}
+	for i := range results {
+		results[i].BookTitle = titles[results[i].BookID]
+	}
+	return nil
+}
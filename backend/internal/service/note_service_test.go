@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNoteLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewNoteService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	book := models.Book{Title: "Dune", Author: "Frank Herbert", PageCount: 412, UserID: user.ID}
+	assert.NoError(t, db.Create(&book).Error)
+	bookID := fmt.Sprintf("%d", book.ID)
+
+	_, err := service.AddNote(ctx, user.Auth0ID, bookID, models.Note{Text: "  "})
+	assert.True(t, IsValidationError(err))
+
+	page := uint(500)
+	_, err = service.AddNote(ctx, user.Auth0ID, bookID, models.Note{Text: "Too far", Page: &page})
+	assert.True(t, IsValidationError(err))
+
+	_, err = service.AddNote(ctx, user.Auth0ID, "9999", models.Note{Text: "No book"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	page = 200
+	imported := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	later, err := service.AddNote(ctx, user.Auth0ID, bookID, models.Note{Text: " The spice must flow ", Page: &page, Chapter: " Book Two "})
+	assert.NoError(t, err)
+	assert.Equal(t, "The spice must flow", later.Text)
+	assert.Equal(t, "Book Two", later.Chapter)
+
+	page = 10
+	earlier, err := service.AddNote(ctx, user.Auth0ID, bookID, models.Note{Text: "Fear is the mind-killer", Page: &page, CreatedAt: imported})
+	assert.NoError(t, err)
+	assert.Equal(t, imported, earlier.CreatedAt.UTC())
+
+	_, err = service.AddNote(ctx, user.Auth0ID, bookID, models.Note{Text: "Loose thought"})
+	assert.NoError(t, err)
+
+	notes, err := service.ListNotes(ctx, user.Auth0ID, bookID)
+	assert.NoError(t, err)
+	assert.Len(t, notes, 3)
+	assert.Equal(t, earlier.ID, notes[0].ID)
+	assert.Equal(t, later.ID, notes[1].ID)
+	assert.Nil(t, notes[2].Page)
+
+	noteID := fmt.Sprintf("%d", later.ID)
+	updated, err := service.UpdateNote(ctx, user.Auth0ID, bookID, noteID, models.Note{Text: "The spice must flow!", Location: "Loc 3021"})
+	assert.NoError(t, err)
+	assert.Equal(t, "The spice must flow!", updated.Text)
+	assert.Nil(t, updated.Page)
+	assert.Equal(t, "Loc 3021", updated.Location)
+
+	assert.NoError(t, service.DeleteNote(ctx, user.Auth0ID, bookID, noteID))
+	assert.ErrorIs(t, service.DeleteNote(ctx, user.Auth0ID, bookID, noteID), gorm.ErrRecordNotFound)
+
+	highlight, err := service.AddHighlight(ctx, user.Auth0ID, bookID, models.Highlight{Text: "I must not fear.", Comment: "Litany"})
+	assert.NoError(t, err)
+	highlightID := fmt.Sprintf("%d", highlight.ID)
+	highlight, err = service.UpdateHighlight(ctx, user.Auth0ID, bookID, highlightID, models.Highlight{Text: "I must not fear.", Comment: "Litany against fear"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Litany against fear", highlight.Comment)
+
+	highlights, err := service.ListHighlights(ctx, user.Auth0ID, bookID)
+	assert.NoError(t, err)
+	assert.Len(t, highlights, 1)
+
+	assert.NoError(t, service.DeleteHighlight(ctx, user.Auth0ID, bookID, highlightID))
+	highlights, err = service.ListHighlights(ctx, user.Auth0ID, bookID)
+	assert.NoError(t, err)
+	assert.Empty(t, highlights)
+}
+
+func TestSearchNotes(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewNoteService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	dune := models.Book{Title: "Dune", Author: "Frank Herbert", UserID: user.ID}
+	emma := models.Book{Title: "Emma", Author: "Jane Austen", UserID: user.ID}
+	gone := models.Book{Title: "Gone", Author: "Someone", UserID: user.ID}
+	assert.NoError(t, db.Create(&[]*models.Book{&dune, &emma, &gone}).Error)
+
+	assert.NoError(t, db.Create(&models.Note{BookID: dune.ID, UserID: user.ID, Text: "Fear and more fear on Arrakis"}).Error)
+	assert.NoError(t, db.Create(&models.Highlight{BookID: dune.ID, UserID: user.ID, Text: "Fear is the mind-killer.", Comment: "Arrakis"}).Error)
+	assert.NoError(t, db.Create(&models.Note{BookID: emma.ID, UserID: user.ID, Text: "No fear here, only matchmaking"}).Error)
+	assert.NoError(t, db.Create(&models.Note{BookID: gone.ID, UserID: user.ID, Text: "Fear on Arrakis too"}).Error)
+	assert.NoError(t, db.Delete(&gone).Error)
+
+	results, err := service.SearchNotes(ctx, user.Auth0ID, "FEAR arrakis")
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, NoteKindNote, results[0].Kind)
+	assert.Equal(t, "Dune", results[0].BookTitle)
+	assert.Equal(t, NoteKindHighlight, results[1].Kind)
+
+	results, err = service.SearchNotes(ctx, user.Auth0ID, "matchmaking")
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Emma", results[0].BookTitle)
+
+	_, err = service.SearchNotes(ctx, user.Auth0ID, "   ")
+	assert.True(t, IsValidationError(err))
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type ReadThroughService interface {
+	ListReadThroughs(ctx context.Context, userID string, bookID string) ([]models.ReadThrough, error)
+	StartReread(ctx context.Context, userID string, bookID string, startedAt time.Time, notes string) (*models.Book, error)
+	DeleteReadThrough(ctx context.Context, userID string, bookID string, readID string) error
+}
+
+type readThroughService struct {
+	DB *gorm.DB
+}
+
+func NewReadThroughService(db *gorm.DB) ReadThroughService {
+	return &readThroughService{
+		DB: db,
+	}
+}
+
+// ListReadThroughs returns the book's earlier reads, oldest first
+func (s *readThroughService) ListReadThroughs(ctx context.Context, userID string, bookID string) ([]models.ReadThrough, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var book models.Book
+	if err := s.DB.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+		return nil, err
+	}
+
+	reads := []models.ReadThrough{}
+	if err := s.DB.Where("book_id = ?", book.ID).Order("finished_at asc, id asc").Find(&reads).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch read-throughs: %v", err)
+	}
+	return reads, nil
+}
+
+// StartReread archives the book's current read as a read-through and starts a new one
+func (s *readThroughService) StartReread(ctx context.Context, userID string, bookID string, startedAt time.Time, notes string) (*models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if startedAt.IsZero() {
+		startedAt = time.Now()
+	}
+
+	var book models.Book
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+			return err
+		}
+		if book.Status != models.StatusFinished && book.Status != models.StatusDidNotFinish {
+			return newValidationError("only finished or abandoned books can be read again")
+		}
+		if book.FinishedAt != nil && startedAt.Before(*book.FinishedAt) {
+			return newValidationError("a re-read cannot start before the previous read finished")
+		}
+
+		read := models.ReadThrough{
+			BookID:     book.ID,
+			UserID:     user.ID,
+			StartedAt:  book.StartedAt,
+			FinishedAt: book.FinishedAt,
+			Rating:     book.Rating,
+			Notes:      notes,
+		}
+		if err := tx.Create(&read).Error; err != nil {
+			return fmt.Errorf("failed to archive read-through: %v", err)
+		}
+
+		if err := book.TransitionTo(models.StatusReading, startedAt, nil); err != nil {
+			return newValidationError(err.Error())
+		}
+		return tx.Model(&book).Updates(map[string]interface{}{
+			"status":       book.Status,
+			"started_at":   book.StartedAt,
+			"finished_at":  book.FinishedAt,
+			"stopped_at":   book.StoppedAt,
+			"stopped_page": book.StoppedPage,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.Preload("ReadThroughs").First(&book, book.ID).Error; err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// DeleteReadThrough removes an earlier read that was recorded by mistake
+func (s *readThroughService) DeleteReadThrough(ctx context.Context, userID string, bookID string, readID string) error {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var read models.ReadThrough
+		if err := tx.Where("id = ? AND book_id = ? AND user_id = ?", readID, bookID, user.ID).First(&read).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&read).Error; err != nil {
+			return fmt.Errorf("failed to delete read-through: %v", err)
+		}
+		return NewGoalService(tx).RecomputeGoalHistory(ctx, userID, finishedOn(read.FinishedAt))
+	})
+}
@@ -0,0 +1,430 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type ReadingSessionService interface {
+	ListSessions(ctx context.Context, userID string, bookID string) ([]models.ReadingSession, error)
+	AddSession(ctx context.Context, userID string, bookID string, session models.ReadingSession) (*models.ReadingSession, error)
+	UpdateSession(ctx context.Context, userID string, bookID string, sessionID string, session models.ReadingSession) (*models.ReadingSession, error)
+	DeleteSession(ctx context.Context, userID string, bookID string, sessionID string) error
+	GetReadingSpeed(ctx context.Context, userID string, bookID string) (*ReadingSpeed, error)
+}
+
+// ReadingSpeed is how quickly the user is getting through a book, measured in
+// the book's progress unit. Only sessions that recorded the time spent count
+// towards the speed.
+type ReadingSpeed struct {
+	Unit             string   `json:"unit"`
+	Minutes          uint     `json:"minutes"`
+	Progress         float64  `json:"progress"`
+	PerHour          *float64 `json:"per_hour"`
+	Remaining        *float64 `json:"remaining"`
+	MinutesRemaining *uint    `json:"minutes_remaining"`
+}
+
+type readingSessionService struct {
+	DB *gorm.DB
+}
+
+func NewReadingSessionService(db *gorm.DB) ReadingSessionService {
+	return &readingSessionService{
+		DB: db,
+	}
+}
+
+// findUserBook loads a non-deleted book owned by the user
+func (s *readingSessionService) findUserBook(auth0ID string, bookID string) (*models.User, *models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, auth0ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var book models.Book
+	if err := s.DB.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+		return nil, nil, err
+	}
+	return user, &book, nil
+}
+
+// ListSessions returns the book's sessions, oldest first
+func (s *readingSessionService) ListSessions(ctx context.Context, userID string, bookID string) ([]models.ReadingSession, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []models.ReadingSession{}
+	if err := s.DB.Where("book_id = ? AND user_id = ?", book.ID, user.ID).
+		Order("date asc, id asc").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch reading sessions: %v", err)
+	}
+	return sessions, nil
+}
+
+// AddSession logs a session against the book. Logging progress on a book that
+// hasn't been started yet marks it as being read.
+func (s *readingSessionService) AddSession(ctx context.Context, userID string, bookID string, session models.ReadingSession) (*models.ReadingSession, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Date.IsZero() {
+		session.Date = time.Now()
+	}
+	if err := validateSession(session, book); err != nil {
+		return nil, err
+	}
+
+	session.ID = 0
+	session.BookID = book.ID
+	session.UserID = user.ID
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return fmt.Errorf("failed to create reading session: %v", err)
+		}
+
+		if book.Status == models.StatusWantToRead || book.Status == models.StatusPaused {
+			if err := book.TransitionTo(models.StatusReading, session.Date, nil); err != nil {
+				return err
+			}
+			if err := tx.Model(book).Updates(map[string]interface{}{
+				"status":       book.Status,
+				"started_at":   book.StartedAt,
+				"stopped_at":   book.StoppedAt,
+				"stopped_page": book.StoppedPage,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to update book status: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// UpdateSession replaces the values of an existing session
+func (s *readingSessionService) UpdateSession(ctx context.Context, userID string, bookID string, sessionID string, session models.ReadingSession) (*models.ReadingSession, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing models.ReadingSession
+	if err := s.DB.Where("id = ? AND book_id = ? AND user_id = ?", sessionID, book.ID, user.ID).First(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	if session.Date.IsZero() {
+		session.Date = existing.Date
+	}
+	if err := validateSession(session, book); err != nil {
+		return nil, err
+	}
+
+	existing.Date = session.Date
+	existing.StartPage = session.StartPage
+	existing.EndPage = session.EndPage
+	existing.StartPercent = session.StartPercent
+	existing.EndPercent = session.EndPercent
+	existing.StartMinute = session.StartMinute
+	existing.EndMinute = session.EndMinute
+	existing.Minutes = session.Minutes
+
+	if err := s.DB.Save(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to update reading session: %v", err)
+	}
+	return &existing, nil
+}
+
+// DeleteSession removes a session from the book
+func (s *readingSessionService) DeleteSession(ctx context.Context, userID string, bookID string, sessionID string) error {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return err
+	}
+
+	result := s.DB.Where("id = ? AND book_id = ? AND user_id = ?", sessionID, book.ID, user.ID).Delete(&models.ReadingSession{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete reading session: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetReadingSpeed works out the user's pace through the book from its sessions
+// and, when the length of the book is known, how long is left at that pace
+func (s *readingSessionService) GetReadingSpeed(ctx context.Context, userID string, bookID string) (*ReadingSpeed, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []models.ReadingSession
+	if err := s.DB.Where("book_id = ? AND user_id = ?", book.ID, user.ID).
+		Order("date asc, id asc").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch reading sessions: %v", err)
+	}
+	return readingSpeed(book, sessions), nil
+}
+
+// readingSpeed adds up the progress and time of the sessions in the book's
+// unit. A session without a start point picks up where the previous one ended,
+// or at the beginning of the book for the first session.
+func readingSpeed(book *models.Book, sessions []models.ReadingSession) *ReadingSpeed {
+	speed := &ReadingSpeed{Unit: book.ProgressUnit()}
+
+	position := 0.0
+	for _, session := range sessions {
+		start, end, ok := sessionRange(session, book)
+		if !ok {
+			continue
+		}
+		if start == nil {
+			start = &position
+		}
+		if session.Minutes > 0 && *end > *start {
+			speed.Minutes += session.Minutes
+			speed.Progress += *end - *start
+		}
+		position = *end
+	}
+
+	if speed.Minutes > 0 && speed.Progress > 0 {
+		perHour := speed.Progress / float64(speed.Minutes) * 60
+		speed.PerHour = &perHour
+	}
+
+	var length float64
+	switch speed.Unit {
+	case models.UnitPages:
+		length = float64(book.PageCount)
+	case models.UnitPercent:
+		length = 100
+	case models.UnitMinutes:
+		length = float64(book.DurationMinutes)
+	}
+	if length > 0 {
+		remaining := math.Max(length-position, 0)
+		speed.Remaining = &remaining
+		if speed.PerHour != nil {
+			minutes := uint(math.Ceil(remaining / *speed.PerHour * 60))
+			speed.MinutesRemaining = &minutes
+		}
+	}
+	return speed
+}
+
+// sessionRange converts a session's start and end points into the book's
+// progress unit. ok is false when the session can't be converted, such as
+// pages logged against a book without a page count.
+func sessionRange(session models.ReadingSession, book *models.Book) (start *float64, end *float64, ok bool) {
+	var unit string
+	var from, to *float64
+	switch {
+	case session.EndPage != nil:
+		unit, from, to = models.UnitPages, uintToFloat(session.StartPage), uintToFloat(session.EndPage)
+	case session.EndMinute != nil:
+		unit, from, to = models.UnitMinutes, uintToFloat(session.StartMinute), uintToFloat(session.EndMinute)
+	case session.EndPercent != nil:
+		unit, from, to = models.UnitPercent, session.StartPercent, session.EndPercent
+	default:
+		return nil, nil, false
+	}
+
+	last, ok := convertProgress(*to, unit, book)
+	if !ok {
+		return nil, nil, false
+	}
+	if from != nil {
+		first, _ := convertProgress(*from, unit, book)
+		start = &first
+	}
+	return start, &last, true
+}
+
+// convertProgress converts a position from one progress unit into the book's,
+// going through a percentage when the units differ
+func convertProgress(value float64, unit string, book *models.Book) (float64, bool) {
+	target := book.ProgressUnit()
+	if unit == target {
+		return value, true
+	}
+
+	lengths := map[string]float64{
+		models.UnitPages:   float64(book.PageCount),
+		models.UnitPercent: 100,
+		models.UnitMinutes: float64(book.DurationMinutes),
+	}
+	if lengths[unit] == 0 || lengths[target] == 0 {
+		return 0, false
+	}
+	return value / lengths[unit] * lengths[target], true
+}
+
+func uintToFloat(value *uint) *float64 {
+	if value == nil {
+		return nil
+	}
+	f := float64(*value)
+	return &f
+}
+
+// validateSession checks the session's progress values against the book.
+// Audiobooks are tracked in minutes or percent, everything else in pages or
+// percent.
+func validateSession(session models.ReadingSession, book *models.Book) error {
+	hasPages := session.StartPage != nil || session.EndPage != nil
+	hasPercent := session.StartPercent != nil || session.EndPercent != nil
+	hasMinutes := session.StartMinute != nil || session.EndMinute != nil
+
+	kinds := 0
+	for _, has := range []bool{hasPages, hasPercent, hasMinutes} {
+		if has {
+			kinds++
+		}
+	}
+	if kinds == 0 {
+		return newValidationError("a session needs page, percent or minute progress")
+	}
+	if kinds > 1 {
+		return newValidationError("a session can record only one of pages, percent or minutes")
+	}
+
+	audiobook := book.Format == models.FormatAudiobook
+	if hasPages && audiobook {
+		return newValidationError("audiobook progress is recorded in minutes or percent")
+	}
+	if hasMinutes && !audiobook {
+		return newValidationError("minute progress can only be recorded for audiobooks")
+	}
+
+	if hasPages {
+		if session.EndPage == nil {
+			return newValidationError("end_page is required")
+		}
+		if session.StartPage != nil && *session.StartPage > *session.EndPage {
+			return newValidationError("start_page cannot be after end_page")
+		}
+		if book.PageCount > 0 && *session.EndPage > book.PageCount {
+			return newValidationError("end_page %d is beyond the book's page count of %d", *session.EndPage, book.PageCount)
+		}
+	}
+
+	if hasMinutes {
+		if session.EndMinute == nil {
+			return newValidationError("end_minute is required")
+		}
+		if session.StartMinute != nil && *session.StartMinute > *session.EndMinute {
+			return newValidationError("start_minute cannot be after end_minute")
+		}
+		if book.DurationMinutes > 0 && *session.EndMinute > book.DurationMinutes {
+			return newValidationError("end_minute %d is beyond the book's duration of %d minutes", *session.EndMinute, book.DurationMinutes)
+		}
+	}
+
+	if hasPercent {
+		if session.EndPercent == nil {
+			return newValidationError("end_percent is required")
+		}
+		for _, percent := range []*float64{session.StartPercent, session.EndPercent} {
+			if percent != nil && (*percent < 0 || *percent > 100) {
+				return newValidationError("percent must be between 0 and 100")
+			}
+		}
+		if session.StartPercent != nil && *session.StartPercent > *session.EndPercent {
+			return newValidationError("start_percent cannot be after end_percent")
+		}
+	}
+
+	if session.Date.After(time.Now().Add(24 * time.Hour)) {
+		return newValidationError("session date cannot be in the future")
+	}
+	return nil
+}
+
+// attachProgress fills in CurrentPage, CurrentMinute and ProgressPercent from
+// each book's most recent session
+func attachProgress(db *gorm.DB, books []models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+
+	var sessions []models.ReadingSession
+	if err := db.Where("book_id IN ?", ids).Order("date asc, id asc").Find(&sessions).Error; err != nil {
+		return fmt.Errorf("failed to fetch reading sessions: %v", err)
+	}
+
+	latest := make(map[uint]models.ReadingSession, len(sessions))
+	for _, session := range sessions {
+		latest[session.BookID] = session
+	}
+
+	for i := range books {
+		session, ok := latest[books[i].ID]
+		if !ok {
+			continue
+		}
+		books[i].CurrentPage, books[i].CurrentMinute, books[i].ProgressPercent = sessionProgress(session, &books[i])
+	}
+	return nil
+}
+
+// sessionProgress converts a session's end point into a page, a minute and a
+// percentage, filling in whichever the book's page count and duration allow.
+// Audiobooks have no current page.
+func sessionProgress(session models.ReadingSession, book *models.Book) (page *uint, minute *uint, percent *float64) {
+	switch {
+	case session.EndPage != nil:
+		value := *session.EndPage
+		page = &value
+		if book.PageCount > 0 {
+			p := math.Min(float64(value)/float64(book.PageCount)*100, 100)
+			percent = &p
+		}
+	case session.EndMinute != nil:
+		value := *session.EndMinute
+		minute = &value
+		if book.DurationMinutes > 0 {
+			p := math.Min(float64(value)/float64(book.DurationMinutes)*100, 100)
+			percent = &p
+		}
+	case session.EndPercent != nil:
+		p := *session.EndPercent
+		percent = &p
+	}
+
+	if percent == nil {
+		return page, minute, percent
+	}
+	if page == nil && book.PageCount > 0 && book.Format != models.FormatAudiobook {
+		value := uint(math.Round(*percent / 100 * float64(book.PageCount)))
+		page = &value
+	}
+	if minute == nil && book.DurationMinutes > 0 {
+		value := uint(math.Round(*percent / 100 * float64(book.DurationMinutes)))
+		minute = &value
+	}
+	return page, minute, percent
+}
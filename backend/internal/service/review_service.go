@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type ReviewService interface {
+	ListReviews(ctx context.Context, userID string, bookID string) ([]models.Review, error)
+	AddReview(ctx context.Context, userID string, bookID string, review models.Review) (*models.Review, error)
+	UpdateReview(ctx context.Context, userID string, bookID string, reviewID string, review models.Review) (*models.Review, error)
+	DeleteReview(ctx context.Context, userID string, bookID string, reviewID string) error
+}
+
+type reviewService struct {
+	DB *gorm.DB
+}
+
+func NewReviewService(db *gorm.DB) ReviewService {
+	return &reviewService{
+		DB: db,
+	}
+}
+
+// findUserBook loads a non-deleted book owned by the user
+func (s *reviewService) findUserBook(auth0ID string, bookID string) (*models.User, *models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, auth0ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var book models.Book
+	if err := s.DB.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+		return nil, nil, err
+	}
+	return user, &book, nil
+}
+
+// ListReviews returns the book's reviews, newest first
+func (s *reviewService) ListReviews(ctx context.Context, userID string, bookID string) ([]models.Review, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := []models.Review{}
+	if err := s.DB.Where("book_id = ? AND user_id = ?", book.ID, user.ID).
+		Order("reviewed_at desc, id desc").
+		Find(&reviews).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch reviews: %v", err)
+	}
+	return reviews, nil
+}
+
+// AddReview saves a review of the book, dated today unless a date is given
+func (s *reviewService) AddReview(ctx context.Context, userID string, bookID string, review models.Review) (*models.Review, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if review.ReviewedAt.IsZero() {
+		review.ReviewedAt = time.Now()
+	}
+	if review, err = validateReview(review, user.RatingScale()); err != nil {
+		return nil, err
+	}
+
+	review.ID = 0
+	review.BookID = book.ID
+	review.UserID = user.ID
+	if err := s.DB.Create(&review).Error; err != nil {
+		return nil, fmt.Errorf("failed to create review: %v", err)
+	}
+	return &review, nil
+}
+
+// UpdateReview replaces the body, spoiler flag and ratings of an existing review
+func (s *reviewService) UpdateReview(ctx context.Context, userID string, bookID string, reviewID string, review models.Review) (*models.Review, error) {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing models.Review
+	if err := s.DB.Where("id = ? AND book_id = ? AND user_id = ?", reviewID, book.ID, user.ID).First(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	if review.ReviewedAt.IsZero() {
+		review.ReviewedAt = existing.ReviewedAt
+	}
+	if review, err = validateReview(review, user.RatingScale()); err != nil {
+		return nil, err
+	}
+
+	existing.Body = review.Body
+	existing.Spoiler = review.Spoiler
+	existing.ReviewedAt = review.ReviewedAt
+	existing.AspectRatings = review.AspectRatings
+
+	if err := s.DB.Save(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to update review: %v", err)
+	}
+	return &existing, nil
+}
+
+// DeleteReview removes a review from the book
+func (s *reviewService) DeleteReview(ctx context.Context, userID string, bookID string, reviewID string) error {
+	user, book, err := s.findUserBook(userID, bookID)
+	if err != nil {
+		return err
+	}
+
+	result := s.DB.Where("id = ? AND book_id = ? AND user_id = ?", reviewID, book.ID, user.ID).Delete(&models.Review{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete review: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// validateReview trims the body and checks the review has something in it and
+// that its aspect ratings are on the user's rating scale
+func validateReview(review models.Review, scale models.RatingScale) (models.Review, error) {
+	review.Body = strings.TrimSpace(review.Body)
+	if len(review.Body) > models.MaxReviewLength {
+		return review, newValidationError("review cannot be longer than %d characters", models.MaxReviewLength)
+	}
+	if review.Body == "" && len(review.AspectRatings) == 0 {
+		return review, newValidationError("a review needs a body or at least one aspect rating")
+	}
+	if review.ReviewedAt.After(time.Now().Add(24 * time.Hour)) {
+		return review, newValidationError("review date cannot be in the future")
+	}
+
+	ratings := models.AspectRatings{}
+	for aspect, rating := range review.AspectRatings {
+		aspect = strings.ToLower(strings.TrimSpace(aspect))
+		if !models.IsValidReviewAspect(aspect) {
+			return review, newValidationError("invalid review aspect: %s", aspect)
+		}
+		if err := scale.Check(rating); err != nil {
+			return review, newValidationError("%s %v", aspect, err)
+		}
+		ratings[aspect] = rating
+	}
+	review.AspectRatings = ratings
+	return review, nil
+}
+
+// checkRating validates a book rating against the user's rating scale
+func checkRating(user *models.User, rating float64) error {
+	if err := user.RatingScale().Check(rating); err != nil {
+		return newValidationError("%v", err)
+	}
+	return nil
+}
+
+// convertRatings moves every rating the user has given onto a new scale
+func convertRatings(tx *gorm.DB, user *models.User, from models.RatingScale, to models.RatingScale) error {
+	var books []models.Book
+	if err := tx.Unscoped().Select("id", "rating").Where("user_id = ? AND rating <> 0", user.ID).Find(&books).Error; err != nil {
+		return fmt.Errorf("failed to fetch book ratings: %v", err)
+	}
+	for _, book := range books {
+		if err := tx.Unscoped().Model(&models.Book{}).Where("id = ?", book.ID).
+			UpdateColumn("rating", to.Convert(book.Rating, from)).Error; err != nil {
+			return fmt.Errorf("failed to convert book rating: %v", err)
+		}
+	}
+
+	var reads []models.ReadThrough
+	if err := tx.Select("id", "rating").Where("user_id = ? AND rating <> 0", user.ID).Find(&reads).Error; err != nil {
+		return fmt.Errorf("failed to fetch read-through ratings: %v", err)
+	}
+	for _, read := range reads {
+		if err := tx.Model(&models.ReadThrough{}).Where("id = ?", read.ID).
+			UpdateColumn("rating", to.Convert(read.Rating, from)).Error; err != nil {
+			return fmt.Errorf("failed to convert read-through rating: %v", err)
+		}
+	}
+
+	var reviews []models.Review
+	if err := tx.Select("id", "aspect_ratings").Where("user_id = ?", user.ID).Find(&reviews).Error; err != nil {
+		return fmt.Errorf("failed to fetch review ratings: %v", err)
+	}
+	for _, review := range reviews {
+		if len(review.AspectRatings) == 0 {
+			continue
+		}
+		for aspect, rating := range review.AspectRatings {
+			review.AspectRatings[aspect] = to.Convert(rating, from)
+		}
+		if err := tx.Model(&models.Review{}).Where("id = ?", review.ID).
+			UpdateColumn("aspect_ratings", review.AspectRatings).Error; err != nil {
+			return fmt.Errorf("failed to convert review ratings: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestRatingScale(t *testing.T) {
+	scale := models.DefaultRatingScale
+	assert.NoError(t, scale.Check(0))
+	assert.NoError(t, scale.Check(4.5))
+	assert.Error(t, scale.Check(4.3))
+	assert.Error(t, scale.Check(5.5))
+	assert.Error(t, scale.Check(-1))
+
+	quarters := models.RatingScale{Max: 5, Step: 0.25}
+	assert.NoError(t, quarters.Validate())
+	assert.NoError(t, quarters.Check(4.75))
+
+	tenths := models.RatingScale{Max: 10, Step: 0.1}
+	assert.NoError(t, tenths.Validate())
+	assert.NoError(t, tenths.Check(7.3))
+
+	assert.Error(t, models.RatingScale{Max: 5, Step: 0.3}.Validate())
+	assert.Error(t, models.RatingScale{Max: 0, Step: 1}.Validate())
+	assert.Error(t, models.RatingScale{Max: 100, Step: 0.01}.Validate())
+
+	// Ratings keep their place on the scale and never drop to unrated
+	assert.Equal(t, 8.0, models.RatingScale{Max: 10, Step: 1}.Convert(4, scale))
+	assert.Equal(t, 4.5, scale.Convert(4.25, quarters))
+	assert.Equal(t, 1.0, models.RatingScale{Max: 5, Step: 1}.Convert(0.25, quarters))
+	assert.Equal(t, 0.0, scale.Convert(0, quarters))
+}
+
+func TestUpdateRatingScale(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBookService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	scale, err := service.GetRatingScale(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DefaultRatingScale, *scale)
+
+	assert.True(t, IsValidationError(service.AddBook(ctx, user.Auth0ID, models.Book{Title: "Dune", Author: "Frank Herbert", Rating: 4.25})))
+	assert.NoError(t, service.AddBook(ctx, user.Auth0ID, models.Book{Title: "Dune", Author: "Frank Herbert", Rating: 4.5}))
+	assert.NoError(t, service.AddBook(ctx, user.Auth0ID, models.Book{Title: "Emma", Author: "Jane Austen"}))
+
+	var dune models.Book
+	assert.NoError(t, db.Where("title = ?", "Dune").First(&dune).Error)
+	assert.NoError(t, db.Create(&models.ReadThrough{BookID: dune.ID, UserID: user.ID, Rating: 3}).Error)
+	review := models.Review{BookID: dune.ID, UserID: user.ID, Body: "Great", AspectRatings: models.AspectRatings{"plot": 5}}
+	assert.NoError(t, db.Create(&review).Error)
+
+	_, err = service.UpdateRatingScale(ctx, user.Auth0ID, models.RatingScale{Max: 10, Step: 0.3})
+	assert.True(t, IsValidationError(err))
+
+	scale, err = service.UpdateRatingScale(ctx, user.Auth0ID, models.RatingScale{Max: 10, Step: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, scale.Max)
+
+	var books []models.Book
+	assert.NoError(t, db.Order("title").Find(&books).Error)
+	assert.Equal(t, 9.0, books[0].Rating)
+	assert.Equal(t, 0.0, books[1].Rating)
+
+	var read models.ReadThrough
+	assert.NoError(t, db.First(&read).Error)
+	assert.Equal(t, 6.0, read.Rating)
+	assert.NoError(t, db.First(&review, review.ID).Error)
+	assert.Equal(t, 10.0, review.AspectRatings["plot"])
+
+	// The new scale is used from now on
+	rating := 7.5
+	err = service.UpdateBook(ctx, user.Auth0ID, fmt.Sprintf("%d", dune.ID), BookPatch{Rating: &rating})
+	assert.True(t, IsValidationError(err))
+	rating = 7
+	assert.NoError(t, service.UpdateBook(ctx, user.Auth0ID, fmt.Sprintf("%d", dune.ID), BookPatch{Rating: &rating}))
+}
+
+func TestReviewLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewReviewService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	book := models.Book{Title: "Dune", Author: "Frank Herbert", UserID: user.ID}
+	assert.NoError(t, db.Create(&book).Error)
+	bookID := fmt.Sprintf("%d", book.ID)
+
+	_, err := service.AddReview(ctx, user.Auth0ID, bookID, models.Review{Body: "  "})
+	assert.True(t, IsValidationError(err))
+	_, err = service.AddReview(ctx, user.Auth0ID, bookID, models.Review{Body: strings.Repeat("a", models.MaxReviewLength+1)})
+	assert.True(t, IsValidationError(err))
+	_, err = service.AddReview(ctx, user.Auth0ID, bookID, models.Review{Body: "Hmm", AspectRatings: models.AspectRatings{"vibes": 4}})
+	assert.True(t, IsValidationError(err))
+	_, err = service.AddReview(ctx, user.Auth0ID, bookID, models.Review{Body: "Hmm", AspectRatings: models.AspectRatings{"plot": 4.2}})
+	assert.True(t, IsValidationError(err))
+
+	older := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	first, err := service.AddReview(ctx, user.Auth0ID, bookID, models.Review{Body: "## First read\n\nLoved it", ReviewedAt: older})
+	assert.NoError(t, err)
+	assert.Equal(t, "## First read\n\nLoved it", first.Body)
+
+	second, err := service.AddReview(ctx, user.Auth0ID, bookID, models.Review{
+		Body:          "Paul becomes emperor",
+		Spoiler:       true,
+		AspectRatings: models.AspectRatings{" Plot ": 5, "prose": 3.5},
+	})
+	assert.NoError(t, err)
+	assert.False(t, second.ReviewedAt.IsZero())
+	assert.Equal(t, models.AspectRatings{"plot": 5, "prose": 3.5}, second.AspectRatings)
+
+	reviews, err := service.ListReviews(ctx, user.Auth0ID, bookID)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 2)
+	assert.Equal(t, second.ID, reviews[0].ID)
+	assert.True(t, reviews[0].Spoiler)
+	assert.Equal(t, 3.5, reviews[0].AspectRatings["prose"])
+
+	reviewID := fmt.Sprintf("%d", first.ID)
+	updated, err := service.UpdateReview(ctx, user.Auth0ID, bookID, reviewID, models.Review{Body: "Still great", AspectRatings: models.AspectRatings{"characters": 4}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Still great", updated.Body)
+	assert.Equal(t, older, updated.ReviewedAt.UTC())
+	assert.Equal(t, models.AspectRatings{"characters": 4}, updated.AspectRatings)
+
+	assert.NoError(t, service.DeleteReview(ctx, user.Auth0ID, bookID, reviewID))
+	assert.ErrorIs(t, service.DeleteReview(ctx, user.Auth0ID, bookID, reviewID), gorm.ErrRecordNotFound)
+	_, err = service.ListReviews(ctx, user.Auth0ID, "9999")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
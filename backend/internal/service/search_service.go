@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+
+	// maxSearchTerms caps the number of words of a query that are searched for
+	maxSearchTerms = 10
+	// maxFallbackCandidates caps the documents ranked in Go when full-text search isn't available
+	maxFallbackCandidates = 1000
+)
+
+// Snippets mark matches with these private use characters until the text around
+// them has been escaped, after which they become <mark> tags
+const (
+	snippetStart = "\uE000"
+	snippetStop  = "\uE001"
+)
+
+// searchWeights rank matches the same way as the Postgres weights A to D
+var searchWeights = struct{ Title, Author, Genre, Notes float64 }{1.0, 0.4, 0.2, 0.1}
+
+// SearchResult is a book matching a search. Snippet is HTML escaped text from
+// the book with the matching words in <mark> tags.
+type SearchResult struct {
+	Book    models.Book `json:"book"`
+	Rank    float64     `json:"rank"`
+	Snippet string      `json:"snippet"`
+}
+
+type SearchService interface {
+	Search(ctx context.Context, userID string, query string, limit int) ([]SearchResult, error)
+}
+
+type searchService struct {
+	DB *gorm.DB
+}
+
+func NewSearchService(db *gorm.DB) SearchService {
+	return &searchService{
+		DB: db,
+	}
+}
+
+// searchHit is a matching book before it is loaded
+type searchHit struct {
+	BookID  uint
+	Rank    float64
+	Snippet string
+}
+
+// Search finds the user's books whose title, author, genre, notes or highlights
+// contain every word of the query, best matches first. Words match as prefixes
+// so results show up while the user is still typing.
+func (s *searchService) Search(ctx context.Context, userID string, query string, limit int) ([]SearchResult, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, newValidationError("a search query is required")
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+
+	var hits []searchHit
+	if s.DB.Dialector.Name() == "postgres" {
+		hits, err = searchPostgres(s.DB, user.ID, terms, limit)
+	} else {
+		hits, err = searchFallback(s.DB, user.ID, terms, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.BookID
+	}
+	var books []models.Book
+	if err := s.DB.Where("id IN ?", ids).Find(&books).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch books: %v", err)
+	}
+	byID := make(map[uint]models.Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		book, ok := byID[hit.BookID]
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{Book: book, Rank: hit.Rank, Snippet: formatSnippet(hit.Snippet)})
+	}
+	return results, nil
+}
+
+// searchPostgres ranks the documents with ts_rank over the weighted tsvector
+// column, and takes the snippets from ts_headline
+func searchPostgres(db *gorm.DB, userID uint, terms []string, limit int) ([]searchHit, error) {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+	options := fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxWords=25, MinWords=8, MaxFragments=2, FragmentDelimiter=" … "`, snippetStart, snippetStop)
+
+	var hits []searchHit
+	err := db.Raw(`
+		SELECT book_searches.book_id AS book_id,
+			ts_rank(book_searches.document, query) AS rank,
+			ts_headline('english', concat_ws(' · ', book_searches.title, book_searches.author, NULLIF(book_searches.genre, ''), NULLIF(book_searches.notes, '')), query, ?) AS snippet
+		FROM book_searches
+		JOIN books ON books.id = book_searches.book_id AND books.deleted_at IS NULL
+		CROSS JOIN to_tsquery('english', ?) AS query
+		WHERE book_searches.user_id = ? AND book_searches.document @@ query
+		ORDER BY rank DESC, book_searches.book_id ASC
+		LIMIT ?`, options, strings.Join(prefixes, " & "), userID, limit).
+		Scan(&hits).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search books: %v", err)
+	}
+	return hits, nil
+}
+
+// searchFallback matches and ranks documents in Go for databases without
+// full-text search, such as the SQLite test databases. It follows the Postgres
+// search apart from stemming.
+func searchFallback(db *gorm.DB, userID uint, terms []string, limit int) ([]searchHit, error) {
+	tx := db.Model(&models.BookSearch{}).
+		Joins("JOIN books ON books.id = book_searches.book_id AND books.deleted_at IS NULL").
+		Where("book_searches.user_id = ?", userID)
+	for _, term := range terms {
+		pattern := "%" + term + "%"
+		tx = tx.Where("LOWER(book_searches.title) LIKE ? OR LOWER(book_searches.author) LIKE ? OR LOWER(book_searches.genre) LIKE ? OR LOWER(book_searches.notes) LIKE ?",
+			pattern, pattern, pattern, pattern)
+	}
+
+	var docs []models.BookSearch
+	if err := tx.Limit(maxFallbackCandidates).Find(&docs).Error; err != nil {
+		return nil, fmt.Errorf("failed to search books: %v", err)
+	}
+
+	hits := []searchHit{}
+	for _, doc := range docs {
+		fields := []struct {
+			text   string
+			weight float64
+		}{
+			{doc.Title, searchWeights.Title},
+			{doc.Author, searchWeights.Author},
+			{doc.Genre, searchWeights.Genre},
+			{doc.Notes, searchWeights.Notes},
+		}
+
+		// Like a tsquery every term has to start a word somewhere in the document
+		rank := 0.0
+		found := map[string]bool{}
+		for _, field := range fields {
+			for _, word := range searchTerms(field.text) {
+				for _, term := range terms {
+					if strings.HasPrefix(word, term) {
+						rank += field.weight
+						found[term] = true
+					}
+				}
+			}
+		}
+		if len(found) < len(terms) {
+			continue
+		}
+
+		texts := []string{}
+		for _, field := range fields {
+			if field.text != "" {
+				texts = append(texts, field.text)
+			}
+		}
+		hits = append(hits, searchHit{BookID: doc.BookID, Rank: rank, Snippet: fallbackSnippet(strings.Join(texts, " · "), terms)})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].BookID < hits[j].BookID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// fallbackSnippet cuts a window of words around the first match and marks every
+// matching word in it
+func fallbackSnippet(text string, terms []string) string {
+	words := strings.Fields(text)
+	matches := func(word string) bool {
+		for _, part := range searchTerms(word) {
+			for _, term := range terms {
+				if strings.HasPrefix(part, term) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	first := 0
+	for i, word := range words {
+		if matches(word) {
+			first = i
+			break
+		}
+	}
+	start := max(first-8, 0)
+	end := min(start+25, len(words))
+
+	snippet := make([]string, 0, end-start)
+	for _, word := range words[start:end] {
+		if matches(word) {
+			word = snippetStart + word + snippetStop
+		}
+		snippet = append(snippet, word)
+	}
+	return strings.Join(snippet, " ")
+}
+
+// formatSnippet escapes the snippet and turns the match markers into <mark> tags
+func formatSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, snippetStart, "<mark>")
+	return strings.ReplaceAll(snippet, snippetStop, "</mark>")
+}
+
+// searchTerms splits text into lowercase words of letters and digits, without
+// duplicates. Everything else is dropped, which also keeps the words safe to use
+// in a tsquery.
+func searchTerms(text string) []string {
+	terms := []string{}
+	seen := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// indexBook rebuilds the search document of a book from the book and its notes
+// and highlights. It is called whenever any of them change.
+func indexBook(tx *gorm.DB, bookID uint) error {
+	var book models.Book
+	if err := tx.Unscoped().Select("id", "user_id", "title", "author", "genre").First(&book, bookID).Error; err != nil {
+		return fmt.Errorf("failed to index book: %v", err)
+	}
+
+	var notes []string
+	if err := tx.Model(&models.Note{}).Where("book_id = ?", bookID).Order("id asc").Pluck("text", &notes).Error; err != nil {
+		return fmt.Errorf("failed to index notes: %v", err)
+	}
+	var highlights []models.Highlight
+	if err := tx.Select("text", "comment").Where("book_id = ?", bookID).Order("id asc").Find(&highlights).Error; err != nil {
+		return fmt.Errorf("failed to index highlights: %v", err)
+	}
+	for _, highlight := range highlights {
+		notes = append(notes, highlight.Text)
+		if highlight.Comment != "" {
+			notes = append(notes, highlight.Comment)
+		}
+	}
+
+	doc := models.BookSearch{
+		BookID:    book.ID,
+		UserID:    book.UserID,
+		Title:     book.Title,
+		Author:    book.Author,
+		Genre:     book.Genre,
+		Notes:     strings.Join(notes, "\n"),
+		UpdatedAt: time.Now(),
+	}
+	if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&doc).Error; err != nil {
+		return fmt.Errorf("failed to index book: %v", err)
+	}
+	return nil
+}
+
+// SetupSearchIndex adds the weighted tsvector column and its GIN index to the
+// search table on Postgres. The column is generated, so it follows every change
+// indexBook makes to the document.
+func SetupSearchIndex(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	if err := db.Exec(`
+		ALTER TABLE book_searches ADD COLUMN IF NOT EXISTS document tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(author, '')), 'B') ||
+			setweight(to_tsvector('english', coalesce(genre, '')), 'C') ||
+			setweight(to_tsvector('english', coalesce(notes, '')), 'D')
+		) STORED`).Error; err != nil {
+		return err
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_book_searches_document ON book_searches USING GIN (document)").Error
+}
+
+// BackfillSearchIndex indexes the books saved before search was added
+func BackfillSearchIndex(db *gorm.DB) error {
+	var books []models.Book
+	result := db.Unscoped().Select("id").
+		Where("id NOT IN (?)", db.Model(&models.BookSearch{}).Select("book_id")).
+		FindInBatches(&books, 200, func(tx *gorm.DB, _ int) error {
+			for _, book := range books {
+				if err := indexBook(db, book.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchBooks(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewSearchService(db)
+	books := NewBookService(db)
+	notes := NewNoteService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+	other := &models.User{Auth0ID: "other-user", Email: "other@example.com"}
+	assert.NoError(t, db.Create(other).Error)
+
+	assert.NoError(t, books.AddBook(ctx, user.Auth0ID, models.Book{Title: "Dune", Author: "Frank Herbert", Genre: "Science Fiction"}))
+	assert.NoError(t, books.AddBook(ctx, user.Auth0ID, models.Book{Title: "Children of Time", Author: "Adrian Tchaikovsky", Genre: "Science Fiction"}))
+	assert.NoError(t, books.AddBook(ctx, user.Auth0ID, models.Book{Title: "The Dune Encyclopedia", Author: "Willis McNelly", Genre: "Reference"}))
+	assert.NoError(t, books.AddBook(ctx, other.Auth0ID, models.Book{Title: "Dune Messiah", Author: "Frank Herbert"}))
+
+	var dune, children models.Book
+	assert.NoError(t, db.Where("title = ? AND user_id = ?", "Dune", user.ID).First(&dune).Error)
+	assert.NoError(t, db.Where("title = ?", "Children of Time").First(&children).Error)
+
+	_, err := service.Search(ctx, user.Auth0ID, " ?! ", 0)
+	assert.True(t, IsValidationError(err))
+
+	// Title matches rank above the same word elsewhere, and other users' books never show up
+	results, err := service.Search(ctx, user.Auth0ID, "dune", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "Dune", results[0].Book.Title)
+		assert.Equal(t, "The Dune Encyclopedia", results[1].Book.Title)
+		assert.Contains(t, results[0].Snippet, "<mark>Dune</mark>")
+	}
+
+	// Words match as prefixes and every word has to match
+	results, err = service.Search(ctx, user.Auth0ID, "scien fic", 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	results, err = service.Search(ctx, user.Auth0ID, "science herbert", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, dune.ID, results[0].Book.ID)
+	}
+	results, err = service.Search(ctx, user.Auth0ID, "dune", 1)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// Notes and highlights are searchable, and snippets are escaped
+	_, err = notes.AddNote(ctx, user.Auth0ID, fmt.Sprintf("%d", children.ID), models.Note{Text: "The spiders <Portiids> build a civilisation"})
+	assert.NoError(t, err)
+	results, err = service.Search(ctx, user.Auth0ID, "spiders", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, children.ID, results[0].Book.ID)
+		assert.Contains(t, results[0].Snippet, "<mark>spiders</mark> &lt;Portiids&gt;")
+	}
+	highlight, err := notes.AddHighlight(ctx, user.Auth0ID, fmt.Sprintf("%d", dune.ID), models.Highlight{Text: "Fear is the mind-killer", Comment: "Litany against fear"})
+	assert.NoError(t, err)
+	results, err = service.Search(ctx, user.Auth0ID, "litany", 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.NoError(t, notes.DeleteHighlight(ctx, user.Auth0ID, fmt.Sprintf("%d", dune.ID), fmt.Sprintf("%d", highlight.ID)))
+	results, err = service.Search(ctx, user.Auth0ID, "litany", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	// Updates are reindexed and deleted books drop out
+	title := "Children of Ruin"
+	assert.NoError(t, books.UpdateBook(ctx, user.Auth0ID, fmt.Sprintf("%d", children.ID), BookPatch{Title: &title}))
+	results, err = service.Search(ctx, user.Auth0ID, "ruin", 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	results, err = service.Search(ctx, user.Auth0ID, "time", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	assert.NoError(t, books.DeleteBook(ctx, user.Auth0ID, dune.ID))
+	results, err = service.Search(ctx, user.Auth0ID, "dune", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "The Dune Encyclopedia", results[0].Book.Title)
+	}
+}
+
+func TestBackfillSearchIndex(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+
+	book := models.Book{Title: "Emma", Author: "Jane Austen", UserID: user.ID}
+	assert.NoError(t, db.Create(&book).Error)
+	assert.NoError(t, BackfillSearchIndex(db))
+	assert.NoError(t, BackfillSearchIndex(db))
+
+	var doc models.BookSearch
+	assert.NoError(t, db.First(&doc, book.ID).Error)
+	assert.Equal(t, "Jane Austen", doc.Author)
+	assert.Equal(t, user.ID, doc.UserID)
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrDuplicateSeries is returned when the user already has a series with the same name
+var ErrDuplicateSeries = errors.New("a series with this name already exists")
+
+// SeriesProgress is a series along with how far the user is through it
+type SeriesProgress struct {
+	models.Series
+	OwnedVolumes    int           `json:"owned_volumes"`
+	FinishedVolumes int           `json:"finished_volumes"`
+	PercentComplete float64       `json:"percent_complete"`
+	NextUnread      *models.Book  `json:"next_unread"`
+	Books           []models.Book `json:"books,omitempty"`
+}
+
+type SeriesService interface {
+	ListSeries(ctx context.Context, userID string) ([]SeriesProgress, error)
+	GetSeries(ctx context.Context, userID string, seriesID string) (*SeriesProgress, error)
+	CreateSeries(ctx context.Context, userID string, series models.Series) (*models.Series, error)
+	UpdateSeries(ctx context.Context, userID string, seriesID string, series models.Series) (*models.Series, error)
+	DeleteSeries(ctx context.Context, userID string, seriesID string) error
+	SetBookSeries(ctx context.Context, userID string, bookID string, seriesID *uint, position *float64) (*models.Book, error)
+	NextUnread(ctx context.Context, userID string, seriesID string) (*models.Book, error)
+}
+
+type seriesService struct {
+	DB *gorm.DB
+}
+
+func NewSeriesService(db *gorm.DB) SeriesService {
+	return &seriesService{
+		DB: db,
+	}
+}
+
+// ListSeries returns the user's series by name with their progress
+func (s *seriesService) ListSeries(ctx context.Context, userID string) ([]SeriesProgress, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var series []models.Series
+	if err := s.DB.Where("user_id = ?", user.ID).Order("LOWER(name) asc, id asc").Find(&series).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch series: %v", err)
+	}
+
+	var books []models.Book
+	if err := s.DB.Where("user_id = ? AND series_id IS NOT NULL", user.ID).
+		Order(seriesOrder).
+		Find(&books).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch series books: %v", err)
+	}
+	bySeries := map[uint][]models.Book{}
+	for _, book := range books {
+		bySeries[*book.SeriesID] = append(bySeries[*book.SeriesID], book)
+	}
+
+	progress := make([]SeriesProgress, len(series))
+	for i, item := range series {
+		progress[i] = seriesProgress(item, bySeries[item.ID])
+	}
+	return progress, nil
+}
+
+// GetSeries returns a series with its progress and its books in reading order
+func (s *seriesService) GetSeries(ctx context.Context, userID string, seriesID string) (*SeriesProgress, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var series models.Series
+	if err := s.DB.Where("id = ? AND user_id = ?", seriesID, user.ID).First(&series).Error; err != nil {
+		return nil, err
+	}
+
+	books := []models.Book{}
+	if err := s.DB.Where("series_id = ?", series.ID).Order(seriesOrder).Find(&books).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch series books: %v", err)
+	}
+
+	progress := seriesProgress(series, books)
+	progress.Books = books
+	return &progress, nil
+}
+
+// CreateSeries adds a series without any books
+func (s *seriesService) CreateSeries(ctx context.Context, userID string, series models.Series) (*models.Series, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if series.Name, err = validateSeriesName(series.Name); err != nil {
+		return nil, err
+	}
+
+	created := models.Series{UserID: user.ID, Name: series.Name, TotalVolumes: series.TotalVolumes}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkSeriesNameFree(tx, user.ID, created.Name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(&created).Error; err != nil {
+			return fmt.Errorf("failed to create series: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateSeries changes the name and total volumes of a series
+func (s *seriesService) UpdateSeries(ctx context.Context, userID string, seriesID string, series models.Series) (*models.Series, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if series.Name, err = validateSeriesName(series.Name); err != nil {
+		return nil, err
+	}
+
+	var existing models.Series
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", seriesID, user.ID).First(&existing).Error; err != nil {
+			return err
+		}
+		if err := checkSeriesNameFree(tx, user.ID, series.Name, existing.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&existing).Updates(map[string]interface{}{
+			"name":          series.Name,
+			"total_volumes": series.TotalVolumes,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update series: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// DeleteSeries removes a series. Its books stay in the collection without a series.
+func (s *seriesService) DeleteSeries(ctx context.Context, userID string, seriesID string) error {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var series models.Series
+		if err := tx.Where("id = ? AND user_id = ?", seriesID, user.ID).First(&series).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Book{}).Unscoped().
+			Where("series_id = ?", series.ID).
+			Updates(map[string]interface{}{"series_id": nil, "series_position": nil}).Error; err != nil {
+			return fmt.Errorf("failed to remove books from series: %v", err)
+		}
+		if err := tx.Delete(&series).Error; err != nil {
+			return fmt.Errorf("failed to delete series: %v", err)
+		}
+		return nil
+	})
+}
+
+// SetBookSeries puts a book in a series at the given position, or takes it out
+// of its series when seriesID is nil
+func (s *seriesService) SetBookSeries(ctx context.Context, userID string, bookID string, seriesID *uint, position *float64) (*models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var book models.Book
+	if err := s.DB.Where("id = ? AND user_id = ?", bookID, user.ID).First(&book).Error; err != nil {
+		return nil, err
+	}
+
+	if seriesID == nil {
+		position = nil
+	} else {
+		var series models.Series
+		if err := s.DB.Where("id = ? AND user_id = ?", *seriesID, user.ID).First(&series).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, newValidationError("series %d not found", *seriesID)
+			}
+			return nil, err
+		}
+		if position != nil && *position < 0 {
+			return nil, newValidationError("series position cannot be negative")
+		}
+	}
+
+	if err := s.DB.Model(&book).Updates(map[string]interface{}{
+		"series_id":       seriesID,
+		"series_position": position,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update book series: %v", err)
+	}
+
+	if err := s.DB.Preload("Series").First(&book, book.ID).Error; err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// NextUnread returns the lowest-position volume of the series the user hasn't
+// finished, or nil when there is none. Books the user gave up on are skipped.
+func (s *seriesService) NextUnread(ctx context.Context, userID string, seriesID string) (*models.Book, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var series models.Series
+	if err := s.DB.Where("id = ? AND user_id = ?", seriesID, user.ID).First(&series).Error; err != nil {
+		return nil, err
+	}
+
+	var books []models.Book
+	if err := s.DB.Where("series_id = ?", series.ID).Order(seriesOrder).Find(&books).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch series books: %v", err)
+	}
+	return nextUnread(books), nil
+}
+
+// seriesOrder sorts books by position, with books of unknown position last
+const seriesOrder = "series_position IS NULL, series_position asc, id asc"
+
+// seriesProgress summarizes the books of a series, which must be in series order
+func seriesProgress(series models.Series, books []models.Book) SeriesProgress {
+	progress := SeriesProgress{
+		Series:       series,
+		OwnedVolumes: len(books),
+		NextUnread:   nextUnread(books),
+	}
+	for _, book := range books {
+		if book.Status == models.StatusFinished {
+			progress.FinishedVolumes++
+		}
+	}
+
+	// Without a known number of volumes, progress is measured against the books in the collection
+	total := int(series.TotalVolumes)
+	if total < progress.OwnedVolumes {
+		total = progress.OwnedVolumes
+	}
+	if total > 0 {
+		progress.PercentComplete = float64(progress.FinishedVolumes) / float64(total) * 100
+	}
+	return progress
+}
+
+func nextUnread(books []models.Book) *models.Book {
+	for i := range books {
+		if books[i].Status != models.StatusFinished && books[i].Status != models.StatusDidNotFinish {
+			return &books[i]
+		}
+	}
+	return nil
+}
+
+func validateSeriesName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", newValidationError("series name is required")
+	}
+	return name, nil
+}
+
+// checkSeriesNameFree makes sure no other series of the user has the name, ignoring case
+func checkSeriesNameFree(tx *gorm.DB, userID uint, name string, exceptID uint) error {
+	var count int64
+	if err := tx.Model(&models.Series{}).
+		Where("user_id = ? AND LOWER(name) = ? AND id <> ?", userID, strings.ToLower(name), exceptID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check series name: %v", err)
+	}
+	if count > 0 {
+		return ErrDuplicateSeries
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesProgressAndNextUnread(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewSeriesService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	series, err := service.CreateSeries(ctx, user.Auth0ID, models.Series{Name: "The Stormlight Archive", TotalVolumes: 10})
+	assert.NoError(t, err)
+	seriesID := fmt.Sprintf("%d", series.ID)
+
+	_, err = service.CreateSeries(ctx, user.Auth0ID, models.Series{Name: "the stormlight archive"})
+	assert.ErrorIs(t, err, ErrDuplicateSeries)
+
+	books := []models.Book{
+		{Title: "Words of Radiance", Author: "Brandon Sanderson", UserID: user.ID, Status: models.StatusWantToRead},
+		{Title: "The Way of Kings", Author: "Brandon Sanderson", UserID: user.ID, Status: models.StatusFinished},
+		{Title: "Edgedancer", Author: "Brandon Sanderson", UserID: user.ID, Status: models.StatusWantToRead},
+	}
+	assert.NoError(t, db.Create(&books).Error)
+
+	positions := []float64{2, 1, 2.5}
+	for i, book := range books {
+		updated, err := service.SetBookSeries(ctx, user.Auth0ID, fmt.Sprintf("%d", book.ID), &series.ID, &positions[i])
+		assert.NoError(t, err)
+		assert.Equal(t, series.Name, updated.Series.Name)
+	}
+
+	next, err := service.NextUnread(ctx, user.Auth0ID, seriesID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Words of Radiance", next.Title)
+
+	// Finishing volume 2 moves on to the novella at 2.5
+	assert.NoError(t, db.Model(&books[0]).Update("status", models.StatusFinished).Error)
+	next, err = service.NextUnread(ctx, user.Auth0ID, seriesID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Edgedancer", next.Title)
+
+	progress, err := service.ListSeries(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Len(t, progress, 1)
+	assert.Equal(t, 3, progress[0].OwnedVolumes)
+	assert.Equal(t, 2, progress[0].FinishedVolumes)
+	assert.Equal(t, 20.0, progress[0].PercentComplete)
+	assert.Equal(t, "Edgedancer", progress[0].NextUnread.Title)
+
+	detail, err := service.GetSeries(ctx, user.Auth0ID, seriesID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"The Way of Kings", "Words of Radiance", "Edgedancer"},
+		[]string{detail.Books[0].Title, detail.Books[1].Title, detail.Books[2].Title})
+
+	// Taking a book out of its series
+	updated, err := service.SetBookSeries(ctx, user.Auth0ID, fmt.Sprintf("%d", books[2].ID), nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, updated.SeriesID)
+	assert.Nil(t, updated.SeriesPosition)
+
+	next, err = service.NextUnread(ctx, user.Auth0ID, seriesID)
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+
+	renamed, err := service.UpdateSeries(ctx, user.Auth0ID, seriesID, models.Series{Name: "Stormlight", TotalVolumes: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, "Stormlight", renamed.Name)
+	assert.Equal(t, uint(5), renamed.TotalVolumes)
+
+	assert.NoError(t, service.DeleteSeries(ctx, user.Auth0ID, seriesID))
+	var linked int64
+	db.Model(&models.Book{}).Where("series_id IS NOT NULL").Count(&linked)
+	assert.Equal(t, int64(0), linked)
+}
+
+func TestSetBookSeriesValidation(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewSeriesService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	book := models.Book{Title: "Book", Author: "Author", UserID: user.ID}
+	assert.NoError(t, db.Create(&book).Error)
+	bookID := fmt.Sprintf("%d", book.ID)
+
+	missing := uint(999)
+	_, err := service.SetBookSeries(ctx, user.Auth0ID, bookID, &missing, nil)
+	assert.True(t, IsValidationError(err))
+
+	series, err := service.CreateSeries(ctx, user.Auth0ID, models.Series{Name: "Series"})
+	assert.NoError(t, err)
+	negative := -1.0
+	_, err = service.SetBookSeries(ctx, user.Auth0ID, bookID, &series.ID, &negative)
+	assert.True(t, IsValidationError(err))
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrDuplicateShelf is returned when the user already has a shelf with the same name
+var ErrDuplicateShelf = errors.New("a shelf with this name already exists")
+
+type ShelfService interface {
+	ListShelves(ctx context.Context, userID string) ([]models.Shelf, error)
+	CreateShelf(ctx context.Context, userID string, name string) (*models.Shelf, error)
+	RenameShelf(ctx context.Context, userID string, shelfID string, name string) (*models.Shelf, error)
+	DeleteShelf(ctx context.Context, userID string, shelfID string) error
+	AddBooks(ctx context.Context, userID string, shelfID string, bookIDs []uint) (*models.Shelf, error)
+	RemoveBooks(ctx context.Context, userID string, shelfID string, bookIDs []uint) (*models.Shelf, error)
+}
+
+type shelfService struct {
+	DB *gorm.DB
+}
+
+func NewShelfService(db *gorm.DB) ShelfService {
+	return &shelfService{
+		DB: db,
+	}
+}
+
+// ListShelves returns the user's shelves by name, with the number of books on each
+func (s *shelfService) ListShelves(ctx context.Context, userID string) ([]models.Shelf, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	shelves := []models.Shelf{}
+	if err := s.DB.Where("user_id = ?", user.ID).Order("LOWER(name) asc, id asc").Find(&shelves).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch shelves: %v", err)
+	}
+	if err := attachShelfCounts(s.DB, shelves); err != nil {
+		return nil, err
+	}
+	return shelves, nil
+}
+
+// CreateShelf adds an empty shelf
+func (s *shelfService) CreateShelf(ctx context.Context, userID string, name string) (*models.Shelf, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	name, err = validateShelfName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	shelf := models.Shelf{UserID: user.ID, Name: name}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkShelfNameFree(tx, user.ID, name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(&shelf).Error; err != nil {
+			return fmt.Errorf("failed to create shelf: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &shelf, nil
+}
+
+// RenameShelf changes the name of a shelf, keeping its books
+func (s *shelfService) RenameShelf(ctx context.Context, userID string, shelfID string, name string) (*models.Shelf, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	name, err = validateShelfName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var shelf models.Shelf
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", shelfID, user.ID).First(&shelf).Error; err != nil {
+			return err
+		}
+		if err := checkShelfNameFree(tx, user.ID, name, shelf.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&shelf).Update("name", name).Error; err != nil {
+			return fmt.Errorf("failed to rename shelf: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.shelfWithCount(shelf)
+}
+
+// DeleteShelf removes a shelf. The books on it stay in the collection.
+func (s *shelfService) DeleteShelf(ctx context.Context, userID string, shelfID string) error {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var shelf models.Shelf
+		if err := tx.Where("id = ? AND user_id = ?", shelfID, user.ID).First(&shelf).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("shelf_id = ?", shelf.ID).Delete(&models.BookShelf{}).Error; err != nil {
+			return fmt.Errorf("failed to remove books from shelf: %v", err)
+		}
+		if err := tx.Delete(&shelf).Error; err != nil {
+			return fmt.Errorf("failed to delete shelf: %v", err)
+		}
+		return nil
+	})
+}
+
+// AddBooks puts the books on the shelf. Books already on it are left alone, and
+// nothing is added if any of the books isn't in the user's collection.
+func (s *shelfService) AddBooks(ctx context.Context, userID string, shelfID string, bookIDs []uint) (*models.Shelf, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var shelf models.Shelf
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", shelfID, user.ID).First(&shelf).Error; err != nil {
+			return err
+		}
+		ids, err := ownedBookIDs(tx, user.ID, bookIDs)
+		if err != nil {
+			return err
+		}
+		return addBooksToShelf(tx, shelf.ID, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.shelfWithCount(shelf)
+}
+
+// RemoveBooks takes the books off the shelf
+func (s *shelfService) RemoveBooks(ctx context.Context, userID string, shelfID string, bookIDs []uint) (*models.Shelf, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(bookIDs) == 0 {
+		return nil, newValidationError("at least one book id is required")
+	}
+
+	var shelf models.Shelf
+	if err := s.DB.Where("id = ? AND user_id = ?", shelfID, user.ID).First(&shelf).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Where("shelf_id = ? AND book_id IN ?", shelf.ID, bookIDs).Delete(&models.BookShelf{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to remove books from shelf: %v", err)
+	}
+	return s.shelfWithCount(shelf)
+}
+
+func (s *shelfService) shelfWithCount(shelf models.Shelf) (*models.Shelf, error) {
+	shelves := []models.Shelf{shelf}
+	if err := attachShelfCounts(s.DB, shelves); err != nil {
+		return nil, err
+	}
+	return &shelves[0], nil
+}
+
+// validateShelfName trims the name and checks it isn't empty or too long
+func validateShelfName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", newValidationError("shelf name is required")
+	}
+	if len(name) > models.MaxShelfNameLength {
+		return "", newValidationError("shelf name cannot be longer than %d characters", models.MaxShelfNameLength)
+	}
+	return name, nil
+}
+
+// checkShelfNameFree makes sure no other shelf of the user has the name, ignoring case
+func checkShelfNameFree(tx *gorm.DB, userID uint, name string, exceptID uint) error {
+	var count int64
+	if err := tx.Model(&models.Shelf{}).
+		Where("user_id = ? AND LOWER(name) = ? AND id <> ?", userID, strings.ToLower(name), exceptID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check shelf name: %v", err)
+	}
+	if count > 0 {
+		return ErrDuplicateShelf
+	}
+	return nil
+}
+
+// ownedBookIDs checks that every id is one of the user's non-deleted books and
+// returns the ids without duplicates
+func ownedBookIDs(tx *gorm.DB, userID uint, bookIDs []uint) ([]uint, error) {
+	if len(bookIDs) == 0 {
+		return nil, newValidationError("at least one book id is required")
+	}
+
+	var ids []uint
+	if err := tx.Model(&models.Book{}).
+		Where("user_id = ? AND id IN ?", userID, bookIDs).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to check books: %v", err)
+	}
+
+	found := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		found[id] = true
+	}
+	for _, id := range bookIDs {
+		if !found[id] {
+			return nil, newValidationError("book %d is not in your collection", id)
+		}
+	}
+	return ids, nil
+}
+
+// addBooksToShelf inserts the join rows, skipping books that are already on the shelf
+func addBooksToShelf(tx *gorm.DB, shelfID uint, bookIDs []uint) error {
+	if len(bookIDs) == 0 {
+		return nil
+	}
+	rows := make([]models.BookShelf, len(bookIDs))
+	for i, id := range bookIDs {
+		rows[i] = models.BookShelf{BookID: id, ShelfID: shelfID}
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to add books to shelf: %v", err)
+	}
+	return nil
+}
+
+// findOrCreateShelves returns the user's shelves with the given names, creating
+// any that don't exist yet. Names are matched ignoring case.
+func findOrCreateShelves(tx *gorm.DB, userID uint, names []string) ([]models.Shelf, error) {
+	shelves := []models.Shelf{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name, err := validateShelfName(name)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		var shelf models.Shelf
+		err = tx.Where("user_id = ? AND LOWER(name) = ?", userID, key).First(&shelf).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			shelf = models.Shelf{UserID: userID, Name: name}
+			err = tx.Create(&shelf).Error
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find shelf %q: %v", name, err)
+		}
+		shelves = append(shelves, shelf)
+	}
+	return shelves, nil
+}
+
+// attachShelfCounts fills in the number of non-deleted books on each shelf
+func attachShelfCounts(db *gorm.DB, shelves []models.Shelf) error {
+	if len(shelves) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(shelves))
+	for i, shelf := range shelves {
+		ids[i] = shelf.ID
+	}
+
+	var counts []struct {
+		ShelfID uint
+		Count   int64
+	}
+	if err := db.Model(&models.BookShelf{}).
+		Select("book_shelves.shelf_id, COUNT(*) AS count").
+		Joins("JOIN books ON books.id = book_shelves.book_id AND books.deleted_at IS NULL").
+		Where("book_shelves.shelf_id IN ?", ids).
+		Group("book_shelves.shelf_id").
+		Scan(&counts).Error; err != nil {
+		return fmt.Errorf("failed to count shelf books: %v", err)
+	}
+
+	byShelf := make(map[uint]int64, len(counts))
+	for _, count := range counts {
+		byShelf[count.ShelfID] = count.Count
+	}
+	for i := range shelves {
+		shelves[i].BookCount = byShelf[shelves[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShelfLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewShelfService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	books := []models.Book{
+		{Title: "Book 1", Author: "Author", UserID: user.ID},
+		{Title: "Book 2", Author: "Author", UserID: user.ID},
+	}
+	assert.NoError(t, db.Create(&books).Error)
+
+	shelf, err := service.CreateShelf(ctx, user.Auth0ID, "  Favorites ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Favorites", shelf.Name)
+
+	_, err = service.CreateShelf(ctx, user.Auth0ID, "favorites")
+	assert.ErrorIs(t, err, ErrDuplicateShelf)
+
+	_, err = service.CreateShelf(ctx, user.Auth0ID, "")
+	assert.True(t, IsValidationError(err))
+
+	shelfID := fmt.Sprintf("%d", shelf.ID)
+	shelf, err = service.AddBooks(ctx, user.Auth0ID, shelfID, []uint{books[0].ID, books[1].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), shelf.BookCount)
+
+	// Adding a book that is already on the shelf is a no-op
+	shelf, err = service.AddBooks(ctx, user.Auth0ID, shelfID, []uint{books[0].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), shelf.BookCount)
+
+	// A book outside the collection fails the whole request
+	_, err = service.AddBooks(ctx, user.Auth0ID, shelfID, []uint{books[0].ID, 999})
+	assert.True(t, IsValidationError(err))
+
+	shelf, err = service.RemoveBooks(ctx, user.Auth0ID, shelfID, []uint{books[1].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), shelf.BookCount)
+
+	shelf, err = service.RenameShelf(ctx, user.Auth0ID, shelfID, "All-time favorites")
+	assert.NoError(t, err)
+	assert.Equal(t, "All-time favorites", shelf.Name)
+	assert.Equal(t, int64(1), shelf.BookCount)
+
+	shelves, err := service.ListShelves(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Len(t, shelves, 1)
+
+	assert.NoError(t, service.DeleteShelf(ctx, user.Auth0ID, shelfID))
+	var links int64
+	db.Model(&models.BookShelf{}).Count(&links)
+	assert.Equal(t, int64(0), links)
+	var remaining int64
+	db.Model(&models.Book{}).Count(&remaining)
+	assert.Equal(t, int64(2), remaining)
+}
+
+func TestQueryUserBooksByShelf(t *testing.T) {
+	db := setupTestDB(t)
+	shelfService := NewShelfService(db)
+	bookService := NewBookService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	books := []models.Book{
+		{Title: "Both", Author: "Author", UserID: user.ID},
+		{Title: "Fantasy only", Author: "Author", UserID: user.ID},
+		{Title: "Neither", Author: "Author", UserID: user.ID},
+	}
+	assert.NoError(t, db.Create(&books).Error)
+
+	fantasy, err := shelfService.CreateShelf(ctx, user.Auth0ID, "Fantasy")
+	assert.NoError(t, err)
+	owned, err := shelfService.CreateShelf(ctx, user.Auth0ID, "Owned")
+	assert.NoError(t, err)
+	_, err = shelfService.AddBooks(ctx, user.Auth0ID, fmt.Sprintf("%d", fantasy.ID), []uint{books[0].ID, books[1].ID})
+	assert.NoError(t, err)
+	_, err = shelfService.AddBooks(ctx, user.Auth0ID, fmt.Sprintf("%d", owned.ID), []uint{books[0].ID})
+	assert.NoError(t, err)
+
+	page, err := bookService.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Shelves: []string{"fantasy", "owned"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), page.Total)
+
+	page, err = bookService.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Shelves: []string{"fantasy", "owned"}, ShelfMatch: "and"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), page.Total)
+	assert.Equal(t, "Both", page.Books[0].Title)
+	assert.Len(t, page.Books[0].Shelves, 2)
+
+	_, err = bookService.QueryUserBooks(ctx, user.Auth0ID, BookQuery{Shelves: []string{"fantasy"}, ShelfMatch: "xor"})
+	assert.Error(t, err)
+}
+
+func TestImportCreatesShelvesFromTags(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewImportService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	_, err := service.Import(ctx, user.Auth0ID, "storygraph", strings.NewReader(storyGraphExport), false)
+	assert.NoError(t, err)
+
+	shelves, err := NewShelfService(db).ListShelves(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	names := []string{}
+	for _, shelf := range shelves {
+		names = append(names, shelf.Name)
+		assert.Equal(t, int64(1), shelf.BookCount)
+	}
+	assert.Equal(t, []string{"favourites", "mysterious", "reflective"}, names)
+}
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrDuplicateSmartShelf is returned when the user already has a smart shelf with the same name
+var ErrDuplicateSmartShelf = errors.New("a smart shelf with this name already exists")
+
+// customFieldPrefix marks smart shelf conditions on custom fields, as in custom_fields.signed
+const customFieldPrefix = "custom_fields."
+
+// The kinds of book fields a smart shelf can filter on
+const (
+	filterText   = "text"
+	filterNumber = "number"
+	filterDate   = "date"
+)
+
+// filterField is a book column a smart shelf can filter on
+type filterField struct {
+	Column   string
+	Kind     string
+	Nullable bool
+}
+
+// unfilterableBookFields are book fields that are either implied by the shelf's
+// owner or filtered some other way
+var unfilterableBookFields = map[string]bool{
+	"user_id":       true,
+	"deleted_at":    true,
+	"coverImage":    true,
+	"custom_fields": true,
+}
+
+// filterValueChecks restrict the values of fields that only take known values
+var filterValueChecks = map[string]func(string) bool{
+	"status": models.IsValidStatus,
+	"format": func(format string) bool { return format != "" && models.IsValidFormat(format) },
+}
+
+var (
+	bookFilterFieldsOnce sync.Once
+	bookFilterFields     map[string]filterField
+	bookFilterFieldsErr  error
+)
+
+// filterFields returns the fields of models.Book that smart shelves can filter
+// on, by their JSON name
+func filterFields() (map[string]filterField, error) {
+	bookFilterFieldsOnce.Do(func() {
+		bookSchema, err := schema.Parse(&models.Book{}, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			bookFilterFieldsErr = fmt.Errorf("failed to read book fields: %v", err)
+			return
+		}
+
+		bookFilterFields = map[string]filterField{}
+		for _, field := range bookSchema.Fields {
+			// Relations and derived fields have no column
+			if field.DBName == "" {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" {
+				name = field.DBName
+			}
+			if name == "-" || unfilterableBookFields[name] {
+				continue
+			}
+
+			kind := ""
+			switch fieldType := field.IndirectFieldType; {
+			case fieldType == reflect.TypeOf(time.Time{}):
+				kind = filterDate
+			case fieldType.Kind() == reflect.String:
+				kind = filterText
+			case fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Float64:
+				kind = filterNumber
+			default:
+				continue
+			}
+			bookFilterFields[name] = filterField{Column: field.DBName, Kind: kind, Nullable: field.FieldType.Kind() == reflect.Ptr}
+		}
+	})
+	return bookFilterFields, bookFilterFieldsErr
+}
+
+type SmartShelfService interface {
+	ListSmartShelves(ctx context.Context, userID string) ([]models.SmartShelf, error)
+	CreateSmartShelf(ctx context.Context, userID string, name string, filter models.ShelfFilter) (*models.SmartShelf, error)
+	EvaluateSmartShelf(ctx context.Context, userID string, shelfID string, paging BookQuery) (*BookPage, error)
+	DeleteSmartShelf(ctx context.Context, userID string, shelfID string) error
+}
+
+type smartShelfService struct {
+	DB *gorm.DB
+}
+
+func NewSmartShelfService(db *gorm.DB) SmartShelfService {
+	return &smartShelfService{
+		DB: db,
+	}
+}
+
+// ListSmartShelves returns the user's smart shelves by name, with the number of
+// books currently matching each
+func (s *smartShelfService) ListSmartShelves(ctx context.Context, userID string) ([]models.SmartShelf, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	shelves := []models.SmartShelf{}
+	if err := s.DB.Where("user_id = ?", user.ID).Order("LOWER(name) asc, id asc").Find(&shelves).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch smart shelves: %v", err)
+	}
+	for i := range shelves {
+		if shelves[i].BookCount, err = countSmartShelf(s.DB, user.ID, shelves[i].Filter); err != nil {
+			return nil, err
+		}
+	}
+	return shelves, nil
+}
+
+// CreateSmartShelf saves a filter under a name after checking it against the
+// book fields and the user's custom fields
+func (s *smartShelfService) CreateSmartShelf(ctx context.Context, userID string, name string, filter models.ShelfFilter) (*models.SmartShelf, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	name, err = validateShelfName(name)
+	if err != nil {
+		return nil, err
+	}
+	if filter, err = validateShelfFilter(s.DB, user.ID, filter); err != nil {
+		return nil, err
+	}
+
+	shelf := models.SmartShelf{UserID: user.ID, Name: name, Filter: filter}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.SmartShelf{}).
+			Where("user_id = ? AND LOWER(name) = ?", user.ID, strings.ToLower(name)).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check smart shelf name: %v", err)
+		}
+		if count > 0 {
+			return ErrDuplicateSmartShelf
+		}
+		if err := tx.Create(&shelf).Error; err != nil {
+			return fmt.Errorf("failed to create smart shelf: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if shelf.BookCount, err = countSmartShelf(s.DB, user.ID, shelf.Filter); err != nil {
+		return nil, err
+	}
+	return &shelf, nil
+}
+
+// EvaluateSmartShelf runs the shelf's filter against the collection as it is now.
+// Only the paging of the given query is used.
+func (s *smartShelfService) EvaluateSmartShelf(ctx context.Context, userID string, shelfID string, paging BookQuery) (*BookPage, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var shelf models.SmartShelf
+	if err := s.DB.Where("id = ? AND user_id = ?", shelfID, user.ID).First(&shelf).Error; err != nil {
+		return nil, err
+	}
+
+	query, err := shelfFilterQuery(shelf.Filter)
+	if err != nil {
+		return nil, err
+	}
+	query.Page = paging.Page
+	query.PageSize = paging.PageSize
+	query.Cursor = paging.Cursor
+	if err := query.Validate(); err != nil {
+		return nil, newValidationError("%v", err)
+	}
+	return NewBookService(s.DB).QueryUserBooks(ctx, userID, query)
+}
+
+// DeleteSmartShelf removes a smart shelf. No books are touched.
+func (s *smartShelfService) DeleteSmartShelf(ctx context.Context, userID string, shelfID string) error {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return err
+	}
+
+	result := s.DB.Where("id = ? AND user_id = ?", shelfID, user.ID).Delete(&models.SmartShelf{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete smart shelf: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// countSmartShelf counts the user's books matching the filter
+func countSmartShelf(db *gorm.DB, userID uint, filter models.ShelfFilter) (int64, error) {
+	query, err := shelfFilterQuery(filter)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := query.filter(db, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := tx.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count books: %v", err)
+	}
+	return count, nil
+}
+
+// validateShelfFilter tidies up the filter and checks every condition against
+// the book fields and the user's custom fields
+func validateShelfFilter(db *gorm.DB, userID uint, filter models.ShelfFilter) (models.ShelfFilter, error) {
+	if len(filter.Conditions) == 0 {
+		return filter, newValidationError("a smart shelf needs at least one condition")
+	}
+	if len(filter.Conditions) > models.MaxSmartShelfConditions {
+		return filter, newValidationError("a smart shelf cannot have more than %d conditions", models.MaxSmartShelfConditions)
+	}
+
+	conditions := make([]models.FilterCondition, len(filter.Conditions))
+	for i, condition := range filter.Conditions {
+		condition.Field = strings.TrimSpace(condition.Field)
+		condition.Op = strings.ToLower(strings.TrimSpace(condition.Op))
+		if value, ok := condition.Value.(string); ok {
+			condition.Value = strings.TrimSpace(value)
+		}
+		conditions[i] = condition
+	}
+	filter.Conditions = conditions
+	filter.Order = strings.ToLower(strings.TrimSpace(filter.Order))
+
+	query, err := shelfFilterQuery(filter)
+	if err != nil {
+		return filter, err
+	}
+	if err := query.Validate(); err != nil {
+		return filter, newValidationError("%v", err)
+	}
+	// Custom field filters are checked against the user's fields when they are applied
+	if _, err := applyCustomFieldFilters(db, db.Model(&models.Book{}), userID, query.CustomFields); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// shelfFilterQuery turns a smart shelf filter into a collection query, with the
+// conditions on custom fields becoming custom field filters
+func shelfFilterQuery(filter models.ShelfFilter) (BookQuery, error) {
+	query := BookQuery{Sort: filter.Sort, Order: filter.Order}
+	for _, condition := range filter.Conditions {
+		key, ok := strings.CutPrefix(condition.Field, customFieldPrefix)
+		if !ok {
+			query.Conditions = append(query.Conditions, condition)
+			continue
+		}
+
+		customFilter := CustomFieldFilter{Key: key}
+		switch condition.Op {
+		case models.FilterEquals:
+			customFilter.Op = CustomFieldEquals
+		case models.FilterGreaterOrEq:
+			customFilter.Op = CustomFieldMin
+		case models.FilterLessOrEq:
+			customFilter.Op = CustomFieldMax
+		default:
+			return query, newValidationError("custom fields can only be filtered with %s, %s or %s", models.FilterEquals, models.FilterGreaterOrEq, models.FilterLessOrEq)
+		}
+		switch value := condition.Value.(type) {
+		case string:
+			customFilter.Value = value
+		case float64:
+			customFilter.Value = strconv.FormatFloat(value, 'f', -1, 64)
+		case bool:
+			customFilter.Value = strconv.FormatBool(value)
+		default:
+			return query, newValidationError("invalid value for %s", condition.Field)
+		}
+		query.CustomFields = append(query.CustomFields, customFilter)
+	}
+	return query, nil
+}
+
+// filterClause turns a condition on a book field into a WHERE clause, checking
+// the operator and value against the type of the field
+func filterClause(condition models.FilterCondition) (string, []interface{}, error) {
+	fields, err := filterFields()
+	if err != nil {
+		return "", nil, err
+	}
+	field, ok := fields[condition.Field]
+	if !ok {
+		return "", nil, newValidationError("unknown filter field: %s", condition.Field)
+	}
+	column := field.Column
+
+	switch condition.Op {
+	case models.FilterExists:
+		exists, ok := condition.Value.(bool)
+		if !ok {
+			return "", nil, newValidationError("%s %s takes true or false", condition.Field, condition.Op)
+		}
+		switch {
+		case field.Kind == filterText && exists:
+			return "(" + column + " IS NOT NULL AND " + column + " <> '')", nil, nil
+		case field.Kind == filterText:
+			return "(" + column + " IS NULL OR " + column + " = '')", nil, nil
+		case !field.Nullable:
+			return "", nil, newValidationError("%s always has a value", condition.Field)
+		case exists:
+			return column + " IS NOT NULL", nil, nil
+		default:
+			return column + " IS NULL", nil, nil
+		}
+
+	case models.FilterIn:
+		list, ok := condition.Value.([]interface{})
+		if !ok || len(list) == 0 {
+			return "", nil, newValidationError("%s %s takes a list of values", condition.Field, condition.Op)
+		}
+		values := make([]interface{}, len(list))
+		for i, item := range list {
+			if values[i], err = filterValue(condition.Field, field, item); err != nil {
+				return "", nil, err
+			}
+		}
+		switch field.Kind {
+		case filterText:
+			return "LOWER(" + column + ") IN ?", []interface{}{values}, nil
+		case filterNumber:
+			return column + " IN ?", []interface{}{values}, nil
+		}
+
+	case models.FilterContains:
+		if field.Kind != filterText {
+			return "", nil, newValidationError("only text fields can be filtered with %s", condition.Op)
+		}
+		value, err := filterValue(condition.Field, field, condition.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		return "LOWER(" + column + ") LIKE ?", []interface{}{"%" + value.(string) + "%"}, nil
+
+	case models.FilterEquals, models.FilterNotEquals:
+		value, err := filterValue(condition.Field, field, condition.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		switch field.Kind {
+		case filterText:
+			if condition.Op == models.FilterEquals {
+				return "LOWER(" + column + ") = ?", []interface{}{value}, nil
+			}
+			return "(" + column + " IS NULL OR LOWER(" + column + ") <> ?)", []interface{}{value}, nil
+		case filterNumber:
+			if condition.Op == models.FilterEquals {
+				return column + " = ?", []interface{}{value}, nil
+			}
+			return "(" + column + " IS NULL OR " + column + " <> ?)", []interface{}{value}, nil
+		case filterDate:
+			// Dates match the whole day
+			day := value.(time.Time)
+			if condition.Op == models.FilterEquals {
+				return "(" + column + " >= ? AND " + column + " < ?)", []interface{}{day, day.AddDate(0, 0, 1)}, nil
+			}
+			return "(" + column + " IS NULL OR " + column + " < ? OR " + column + " >= ?)", []interface{}{day, day.AddDate(0, 0, 1)}, nil
+		}
+
+	case models.FilterGreater, models.FilterGreaterOrEq, models.FilterLess, models.FilterLessOrEq:
+		if field.Kind == filterText {
+			return "", nil, newValidationError("text fields cannot be filtered with %s", condition.Op)
+		}
+		value, err := filterValue(condition.Field, field, condition.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		comparison := map[string]string{
+			models.FilterGreater:     ">",
+			models.FilterGreaterOrEq: ">=",
+			models.FilterLess:        "<",
+			models.FilterLessOrEq:    "<=",
+		}[condition.Op]
+		if field.Kind == filterDate {
+			// Dates cover the whole day, so after a day starts at the next one
+			day := value.(time.Time)
+			switch condition.Op {
+			case models.FilterGreater:
+				return column + " >= ?", []interface{}{day.AddDate(0, 0, 1)}, nil
+			case models.FilterLessOrEq:
+				return column + " < ?", []interface{}{day.AddDate(0, 0, 1)}, nil
+			}
+		}
+		return column + " " + comparison + " ?", []interface{}{value}, nil
+	}
+	return "", nil, newValidationError("invalid filter operator for %s: %s", condition.Field, condition.Op)
+}
+
+// filterValue checks a single condition value against the kind of the field.
+// Text comes back lowercase, numbers as float64 and dates as the start of the day.
+func filterValue(name string, field filterField, value interface{}) (interface{}, error) {
+	switch field.Kind {
+	case filterText:
+		text, ok := value.(string)
+		if !ok {
+			return nil, newValidationError("%s takes text values", name)
+		}
+		if check, ok := filterValueChecks[name]; ok && !check(strings.ToLower(text)) {
+			return nil, newValidationError("invalid %s: %s", name, text)
+		}
+		return strings.ToLower(text), nil
+	case filterNumber:
+		switch number := value.(type) {
+		case float64:
+			return number, nil
+		case int:
+			return float64(number), nil
+		}
+		return nil, newValidationError("%s takes numbers", name)
+	case filterDate:
+		text, ok := value.(string)
+		if !ok {
+			return nil, newValidationError("%s takes dates as YYYY-MM-DD", name)
+		}
+		day, err := time.Parse("2006-01-02", text)
+		if err != nil {
+			return nil, newValidationError("invalid %s: %s, expected YYYY-MM-DD", name, text)
+		}
+		return day, nil
+	}
+	return nil, newValidationError("%s cannot be filtered", name)
+}
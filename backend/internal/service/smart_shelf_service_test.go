@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// parseShelfFilter decodes a filter the way it arrives from the API
+func parseShelfFilter(t *testing.T, raw string) models.ShelfFilter {
+	var filter models.ShelfFilter
+	assert.NoError(t, json.Unmarshal([]byte(raw), &filter))
+	return filter
+}
+
+func TestSmartShelves(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewSmartShelfService(db)
+	fields := NewCustomFieldService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	finished := time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+	books := []models.Book{
+		{Title: "The Way of Kings", Author: "Brandon Sanderson", Genre: "Fantasy", PageCount: 1007, Rating: 5, Status: models.StatusWantToRead},
+		{Title: "The Name of the Wind", Author: "Patrick Rothfuss", Genre: "fantasy", PageCount: 662, Rating: 4, Status: models.StatusReading},
+		{Title: "The Hobbit", Author: "J.R.R. Tolkien", Genre: "Fantasy", PageCount: 310, Rating: 5, Status: models.StatusWantToRead},
+		{Title: "Mistborn", Author: "Brandon Sanderson", Genre: "Fantasy", PageCount: 541, Rating: 3, Status: models.StatusWantToRead},
+		{Title: "Dune", Author: "Frank Herbert", Genre: "Science Fiction", PageCount: 412, Rating: 5, Status: models.StatusFinished, FinishedAt: &finished},
+	}
+	for i := range books {
+		books[i].UserID = user.ID
+		assert.NoError(t, db.Create(&books[i]).Error)
+	}
+
+	// Unread fantasy over 400 pages, rated 4+
+	unread := parseShelfFilter(t, `{
+		"conditions": [
+			{"field": "status", "op": "ne", "value": "finished"},
+			{"field": "genre", "op": "eq", "value": "FANTASY"},
+			{"field": "page_count", "op": "gt", "value": 400},
+			{"field": "rating", "op": "gte", "value": 4}
+		],
+		"sort": "page_count",
+		"order": "DESC"
+	}`)
+	shelf, err := service.CreateSmartShelf(ctx, user.Auth0ID, " Big fantasy ", unread)
+	assert.NoError(t, err)
+	assert.Equal(t, "Big fantasy", shelf.Name)
+	assert.Equal(t, "desc", shelf.Filter.Order)
+	assert.Equal(t, int64(2), shelf.BookCount)
+
+	_, err = service.CreateSmartShelf(ctx, user.Auth0ID, "big FANTASY", unread)
+	assert.ErrorIs(t, err, ErrDuplicateSmartShelf)
+
+	shelfID := fmt.Sprintf("%d", shelf.ID)
+	page, err := service.EvaluateSmartShelf(ctx, user.Auth0ID, shelfID, BookQuery{})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Books, 2) {
+		assert.Equal(t, "The Way of Kings", page.Books[0].Title)
+		assert.Equal(t, "The Name of the Wind", page.Books[1].Title)
+	}
+
+	// The shelf follows the collection as it changes
+	assert.NoError(t, db.Model(&books[3]).Update("rating", 4.5).Error)
+	page, err = service.EvaluateSmartShelf(ctx, user.Auth0ID, shelfID, BookQuery{PageSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Len(t, page.Books, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// Lists, text search, dates and exists
+	other, err := service.CreateSmartShelf(ctx, user.Auth0ID, "Sanderson and Tolkien", parseShelfFilter(t, `{"conditions": [
+		{"field": "author", "op": "in", "value": ["brandon sanderson", "J.R.R. Tolkien"]},
+		{"field": "title", "op": "contains", "value": "THE"}
+	]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), other.BookCount)
+	march, err := service.CreateSmartShelf(ctx, user.Auth0ID, "Finished in March", parseShelfFilter(t, `{"conditions": [
+		{"field": "finished_at", "op": "eq", "value": "2024-03-10"},
+		{"field": "started_at", "op": "exists", "value": false}
+	]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), march.BookCount)
+
+	invalid := map[string]string{
+		"no conditions":        `{"conditions": []}`,
+		"unknown field":        `{"conditions": [{"field": "shelf_life", "op": "eq", "value": "x"}]}`,
+		"derived field":        `{"conditions": [{"field": "on_loan", "op": "eq", "value": true}]}`,
+		"owner field":          `{"conditions": [{"field": "user_id", "op": "eq", "value": 1}]}`,
+		"bad operator":         `{"conditions": [{"field": "rating", "op": "like", "value": 4}]}`,
+		"text range":           `{"conditions": [{"field": "title", "op": "gt", "value": "M"}]}`,
+		"number as text":       `{"conditions": [{"field": "page_count", "op": "gt", "value": "400"}]}`,
+		"bad status":           `{"conditions": [{"field": "status", "op": "eq", "value": "skimmed"}]}`,
+		"bad date":             `{"conditions": [{"field": "finished_at", "op": "gte", "value": "March"}]}`,
+		"empty list":           `{"conditions": [{"field": "genre", "op": "in", "value": []}]}`,
+		"bad sort":             `{"conditions": [{"field": "rating", "op": "gte", "value": 4}], "sort": "isbn"}`,
+		"unknown custom field": `{"conditions": [{"field": "custom_fields.signed", "op": "eq", "value": true}]}`,
+	}
+	for name, raw := range invalid {
+		_, err := service.CreateSmartShelf(ctx, user.Auth0ID, name, parseShelfFilter(t, raw))
+		assert.True(t, IsValidationError(err), name)
+	}
+
+	// Custom fields can be filtered on and can't be deleted while a shelf uses them
+	signed, err := fields.CreateCustomField(ctx, user.Auth0ID, models.CustomField{Name: "Signed", Type: models.CustomFieldBool})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Model(&books[2]).Update("custom_fields", models.CustomFieldValues{"signed": true}).Error)
+	signedShelf, err := service.CreateSmartShelf(ctx, user.Auth0ID, "Signed", parseShelfFilter(t, `{"conditions": [{"field": "custom_fields.signed", "op": "eq", "value": true}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), signedShelf.BookCount)
+	err = fields.DeleteCustomField(ctx, user.Auth0ID, fmt.Sprintf("%d", signed.ID))
+	assert.True(t, IsValidationError(err))
+
+	shelves, err := service.ListSmartShelves(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	if assert.Len(t, shelves, 4) {
+		assert.Equal(t, "Big fantasy", shelves[0].Name)
+		assert.Equal(t, int64(3), shelves[0].BookCount)
+	}
+
+	assert.NoError(t, service.DeleteSmartShelf(ctx, user.Auth0ID, fmt.Sprintf("%d", signedShelf.ID)))
+	assert.NoError(t, fields.DeleteCustomField(ctx, user.Auth0ID, fmt.Sprintf("%d", signed.ID)))
+	_, err = service.EvaluateSmartShelf(ctx, user.Auth0ID, fmt.Sprintf("%d", signedShelf.ID), BookQuery{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+)
+
+// storyGraphStatuses maps StoryGraph read statuses onto reading statuses
+var storyGraphStatuses = map[string]string{
+	"read":              models.StatusFinished,
+	"currently-reading": models.StatusReading,
+	"to-read":           models.StatusWantToRead,
+	"did-not-finish":    models.StatusDidNotFinish,
+	"paused":            models.StatusPaused,
+}
+
+// storyGraphImporter reads a StoryGraph CSV export
+type storyGraphImporter struct{}
+
+func (storyGraphImporter) Format() string {
+	return "storygraph"
+}
+
+func (storyGraphImporter) Detect(sample []byte) bool {
+	return hasColumns(headerColumns(sample, ","), "Title", "Authors", "Read Status", "Star Rating")
+}
+
+func (storyGraphImporter) Parse(r io.Reader) ([]ImportRow, error) {
+	table, err := readCSVTable(r, ',')
+	if err != nil {
+		return nil, err
+	}
+	if !table.has("Title") || !table.has("Authors") {
+		return nil, newValidationError("not a StoryGraph export: Title and Authors columns are required")
+	}
+
+	rows := make([]ImportRow, 0, len(table.records))
+	for i, record := range table.records {
+		row := ImportRow{Line: i + 2}
+		row.Book, row.Err = storyGraphRecordToBook(table, record)
+
+		// Moods are kept as tags alongside the user's own tags
+		row.Tags = append(row.Tags, splitImportList(table.get(record, "Moods"), ",")...)
+		row.Tags = append(row.Tags, splitImportList(table.get(record, "Tags"), ",")...)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func storyGraphRecordToBook(table *csvTable, record []string) (models.Book, error) {
+	book := models.Book{
+		Title:  table.get(record, "Title"),
+		Author: table.get(record, "Authors"),
+	}
+
+	// ISBN/UID holds a StoryGraph id for books without an ISBN
+	if isbn := NormalizeISBN(table.get(record, "ISBN/UID")); isISBN(isbn) {
+		book.ISBN = isbn
+	}
+
+	book.Format = importFormat(table.get(record, "Format"))
+
+	var err error
+	if book.Rating, err = parseImportFloat(table.get(record, "Star Rating")); err != nil {
+		return book, fmt.Errorf("Star Rating: %v", err)
+	}
+	added, err := parseImportDate(table.get(record, "Date Added"))
+	if err != nil {
+		return book, fmt.Errorf("Date Added: %v", err)
+	}
+	if added != nil {
+		book.CreatedAt = *added
+	}
+
+	// Dates Read holds every read as start-end ranges, the last one being the most recent
+	if ranges := splitImportList(table.get(record, "Dates Read"), ","); len(ranges) > 0 {
+		dates := strings.SplitN(ranges[len(ranges)-1], "-", 2)
+		if book.StartedAt, err = parseImportDate(strings.TrimSpace(dates[0])); err != nil {
+			return book, fmt.Errorf("Dates Read: %v", err)
+		}
+		if len(dates) == 2 {
+			if book.FinishedAt, err = parseImportDate(strings.TrimSpace(dates[1])); err != nil {
+				return book, fmt.Errorf("Dates Read: %v", err)
+			}
+		}
+	}
+	if book.FinishedAt == nil {
+		if book.FinishedAt, err = parseImportDate(table.get(record, "Last Date Read")); err != nil {
+			return book, fmt.Errorf("Last Date Read: %v", err)
+		}
+	}
+
+	book.Status = models.StatusWantToRead
+	if status, ok := storyGraphStatuses[strings.ToLower(table.get(record, "Read Status"))]; ok {
+		book.Status = status
+	}
+	if book.Status != models.StatusFinished {
+		book.FinishedAt = nil
+	}
+	if book.Status == models.StatusWantToRead {
+		book.StartedAt = nil
+	}
+	return book, nil
+}
+
+// isISBN reports whether a normalized value looks like an ISBN-10 or ISBN-13
+func isISBN(value string) bool {
+	if len(value) != 10 && len(value) != 13 {
+		return false
+	}
+	for i, c := range value {
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		if c == 'X' && i == len(value)-1 && len(value) == 10 {
+			continue
+		}
+		return false
+	}
+	return true
+}
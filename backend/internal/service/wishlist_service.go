@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrAlreadyOwned is returned when wishing for or buying a book that is already in the collection
+var ErrAlreadyOwned = errors.New("this book is already in your collection")
+
+// ErrPriceLookupFailed is returned when the price catalogue can't be reached
+var ErrPriceLookupFailed = errors.New("price lookup failed")
+
+// currencyCode matches ISO 4217 currency codes such as USD
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type WishlistService interface {
+	ListWishlist(ctx context.Context, userID string) ([]models.WishlistItem, error)
+	AddWishlistItem(ctx context.Context, userID string, item models.WishlistItem) (*models.WishlistItem, error)
+	UpdateWishlistItem(ctx context.Context, userID string, itemID string, item models.WishlistItem) (*models.WishlistItem, error)
+	DeleteWishlistItem(ctx context.Context, userID string, itemID string) error
+	RefreshPrice(ctx context.Context, userID string, itemID string) (*models.WishlistItem, error)
+	PurchaseItem(ctx context.Context, userID string, itemID string, purchase Purchase) (*models.Book, error)
+	CollectionValue(ctx context.Context, userID string) (*CollectionValue, error)
+}
+
+// Purchase is how a wishlist item was bought. Format and Status override those
+// of the book added to the collection when given.
+type Purchase struct {
+	Price       *float64
+	Currency    string
+	PurchasedAt *time.Time
+	Store       string
+	Format      string
+	Status      string
+}
+
+// CollectionValue sums what the user has paid for their books and what the
+// wishlist would cost at its target prices, per currency
+type CollectionValue struct {
+	Books    int64           `json:"books"`
+	Priced   int64           `json:"priced"`
+	Totals   []CurrencyTotal `json:"totals"`
+	Wishlist []CurrencyTotal `json:"wishlist"`
+}
+
+type CurrencyTotal struct {
+	Currency string  `json:"currency"`
+	Count    int64   `json:"count"`
+	Total    float64 `json:"total"`
+}
+
+type wishlistService struct {
+	DB     *gorm.DB
+	Prices PriceProvider
+}
+
+func NewWishlistService(db *gorm.DB) WishlistService {
+	return &wishlistService{
+		DB:     db,
+		Prices: NewGoogleBooksProvider(envOrDefault("GOOGLE_BOOKS_API_URL", DefaultGoogleBooksURL), os.Getenv("GOOGLE_BOOKS_API_KEY")),
+	}
+}
+
+// findUserItem loads a wishlist item owned by the user
+func (s *wishlistService) findUserItem(auth0ID string, itemID string) (*models.User, *models.WishlistItem, error) {
+	user, err := findUserByAuth0ID(s.DB, auth0ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var item models.WishlistItem
+	if err := s.DB.Where("id = ? AND user_id = ?", itemID, user.ID).First(&item).Error; err != nil {
+		return nil, nil, err
+	}
+	return user, &item, nil
+}
+
+// ListWishlist returns the user's wishlist, newest first
+func (s *wishlistService) ListWishlist(ctx context.Context, userID string) ([]models.WishlistItem, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []models.WishlistItem{}
+	if err := s.DB.Where("user_id = ?", user.ID).Order("created_at desc, id desc").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch wishlist: %v", err)
+	}
+	for i := range items {
+		items[i].AtTargetPrice = items[i].IsAtTargetPrice()
+	}
+	return items, nil
+}
+
+// AddWishlistItem puts a book on the wishlist unless it is already in the collection
+func (s *wishlistService) AddWishlistItem(ctx context.Context, userID string, item models.WishlistItem) (*models.WishlistItem, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if item, err = validateWishlistItem(item); err != nil {
+		return nil, err
+	}
+	if err := s.checkNotOwned(ctx, userID, item); err != nil {
+		return nil, err
+	}
+
+	item.ID = 0
+	item.UserID = user.ID
+	item.CurrentPrice = nil
+	item.CurrentCurrency = ""
+	item.BuyLink = ""
+	item.PriceCheckedAt = nil
+	if err := s.DB.Create(&item).Error; err != nil {
+		return nil, fmt.Errorf("failed to create wishlist item: %v", err)
+	}
+	return &item, nil
+}
+
+// UpdateWishlistItem replaces the details and target price of a wishlist item
+func (s *wishlistService) UpdateWishlistItem(ctx context.Context, userID string, itemID string, item models.WishlistItem) (*models.WishlistItem, error) {
+	_, existing, err := s.findUserItem(userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if item, err = validateWishlistItem(item); err != nil {
+		return nil, err
+	}
+
+	existing.Title = item.Title
+	existing.Author = item.Author
+	existing.ISBN = item.ISBN
+	existing.CoverImage = item.CoverImage
+	existing.Format = item.Format
+	existing.TargetPrice = item.TargetPrice
+	existing.Currency = item.Currency
+
+	if err := s.DB.Save(existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to update wishlist item: %v", err)
+	}
+	existing.AtTargetPrice = existing.IsAtTargetPrice()
+	return existing, nil
+}
+
+// DeleteWishlistItem removes a book from the wishlist
+func (s *wishlistService) DeleteWishlistItem(ctx context.Context, userID string, itemID string) error {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return err
+	}
+
+	result := s.DB.Where("id = ? AND user_id = ?", itemID, user.ID).Delete(&models.WishlistItem{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete wishlist item: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RefreshPrice looks up what the book currently sells for, by ISBN when the
+// item has one and by title and author otherwise
+func (s *wishlistService) RefreshPrice(ctx context.Context, userID string, itemID string) (*models.WishlistItem, error) {
+	_, item, err := s.findUserItem(userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := MetadataQuery{ISBN: item.ISBN}
+	if query.ISBN == "" {
+		query.Query = fmt.Sprintf("intitle:%s inauthor:%s", item.Title, item.Author)
+	}
+	price, err := s.Prices.LookupPrice(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPriceLookupFailed, err)
+	}
+
+	now := time.Now()
+	item.PriceCheckedAt = &now
+	item.CurrentPrice = nil
+	item.CurrentCurrency = ""
+	item.BuyLink = ""
+	if price != nil {
+		item.CurrentPrice = &price.Amount
+		item.CurrentCurrency = price.Currency
+		item.BuyLink = price.BuyLink
+	}
+
+	if err := s.DB.Model(item).Updates(map[string]interface{}{
+		"current_price":    item.CurrentPrice,
+		"current_currency": item.CurrentCurrency,
+		"buy_link":         item.BuyLink,
+		"price_checked_at": item.PriceCheckedAt,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to save price: %v", err)
+	}
+	item.AtTargetPrice = item.IsAtTargetPrice()
+	return item, nil
+}
+
+// PurchaseItem moves a wishlist item into the collection as a book, recording
+// what was paid for it, and takes it off the wishlist
+func (s *wishlistService) PurchaseItem(ctx context.Context, userID string, itemID string, purchase Purchase) (*models.Book, error) {
+	user, item, err := s.findUserItem(userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNotOwned(ctx, userID, *item); err != nil {
+		return nil, err
+	}
+
+	book := models.Book{
+		Title:            item.Title,
+		Author:           item.Author,
+		ISBN:             item.ISBN,
+		CoverImage:       item.CoverImage,
+		Format:           item.Format,
+		PurchasePrice:    purchase.Price,
+		PurchaseCurrency: purchase.Currency,
+		PurchasedAt:      purchase.PurchasedAt,
+		PurchaseStore:    purchase.Store,
+	}
+	if purchase.Format != "" {
+		book.Format = purchase.Format
+	}
+	if book.PurchasedAt == nil {
+		now := time.Now()
+		book.PurchasedAt = &now
+	}
+	if purchase.Status != "" {
+		if err := book.TransitionTo(purchase.Status, time.Now(), nil); err != nil {
+			return nil, newValidationError("%v", err)
+		}
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := createBook(tx, user, &book); err != nil {
+			return err
+		}
+		if err := NewGoalService(tx).RecomputeGoalHistory(ctx, userID, finishedOn(book.FinishedAt)); err != nil {
+			return err
+		}
+		if err := tx.Delete(item).Error; err != nil {
+			return fmt.Errorf("failed to remove wishlist item: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// CollectionValue totals the purchase prices of the user's books and the target
+// prices of their wishlist
+func (s *wishlistService) CollectionValue(ctx context.Context, userID string) (*CollectionValue, error) {
+	user, err := findUserByAuth0ID(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	value := &CollectionValue{Totals: []CurrencyTotal{}, Wishlist: []CurrencyTotal{}}
+	if err := s.DB.Model(&models.Book{}).Where("user_id = ?", user.ID).Count(&value.Books).Error; err != nil {
+		return nil, fmt.Errorf("failed to count books: %v", err)
+	}
+
+	if err := s.DB.Model(&models.Book{}).
+		Select("purchase_currency AS currency, COUNT(*) AS count, SUM(purchase_price) AS total").
+		Where("user_id = ? AND purchase_price IS NOT NULL", user.ID).
+		Group("purchase_currency").
+		Order("purchase_currency asc").
+		Scan(&value.Totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to total purchase prices: %v", err)
+	}
+	for i := range value.Totals {
+		value.Priced += value.Totals[i].Count
+		value.Totals[i].Total = roundPrice(value.Totals[i].Total)
+	}
+
+	if err := s.DB.Model(&models.WishlistItem{}).
+		Select("currency, COUNT(*) AS count, SUM(target_price) AS total").
+		Where("user_id = ? AND target_price IS NOT NULL", user.ID).
+		Group("currency").
+		Order("currency asc").
+		Scan(&value.Wishlist).Error; err != nil {
+		return nil, fmt.Errorf("failed to total wishlist prices: %v", err)
+	}
+	for i := range value.Wishlist {
+		value.Wishlist[i].Total = roundPrice(value.Wishlist[i].Total)
+	}
+	return value, nil
+}
+
+// checkNotOwned returns ErrAlreadyOwned when the item matches a book still in
+// the collection. Deleted books don't count, as they can be bought again.
+func (s *wishlistService) checkNotOwned(ctx context.Context, userID string, item models.WishlistItem) error {
+	existing, err := NewDuplicateService(s.DB).FindDuplicate(ctx, userID, models.Book{
+		Title:  item.Title,
+		Author: item.Author,
+		ISBN:   item.ISBN,
+	})
+	if err != nil {
+		return err
+	}
+	if existing != nil && !existing.DeletedAt.Valid {
+		return ErrAlreadyOwned
+	}
+	return nil
+}
+
+// validateWishlistItem trims the item's details and checks its target price
+func validateWishlistItem(item models.WishlistItem) (models.WishlistItem, error) {
+	item.Title = strings.TrimSpace(item.Title)
+	item.Author = strings.TrimSpace(item.Author)
+	if item.Title == "" || item.Author == "" {
+		return item, newValidationError("title and author are required")
+	}
+
+	item.ISBN = NormalizeISBN(item.ISBN)
+	if item.ISBN != "" && !isISBN(item.ISBN) {
+		return item, newValidationError("invalid ISBN: %s", item.ISBN)
+	}
+	item.Format = strings.ToLower(strings.TrimSpace(item.Format))
+	if !models.IsValidFormat(item.Format) {
+		return item, newValidationError("invalid format: %s", item.Format)
+	}
+
+	var err error
+	if item.Currency, err = normalizePrice(item.TargetPrice, item.Currency); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+// normalizePurchase checks the purchase details of a book
+func normalizePurchase(book *models.Book) error {
+	book.PurchaseStore = strings.TrimSpace(book.PurchaseStore)
+
+	var err error
+	if book.PurchaseCurrency, err = normalizePrice(book.PurchasePrice, book.PurchaseCurrency); err != nil {
+		return err
+	}
+	if book.PurchasedAt != nil && book.PurchasedAt.After(time.Now().Add(24*time.Hour)) {
+		return newValidationError("purchase date cannot be in the future")
+	}
+	return nil
+}
+
+// normalizePrice checks a price isn't negative and returns its currency code,
+// which defaults to DefaultCurrency when a price is given without one
+func normalizePrice(price *float64, currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if price == nil {
+		return currency, nil
+	}
+	if *price < 0 || math.IsNaN(*price) || math.IsInf(*price, 0) {
+		return currency, newValidationError("price cannot be negative")
+	}
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if !currencyCode.MatchString(currency) {
+		return currency, newValidationError("currency must be an ISO 4217 code such as USD")
+	}
+	return currency, nil
+}
+
+func roundPrice(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/RyanFloresTT/Book-Collection-Backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubPriceProvider struct {
+	price *BookPrice
+	err   error
+}
+
+func (p stubPriceProvider) LookupPrice(ctx context.Context, query MetadataQuery) (*BookPrice, error) {
+	return p.price, p.err
+}
+
+func TestWishlistLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	service := &wishlistService{DB: db, Prices: stubPriceProvider{price: &BookPrice{Amount: 9.99, Currency: "USD", BuyLink: "https://example.com/buy"}}}
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	owned := models.Book{Title: "Dune", Author: "Frank Herbert", UserID: user.ID}
+	assert.NoError(t, db.Create(&owned).Error)
+
+	_, err := service.AddWishlistItem(ctx, user.Auth0ID, models.WishlistItem{Title: "dune", Author: "Frank Herbert"})
+	assert.ErrorIs(t, err, ErrAlreadyOwned)
+
+	negative := -5.0
+	_, err = service.AddWishlistItem(ctx, user.Auth0ID, models.WishlistItem{Title: "Piranesi", Author: "Susanna Clarke", TargetPrice: &negative})
+	assert.True(t, IsValidationError(err))
+
+	target := 12.0
+	_, err = service.AddWishlistItem(ctx, user.Auth0ID, models.WishlistItem{Title: "Piranesi", Author: "Susanna Clarke", TargetPrice: &target, Currency: "dollars"})
+	assert.True(t, IsValidationError(err))
+
+	item, err := service.AddWishlistItem(ctx, user.Auth0ID, models.WishlistItem{Title: " Piranesi ", Author: "Susanna Clarke", ISBN: "978-1-63557-599-6", TargetPrice: &target})
+	assert.NoError(t, err)
+	assert.Equal(t, "Piranesi", item.Title)
+	assert.Equal(t, "9781635575996", item.ISBN)
+	assert.Equal(t, models.DefaultCurrency, item.Currency)
+	itemID := fmt.Sprintf("%d", item.ID)
+
+	refreshed, err := service.RefreshPrice(ctx, user.Auth0ID, itemID)
+	assert.NoError(t, err)
+	assert.Equal(t, 9.99, *refreshed.CurrentPrice)
+	assert.Equal(t, "https://example.com/buy", refreshed.BuyLink)
+	assert.True(t, refreshed.AtTargetPrice)
+
+	lower := 5.0
+	updated, err := service.UpdateWishlistItem(ctx, user.Auth0ID, itemID, models.WishlistItem{Title: "Piranesi", Author: "Susanna Clarke", ISBN: item.ISBN, TargetPrice: &lower, Currency: "usd"})
+	assert.NoError(t, err)
+	assert.False(t, updated.AtTargetPrice)
+
+	service.Prices = stubPriceProvider{err: fmt.Errorf("unavailable")}
+	_, err = service.RefreshPrice(ctx, user.Auth0ID, itemID)
+	assert.ErrorIs(t, err, ErrPriceLookupFailed)
+
+	other, err := service.AddWishlistItem(ctx, user.Auth0ID, models.WishlistItem{Title: "Jonathan Strange & Mr Norrell", Author: "Susanna Clarke", TargetPrice: &target, Currency: "GBP"})
+	assert.NoError(t, err)
+
+	items, err := service.ListWishlist(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	price := 11.5
+	_, err = service.PurchaseItem(ctx, user.Auth0ID, itemID, Purchase{Price: &price, Format: "scroll"})
+	assert.True(t, IsValidationError(err))
+
+	book, err := service.PurchaseItem(ctx, user.Auth0ID, itemID, Purchase{Price: &price, Store: " Local Books ", Format: models.FormatHardcover})
+	assert.NoError(t, err)
+	assert.Equal(t, "Piranesi", book.Title)
+	assert.Equal(t, "9781635575996", book.ISBN13)
+	assert.Equal(t, models.FormatHardcover, book.Format)
+	assert.Equal(t, models.DefaultCurrency, book.PurchaseCurrency)
+	assert.Equal(t, "Local Books", book.PurchaseStore)
+	assert.NotNil(t, book.PurchasedAt)
+	assert.Equal(t, models.StatusWantToRead, book.Status)
+
+	items, err = service.ListWishlist(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, other.ID, items[0].ID)
+
+	cheap := 3.25
+	assert.NoError(t, db.Model(&owned).Updates(map[string]interface{}{"purchase_price": cheap, "purchase_currency": "USD"}).Error)
+
+	value, err := service.CollectionValue(ctx, user.Auth0ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), value.Books)
+	assert.Equal(t, int64(2), value.Priced)
+	assert.Equal(t, []CurrencyTotal{{Currency: "USD", Count: 2, Total: 14.75}}, value.Totals)
+	assert.Equal(t, []CurrencyTotal{{Currency: "GBP", Count: 1, Total: 12}}, value.Wishlist)
+
+	assert.NoError(t, service.DeleteWishlistItem(ctx, user.Auth0ID, fmt.Sprintf("%d", other.ID)))
+	assert.Error(t, service.DeleteWishlistItem(ctx, user.Auth0ID, fmt.Sprintf("%d", other.ID)))
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_books_status;
+ALTER TABLE books DROP COLUMN IF EXISTS stopped_page;
+ALTER TABLE books DROP COLUMN IF EXISTS stopped_at;
+ALTER TABLE books DROP COLUMN IF EXISTS status;
@@ -0,0 +1,7 @@
+ALTER TABLE books ADD COLUMN IF NOT EXISTS status VARCHAR(20);
+ALTER TABLE books ADD COLUMN IF NOT EXISTS stopped_at TIMESTAMPTZ;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS stopped_page BIGINT;
+
+-- Existing books get their status from BackfillBookStatus when the server starts
+
+CREATE INDEX IF NOT EXISTS idx_books_status ON books(status);